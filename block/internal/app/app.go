@@ -1,51 +1,142 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"time"
 
 	"github.com/connorkuljis/block-cli/internal/blocker"
+	"github.com/connorkuljis/block-cli/internal/config"
+	"github.com/connorkuljis/block-cli/internal/idle"
 	"github.com/connorkuljis/block-cli/internal/interactive"
+	"github.com/connorkuljis/block-cli/internal/notify"
+	"github.com/connorkuljis/block-cli/internal/plugins"
 	"github.com/connorkuljis/block-cli/internal/tasks"
 	"github.com/jmoiron/sqlx"
 )
 
-func Start(w io.Writer, db *sqlx.DB, currentTask tasks.Task) error {
-	blocker := blocker.NewBlocker()
-	if currentTask.BlockerEnabled == 1 {
-		n, err := blocker.Start()
-		if err != nil {
+// Start inserts a new task and runs a session for its full estimated duration. inserted, when
+// not nil, runs in the transaction that inserts the task, so whatever it records is in place
+// before the session starts. host may be nil when no plugins are loaded.
+func Start(ctx context.Context, w io.Writer, db *sqlx.DB, cfg config.Config, host *plugins.Host, currentTask *tasks.Task, inserted func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tasks.NewStore(tx).Insert(ctx, currentTask); err != nil {
+		return err
+	}
+	if inserted != nil {
+		if err := inserted(tx); err != nil {
 			return err
 		}
-		slog.Info(fmt.Sprintf("Blocker started (%d bytes written).", n))
 	}
-
-	err := tasks.InsertTask(db, &currentTask)
-	if err != nil {
+	if err := tx.Commit(); err != nil {
 		return err
 	}
 
-	totalTimeSeconds, percent := interactive.Run(w, &currentTask, blocker, db)
-	finishTime := time.Now()
+	host.Emit(ctx, plugins.EventTaskCreated, currentTask)
 
-	currentTask.SetActualDuration(totalTimeSeconds)
-	currentTask.SetCompletionPercent(percent)
-	currentTask.SetFinishTime(finishTime)
+	return runSegment(ctx, w, db, cfg, host, currentTask)
+}
+
+// Resume runs a session for the remaining planned time of an incomplete task.
+func Resume(ctx context.Context, w io.Writer, db *sqlx.DB, cfg config.Config, host *plugins.Host, currentTask *tasks.Task) error {
+	if currentTask.Completed == 1 {
+		return fmt.Errorf("Error, task %d is already completed", currentTask.TaskId)
+	}
+
+	if currentTask.RemainingSeconds() == 0 {
+		return errors.New("Error, task has no planned time remaining")
+	}
+
+	return runSegment(ctx, w, db, cfg, host, currentTask)
+}
+
+// runSegment runs one session segment for an inserted task, then accumulates the time worked onto the task.
+func runSegment(ctx context.Context, w io.Writer, db *sqlx.DB, cfg config.Config, host *plugins.Host, currentTask *tasks.Task) (err error) {
+	notifier, err := notify.NewFromConfig(cfg.Notifications)
+	if err != nil {
+		return err
+	}
 
-	err = tasks.UpdateTaskAsFinished(db, currentTask)
+	monitor, err := newIdleMonitor(cfg.Idle)
 	if err != nil {
 		return err
 	}
 
+	blocker := blocker.NewBlocker()
 	if currentTask.BlockerEnabled == 1 {
-		n, err := blocker.Stop()
-		if err != nil {
+		n, startErr := blocker.Start()
+		if startErr != nil {
+			return startErr
+		}
+		slog.Info(fmt.Sprintf("Blocker started (%d bytes written).", n))
+
+		// the hosts file is restored even when the segment fails to save.
+		defer func() {
+			n, stopErr := blocker.Stop()
+			if stopErr != nil {
+				err = errors.Join(err, stopErr)
+				return
+			}
+			slog.Info(fmt.Sprintf("Blocker stopped (%d bytes written).", n))
+		}()
+	}
+
+	remote := interactive.NewRemote(w, currentTask, blocker, db)
+	remote.DurationSeconds = int(currentTask.RemainingSeconds())
+	remote.Notifier = notifier
+	remote.Monitor = monitor
+	remote.RecordingsPath = cfg.FfmpegRecordingsPath
+	remote.AvfoundationDevice = cfg.AvfoundationDevice
+	remote.Plugins = host
+
+	host.Emit(ctx, plugins.EventSessionStart, currentTask)
+
+	totalTimeSeconds, percent := interactive.Run(remote)
+	finishTime := time.Now()
+
+	segment := tasks.Segment{
+		TaskId:          currentTask.TaskId,
+		StartedAt:       remote.StartedAt,
+		FinishedAt:      finishTime,
+		DurationSeconds: int64(totalTimeSeconds),
+	}
+
+	currentTask.AccumulateSegment(totalTimeSeconds, percent == 100.0)
+	currentTask.SetFinishTime(finishTime)
+
+	err = tasks.NewStore(db).InTx(ctx, func(store tasks.TaskStore) error {
+		if err := store.InsertSegment(ctx, &segment); err != nil {
 			return err
 		}
-		slog.Info(fmt.Sprintf("Blocker stopped (%d bytes written).", n))
+		return store.Finish(ctx, *currentTask)
+	})
+	if err != nil {
+		return err
 	}
 
+	host.Emit(ctx, plugins.EventSessionFinish, currentTask)
+
 	return nil
 }
+
+// newIdleMonitor returns nil when idle detection is disabled.
+func newIdleMonitor(cfg config.IdleConfig) (*idle.Monitor, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	provider, err := idle.NewProvider(cfg.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	return idle.NewMonitor(provider, time.Duration(cfg.ThresholdSeconds)*time.Second), nil
+}
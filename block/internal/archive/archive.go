@@ -0,0 +1,502 @@
+// Package archive exports the task database to a portable json document and merges it back in.
+//
+// Tasks are matched by their uuid and buckets by name, so importing the same archive twice,
+// or an archive exported from another machine, never duplicates history.
+package archive
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/idle"
+	"github.com/connorkuljis/block-cli/internal/notes"
+	"github.com/connorkuljis/block-cli/internal/tags"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/connorkuljis/block-cli/internal/utils"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Version is bumped whenever the archive format changes incompatibly.
+const Version = 1
+
+type Archive struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Buckets    []Bucket  `json:"buckets"`
+	Tasks      []Task    `json:"tasks"`
+}
+
+type Bucket struct {
+	UUID              string     `json:"uuid,omitempty"`
+	Name              string     `json:"name"`
+	Colour            string     `json:"colour,omitempty"`
+	WeeklyGoalSeconds *int64     `json:"weekly_goal_seconds,omitempty"`
+	ArchivedAt        *time.Time `json:"archived_at,omitempty"`
+}
+
+// Task is a task with everything recorded against it. Bucket refers to a bucket by name.
+type Task struct {
+	UUID              string     `json:"uuid"`
+	Name              string     `json:"name"`
+	Bucket            string     `json:"bucket,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	FinishedAt        *time.Time `json:"finished_at,omitempty"`
+	PlannedSeconds    int64      `json:"planned_seconds"`
+	ActualSeconds     *int64     `json:"actual_seconds,omitempty"`
+	CompletionPercent *float64   `json:"completion_percent,omitempty"`
+	Completed         bool       `json:"completed"`
+	BlockerEnabled    bool       `json:"blocker_enabled"`
+	ScreenEnabled     bool       `json:"screen_enabled"`
+	ScreenURL         string     `json:"screen_url,omitempty"`
+
+	Tags          []string       `json:"tags,omitempty"`
+	Segments      []Segment      `json:"segments,omitempty"`
+	IdleIntervals []IdleInterval `json:"idle_intervals,omitempty"`
+	Notes         []Note         `json:"notes,omitempty"`
+	Interruptions []Interruption `json:"interruptions,omitempty"`
+}
+
+type Segment struct {
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at"`
+	DurationSeconds int64     `json:"duration_seconds"`
+}
+
+type IdleInterval struct {
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	Kept      bool      `json:"kept"`
+}
+
+type Note struct {
+	Outcome     string    `json:"outcome"`
+	FocusRating *int64    `json:"focus_rating,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Interruption struct {
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Conflict is a record that exists on both sides with different values. The local copy is kept.
+type Conflict struct {
+	Kind   string // task or bucket
+	Key    string // the task uuid or bucket name.
+	Name   string
+	Fields []string // the fields that differ.
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s %s (%s): %s differ", c.Kind, c.Key, c.Name, strings.Join(c.Fields, ", "))
+}
+
+type Result struct {
+	BucketsCreated int
+	TasksCreated   int
+	TasksUnchanged int
+	Conflicts      []Conflict
+}
+
+// Export reads every bucket and task, with tags, segments, idle intervals, notes and interruptions.
+func Export(ctx context.Context, db *sqlx.DB, now time.Time) (Archive, error) {
+	a := Archive{Version: Version, ExportedAt: now, Buckets: []Bucket{}, Tasks: []Task{}}
+
+	allBuckets, err := buckets.GetAllBuckets(db)
+	if err != nil {
+		return a, err
+	}
+
+	bucketNames := make(map[int64]string)
+	for _, b := range allBuckets {
+		bucketNames[b.BucketId] = b.BucketName
+		a.Buckets = append(a.Buckets, fromBucket(b))
+	}
+
+	all, err := tasks.NewStore(db).Select(ctx, tasks.NewQuery().OrderBy("date", false))
+	if err != nil {
+		return a, err
+	}
+
+	for _, task := range all {
+		t, err := ExportTask(ctx, db, task, bucketNames[task.BucketId.Int64])
+		if err != nil {
+			return a, err
+		}
+		a.Tasks = append(a.Tasks, t)
+	}
+
+	return a, nil
+}
+
+// ExportTask reads a task with everything recorded against it.
+func ExportTask(ctx context.Context, db *sqlx.DB, task tasks.Task, bucketName string) (Task, error) {
+	t := fromTask(task, bucketName)
+	if err := loadChildren(ctx, db, task.TaskId, &t); err != nil {
+		return t, fmt.Errorf("Error exporting task %d: %w", task.TaskId, err)
+	}
+	return t, nil
+}
+
+// ExportBucket converts a bucket to its archived form.
+func ExportBucket(b buckets.Bucket) Bucket {
+	return fromBucket(b)
+}
+
+func loadChildren(ctx context.Context, db *sqlx.DB, taskId int64, t *Task) error {
+	var err error
+
+	t.Tags, err = tags.GetTagsByTaskId(db, taskId)
+	if err != nil {
+		return err
+	}
+
+	segments, err := tasks.NewStore(db).Segments(ctx, taskId)
+	if err != nil {
+		return err
+	}
+	for _, s := range segments {
+		t.Segments = append(t.Segments, Segment{StartedAt: s.StartedAt, FinishedAt: s.FinishedAt, DurationSeconds: s.DurationSeconds})
+	}
+
+	intervals, err := idle.GetIntervalsByTaskId(db, taskId)
+	if err != nil {
+		return err
+	}
+	for _, i := range intervals {
+		t.IdleIntervals = append(t.IdleIntervals, IdleInterval{StartedAt: i.StartedAt, EndedAt: i.EndedAt, Kept: i.Kept == 1})
+	}
+
+	taskNotes, err := notes.GetNotesByTaskId(db, taskId)
+	if err != nil {
+		return err
+	}
+	for _, n := range taskNotes {
+		note := Note{Outcome: n.Outcome, CreatedAt: n.CreatedAt}
+		if n.FocusRating.Valid {
+			rating := n.FocusRating.Int64
+			note.FocusRating = &rating
+		}
+		t.Notes = append(t.Notes, note)
+	}
+
+	interruptions, err := notes.GetInterruptionsByTaskId(db, taskId)
+	if err != nil {
+		return err
+	}
+	for _, i := range interruptions {
+		t.Interruptions = append(t.Interruptions, Interruption{Reason: i.Reason, CreatedAt: i.CreatedAt})
+	}
+
+	return nil
+}
+
+// Import merges an archive into db in a single transaction. New buckets and tasks are created,
+// identical ones are skipped and differing ones are reported as conflicts and left untouched.
+// A dry run reports the same result and rolls back.
+func Import(ctx context.Context, db *sqlx.DB, a Archive, dryRun bool) (Result, error) {
+	var result Result
+
+	if a.Version != Version {
+		return result, fmt.Errorf("Error, unsupported archive version %d, expected %d", a.Version, Version)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	bucketIds := make(map[string]int64)
+	for _, b := range a.Buckets {
+		id, err := importBucket(tx, b, &result)
+		if err != nil {
+			return result, fmt.Errorf("Error importing bucket '%s': %w", b.Name, err)
+		}
+		bucketIds[strings.ToLower(b.Name)] = id
+	}
+
+	for _, t := range a.Tasks {
+		if err := importTask(ctx, tx, t, bucketIds, &result); err != nil {
+			return result, fmt.Errorf("Error importing task %s: %w", t.UUID, err)
+		}
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	return result, tx.Commit()
+}
+
+func importBucket(tx *sqlx.Tx, b Bucket, result *Result) (int64, error) {
+	existing, err := buckets.FindByName(tx, b.Name)
+	if err == nil {
+		if fields := diffBucket(fromBucket(existing), b); len(fields) > 0 {
+			result.Conflicts = append(result.Conflicts, Conflict{Kind: "bucket", Key: b.Name, Name: b.Name, Fields: fields})
+		}
+		return existing.BucketId, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+
+	bucket, err := b.NewBucket(tx)
+	if err != nil {
+		return 0, err
+	}
+	if err := buckets.InsertBucket(tx, bucket); err != nil {
+		return 0, err
+	}
+	result.BucketsCreated++
+
+	return bucket.BucketId, nil
+}
+
+func importTask(ctx context.Context, tx *sqlx.Tx, t Task, bucketIds map[string]int64, result *Result) error {
+	if _, err := uuid.Parse(t.UUID); err != nil {
+		return fmt.Errorf("Error, invalid uuid: %w", err)
+	}
+
+	store := tasks.NewStore(tx)
+
+	existing, err := store.GetByUUID(ctx, t.UUID)
+	if err == nil {
+		var bucketName string
+		if existing.BucketId.Valid {
+			bucketName, err = bucketNameById(tx, existing.BucketId.Int64)
+			if err != nil {
+				return err
+			}
+		}
+
+		if fields := diffTask(fromTask(existing, bucketName), t); len(fields) > 0 {
+			result.Conflicts = append(result.Conflicts, Conflict{Kind: "task", Key: t.UUID, Name: t.Name, Fields: fields})
+		} else {
+			result.TasksUnchanged++
+		}
+		return nil
+	}
+	if !errors.Is(err, tasks.ErrNotFound) {
+		return err
+	}
+
+	if err := tags.Validate(t.Tags); err != nil {
+		return err
+	}
+
+	task := t.NewTask()
+	if t.Bucket != "" {
+		id, ok := bucketIds[strings.ToLower(t.Bucket)]
+		if !ok {
+			return fmt.Errorf("Error, bucket '%s' is not in the archive", t.Bucket)
+		}
+		task.AddBucketTag(id)
+	}
+
+	if err := store.Insert(ctx, task); err != nil {
+		return err
+	}
+	if err := store.Finish(ctx, *task); err != nil {
+		return err
+	}
+
+	if err := InsertChildren(ctx, tx, task.TaskId, t); err != nil {
+		return err
+	}
+
+	result.TasksCreated++
+
+	return nil
+}
+
+// NewBucket returns the bucket record of b, ready to insert. It keeps b's uuid unless another
+// bucket in db already has it.
+func (b Bucket) NewBucket(db sqlx.Queryer) (*buckets.Bucket, error) {
+	bucket := buckets.NewBucket(b.Name)
+	bucket.SetColour(b.Colour)
+	if b.WeeklyGoalSeconds != nil {
+		bucket.SetWeeklyGoal(time.Duration(*b.WeeklyGoalSeconds) * time.Second)
+	}
+	if b.ArchivedAt != nil {
+		bucket.ArchivedAt = sql.NullTime{Time: *b.ArchivedAt, Valid: true}
+	}
+
+	if b.UUID != "" {
+		_, err := buckets.FindByUUID(db, b.UUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			bucket.BucketUUID = b.UUID
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return bucket, nil
+}
+
+// NewTask returns the task record of t, without its bucket, ready to insert.
+func (t Task) NewTask() *tasks.Task {
+	task := tasks.NewTask(t.Name, t.PlannedSeconds, t.BlockerEnabled, t.ScreenEnabled, t.CreatedAt)
+	task.TaskUUID = t.UUID
+	task.ScreenURL = sql.NullString{String: t.ScreenURL, Valid: t.ScreenURL != ""}
+	task.Completed = utils.BoolToInt(t.Completed)
+	if t.CompletionPercent != nil {
+		task.CompletionPercent = sql.NullFloat64{Float64: *t.CompletionPercent, Valid: true}
+	}
+	if t.ActualSeconds != nil {
+		task.SetActualDuration(int(*t.ActualSeconds))
+	}
+	if t.FinishedAt != nil {
+		task.SetFinishTime(*t.FinishedAt)
+	}
+	return task
+}
+
+// InsertChildren records the tags, segments, idle intervals, notes and interruptions of t against taskId.
+func InsertChildren(ctx context.Context, tx *sqlx.Tx, taskId int64, t Task) error {
+	if err := tags.AddTags(tx, taskId, t.Tags); err != nil {
+		return err
+	}
+	for _, s := range t.Segments {
+		segment := tasks.Segment{TaskId: taskId, StartedAt: s.StartedAt, FinishedAt: s.FinishedAt, DurationSeconds: s.DurationSeconds}
+		if err := tasks.NewStore(tx).InsertSegment(ctx, &segment); err != nil {
+			return err
+		}
+	}
+	for _, i := range t.IdleIntervals {
+		interval := idle.Interval{TaskId: taskId, StartedAt: i.StartedAt, EndedAt: i.EndedAt, Kept: utils.BoolToInt(i.Kept)}
+		if err := idle.InsertInterval(tx, &interval); err != nil {
+			return err
+		}
+	}
+	for _, n := range t.Notes {
+		note := notes.Note{TaskId: taskId, Outcome: n.Outcome, CreatedAt: n.CreatedAt}
+		if n.FocusRating != nil {
+			note.FocusRating = sql.NullInt64{Int64: *n.FocusRating, Valid: true}
+		}
+		if err := notes.InsertNote(tx, &note); err != nil {
+			return err
+		}
+	}
+	for _, i := range t.Interruptions {
+		interruption := notes.Interruption{TaskId: taskId, Reason: i.Reason, CreatedAt: i.CreatedAt}
+		if err := notes.InsertInterruption(tx, &interruption); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func bucketNameById(tx *sqlx.Tx, bucketId int64) (string, error) {
+	var name string
+	err := tx.Get(&name, "SELECT bucket_name FROM Buckets WHERE bucket_id = ?", bucketId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return name, err
+}
+
+func fromBucket(b buckets.Bucket) Bucket {
+	bucket := Bucket{UUID: b.BucketUUID, Name: b.BucketName, Colour: b.Colour.String}
+	if b.WeeklyGoalSeconds.Valid {
+		goal := b.WeeklyGoalSeconds.Int64
+		bucket.WeeklyGoalSeconds = &goal
+	}
+	if b.ArchivedAt.Valid {
+		archivedAt := b.ArchivedAt.Time
+		bucket.ArchivedAt = &archivedAt
+	}
+	return bucket
+}
+
+func fromTask(task tasks.Task, bucketName string) Task {
+	t := Task{
+		UUID:           task.TaskUUID,
+		Name:           task.TaskName,
+		Bucket:         bucketName,
+		CreatedAt:      task.CreatedAt,
+		PlannedSeconds: task.EstimatedDurationSeconds,
+		Completed:      task.Completed == 1,
+		BlockerEnabled: task.BlockerEnabled == 1,
+		ScreenEnabled:  task.ScreenEnabled == 1,
+		ScreenURL:      task.ScreenURL.String,
+	}
+	if task.FinishedAt.Valid {
+		finishedAt := task.FinishedAt.Time
+		t.FinishedAt = &finishedAt
+	}
+	if task.ActualDurationSeconds.Valid {
+		actual := task.ActualDurationSeconds.Int64
+		t.ActualSeconds = &actual
+	}
+	if task.CompletionPercent.Valid {
+		percent := task.CompletionPercent.Float64
+		t.CompletionPercent = &percent
+	}
+	return t
+}
+
+func diffBucket(local, incoming Bucket) []string {
+	var fields []string
+	if local.Colour != incoming.Colour {
+		fields = append(fields, "colour")
+	}
+	if !equalPtr(local.WeeklyGoalSeconds, incoming.WeeklyGoalSeconds) {
+		fields = append(fields, "weekly_goal_seconds")
+	}
+	if !equalTimePtr(local.ArchivedAt, incoming.ArchivedAt) {
+		fields = append(fields, "archived_at")
+	}
+	return fields
+}
+
+// diffTask compares the task record itself. Child records are only imported alongside new tasks.
+func diffTask(local, incoming Task) []string {
+	var fields []string
+	if local.Name != incoming.Name {
+		fields = append(fields, "name")
+	}
+	if !strings.EqualFold(local.Bucket, incoming.Bucket) {
+		fields = append(fields, "bucket")
+	}
+	if !local.CreatedAt.Equal(incoming.CreatedAt) {
+		fields = append(fields, "created_at")
+	}
+	if !equalTimePtr(local.FinishedAt, incoming.FinishedAt) {
+		fields = append(fields, "finished_at")
+	}
+	if local.PlannedSeconds != incoming.PlannedSeconds {
+		fields = append(fields, "planned_seconds")
+	}
+	if !equalPtr(local.ActualSeconds, incoming.ActualSeconds) {
+		fields = append(fields, "actual_seconds")
+	}
+	if !equalPtr(local.CompletionPercent, incoming.CompletionPercent) {
+		fields = append(fields, "completion_percent")
+	}
+	if local.Completed != incoming.Completed {
+		fields = append(fields, "completed")
+	}
+	return fields
+}
+
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalTimePtr(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
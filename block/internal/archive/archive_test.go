@@ -0,0 +1,183 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/db/dbtest"
+	"github.com/connorkuljis/block-cli/internal/notes"
+	"github.com/connorkuljis/block-cli/internal/tags"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/jmoiron/sqlx"
+)
+
+// seed creates a bucket and a finished, tagged task with a note and a segment.
+func seed(t *testing.T, conn *sqlx.DB) tasks.Task {
+	t.Helper()
+
+	bucket := buckets.NewBucket("work")
+	bucket.SetColour("#3b82f6")
+	if err := buckets.InsertBucket(conn, bucket); err != nil {
+		t.Fatal(err)
+	}
+
+	createdAt := time.Date(2024, 3, 4, 9, 0, 0, 0, time.Local)
+	task := tasks.NewTask("write report", 1500, true, false, createdAt)
+	task.AddBucketTag(bucket.BucketId)
+	if err := tasks.NewStore(conn).Insert(context.Background(), task); err != nil {
+		t.Fatal(err)
+	}
+
+	task.AccumulateSegment(1500, true)
+	task.SetFinishTime(createdAt.Add(25 * time.Minute))
+	if err := tasks.NewStore(conn).Finish(context.Background(), *task); err != nil {
+		t.Fatal(err)
+	}
+
+	segment := tasks.Segment{TaskId: task.TaskId, StartedAt: createdAt, FinishedAt: createdAt.Add(25 * time.Minute), DurationSeconds: 1500}
+	if err := tasks.NewStore(conn).InsertSegment(context.Background(), &segment); err != nil {
+		t.Fatal(err)
+	}
+	if err := notes.InsertNote(conn, notes.NewNote(task.TaskId, "first draft done", 4, createdAt.Add(25*time.Minute))); err != nil {
+		t.Fatal(err)
+	}
+	if err := tags.TagTask(conn, task.TaskId, []string{"writing"}); err != nil {
+		t.Fatal(err)
+	}
+
+	return *task
+}
+
+// roundTrip exports src and decodes it again, as `block export | block import -` would.
+func roundTrip(t *testing.T, src *sqlx.DB) Archive {
+	t.Helper()
+
+	exported, err := Export(context.Background(), src, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var a Archive
+	if err := json.Unmarshal(b, &a); err != nil {
+		t.Fatal(err)
+	}
+
+	return a
+}
+
+func TestImportIsIdempotent(t *testing.T) {
+	src := dbtest.Open(t)
+	dst := dbtest.Open(t)
+	original := seed(t, src)
+
+	a := roundTrip(t, src)
+
+	result, err := Import(context.Background(), dst, a, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TasksCreated != 1 || result.BucketsCreated != 1 || len(result.Conflicts) != 0 {
+		t.Fatalf("Expected 1 task and 1 bucket created, got: %+v", result)
+	}
+
+	imported, err := tasks.NewStore(dst).First(context.Background(), tasks.NewQuery())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.TaskUUID != original.TaskUUID || imported.ActualDurationSeconds.Int64 != 1500 || imported.Completed != 1 {
+		t.Errorf("Expected imported task to match original, got: %+v", imported)
+	}
+
+	taskTags, err := tags.GetTagsByTaskId(dst, imported.TaskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskNotes, err := notes.GetNotesByTaskId(dst, imported.TaskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	segments, err := tasks.NewStore(dst).Segments(context.Background(), imported.TaskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(taskTags) != 1 || len(taskNotes) != 1 || len(segments) != 1 {
+		t.Errorf("Expected tags, notes and segments to be imported, got: %d %d %d", len(taskTags), len(taskNotes), len(segments))
+	}
+
+	// importing the same archive again changes nothing.
+	result, err = Import(context.Background(), dst, a, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TasksCreated != 0 || result.BucketsCreated != 0 || result.TasksUnchanged != 1 || len(result.Conflicts) != 0 {
+		t.Errorf("Expected second import to be a no-op, got: %+v", result)
+	}
+
+	var count int
+	if err := dst.Get(&count, "SELECT COUNT(*) FROM Tasks"); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 task after importing twice, got: %d", count)
+	}
+}
+
+func TestImportReportsConflicts(t *testing.T) {
+	src := dbtest.Open(t)
+	dst := dbtest.Open(t)
+	seed(t, src)
+
+	a := roundTrip(t, src)
+	if _, err := Import(context.Background(), dst, a, false); err != nil {
+		t.Fatal(err)
+	}
+
+	a.Tasks[0].Name = "write the report"
+	a.Buckets[0].Colour = "#ff0000"
+
+	result, err := Import(context.Background(), dst, a, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Conflicts) != 2 {
+		t.Fatalf("Expected a task and a bucket conflict, got: %+v", result.Conflicts)
+	}
+
+	local, err := tasks.NewStore(dst).First(context.Background(), tasks.NewQuery())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if local.TaskName != "write report" {
+		t.Errorf("Expected the local copy to be kept, got: %q", local.TaskName)
+	}
+}
+
+func TestImportDryRunWritesNothing(t *testing.T) {
+	src := dbtest.Open(t)
+	dst := dbtest.Open(t)
+	seed(t, src)
+
+	result, err := Import(context.Background(), dst, roundTrip(t, src), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TasksCreated != 1 {
+		t.Errorf("Expected dry run to report 1 task, got: %+v", result)
+	}
+
+	var count int
+	if err := dst.Get(&count, "SELECT COUNT(*) FROM Tasks"); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("Expected dry run to write nothing, got: %d tasks", count)
+	}
+}
@@ -1,26 +1,105 @@
 package buckets
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/calendar"
 	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
 
-var BucketsSchema = `
-	CREATE TABLE IF NOT EXISTS Buckets (
-		bucket_id INTEGER PRIMARY KEY AUTOINCREMENT,
-		bucket_name string
-	);
-`
-
 type Bucket struct {
-	BucketId   int64  `db:"bucket_id"`
-	BucketName string `db:"bucket_name"`
-	Tasks      []tasks.Task
+	BucketId          int64          `db:"bucket_id"`
+	BucketName        string         `db:"bucket_name"`
+	BucketUUID        string         `db:"bucket_uuid"`
+	Colour            sql.NullString `db:"colour"`
+	WeeklyGoalSeconds sql.NullInt64  `db:"weekly_goal_seconds"`
+	ArchivedAt        sql.NullTime   `db:"archived_at"`
+	UpdatedAt         sql.NullTime   `db:"updated_at"`
+	Tasks             []tasks.Task
+}
+
+// Progress is the focus time logged against a bucket's weekly goal.
+type Progress struct {
+	Bucket       Bucket
+	FocusSeconds int64
+}
+
+// Percent returns the share of the weekly goal reached, or 0 if the bucket has no goal.
+func (p Progress) Percent() float64 {
+	if !p.Bucket.WeeklyGoalSeconds.Valid || p.Bucket.WeeklyGoalSeconds.Int64 == 0 {
+		return 0
+	}
+	return float64(p.FocusSeconds) / float64(p.Bucket.WeeklyGoalSeconds.Int64) * 100
+}
+
+func NewBucket(bucketName string) *Bucket {
+	return &Bucket{
+		BucketName:        strings.TrimSpace(bucketName),
+		BucketUUID:        uuid.NewString(),
+		Colour:            sql.NullString{Valid: false},
+		WeeklyGoalSeconds: sql.NullInt64{Valid: false},
+		ArchivedAt:        sql.NullTime{Valid: false},
+	}
+}
+
+func (b *Bucket) SetColour(colour string) {
+	b.Colour = sql.NullString{String: colour, Valid: colour != ""}
+}
+
+func (b *Bucket) SetWeeklyGoal(goal time.Duration) {
+	b.WeeklyGoalSeconds = sql.NullInt64{Int64: int64(goal.Seconds()), Valid: goal > 0}
+}
+
+func (b Bucket) Archived() bool {
+	return b.ArchivedAt.Valid
+}
+
+func InsertBucket(db sqlx.Ext, bucket *Bucket) error {
+	if bucket.BucketName == "" {
+		return errors.New("Error, bucket name must not be empty")
+	}
+
+	if _, err := strconv.ParseInt(bucket.BucketName, 10, 64); err == nil {
+		return errors.New("Error, bucket name must not be a number")
+	}
+
+	_, err := FindByName(db, bucket.BucketName)
+	if err == nil {
+		return fmt.Errorf("Error, bucket '%s' already exists", bucket.BucketName)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	query := `INSERT INTO Buckets (bucket_name, bucket_uuid, colour, weekly_goal_seconds, archived_at)
+	VALUES (:bucket_name, :bucket_uuid, :colour, :weekly_goal_seconds, :archived_at)`
+
+	result, err := sqlx.NamedExec(db, query, bucket)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	bucket.BucketId = id
+
+	return nil
 }
 
 func GetAllBuckets(db *sqlx.DB) ([]Bucket, error) {
 	var buckets []Bucket
-	q := `SELECT * FROM Buckets`
+	q := `SELECT * FROM Buckets ORDER BY bucket_name ASC`
 
 	err := db.Select(&buckets, q)
 	if err != nil {
@@ -30,16 +109,37 @@ func GetAllBuckets(db *sqlx.DB) ([]Bucket, error) {
 	return buckets, nil
 }
 
-func GetBucketByName(db *sqlx.DB, bucketName string) (Bucket, error) {
+func GetActiveBuckets(db *sqlx.DB) ([]Bucket, error) {
+	var buckets []Bucket
+	q := `SELECT * FROM Buckets WHERE archived_at IS NULL ORDER BY bucket_name ASC`
+
+	err := db.Select(&buckets, q)
+	if err != nil {
+		return buckets, err
+	}
+
+	return buckets, nil
+}
+
+func GetBucketByID(db *sqlx.DB, bucketId int64) (Bucket, error) {
 	var bucket Bucket
-	q := `SELECT * FROM Buckets WHERE bucket_name = ?`
 
-	err := db.Get(&bucket, q, bucketName)
+	err := db.Get(&bucket, `SELECT * FROM Buckets WHERE bucket_id = ?`, bucketId)
+	if err != nil {
+		return bucket, err
+	}
+
+	return bucket, nil
+}
+
+// GetBucketByName matches names case-insensitively and loads the bucket's tasks.
+func GetBucketByName(ctx context.Context, db *sqlx.DB, bucketName string) (Bucket, error) {
+	bucket, err := FindByName(db, bucketName)
 	if err != nil {
 		return bucket, err
 	}
 
-	tasks, err := tasks.GetTasksByBucketId(db, bucket.BucketId)
+	tasks, err := tasks.NewStore(db).Select(ctx, tasks.NewQuery().Bucket(bucket.BucketId))
 	if err != nil {
 		return bucket, err
 	}
@@ -48,3 +148,144 @@ func GetBucketByName(db *sqlx.DB, bucketName string) (Bucket, error) {
 
 	return bucket, nil
 }
+
+// FindByUUID finds a bucket by the uuid it is synced under, without loading its tasks.
+func FindByUUID(db sqlx.Queryer, bucketUUID string) (Bucket, error) {
+	var bucket Bucket
+
+	err := sqlx.Get(db, &bucket, `SELECT * FROM Buckets WHERE bucket_uuid = ?`, bucketUUID)
+	if err != nil {
+		return bucket, err
+	}
+
+	return bucket, nil
+}
+
+// FindByName matches names case-insensitively without loading the bucket's tasks.
+func FindByName(db sqlx.Queryer, bucketName string) (Bucket, error) {
+	var bucket Bucket
+	q := `SELECT * FROM Buckets WHERE bucket_name = ? COLLATE NOCASE`
+
+	err := sqlx.Get(db, &bucket, q, strings.TrimSpace(bucketName))
+	if err != nil {
+		return bucket, err
+	}
+
+	return bucket, nil
+}
+
+// Resolve finds a bucket by id or name, as accepted by the --bucket flag.
+func Resolve(db *sqlx.DB, nameOrId string) (Bucket, error) {
+	var bucket Bucket
+	var err error
+
+	if id, parseErr := strconv.ParseInt(nameOrId, 10, 64); parseErr == nil {
+		bucket, err = GetBucketByID(db, id)
+	} else {
+		bucket, err = FindByName(db, nameOrId)
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return bucket, fmt.Errorf("Error, no bucket '%s', create it with `block bucket add`", nameOrId)
+	}
+
+	return bucket, err
+}
+
+// ResolveActive is Resolve but rejects archived buckets, for tagging new tasks.
+func ResolveActive(db *sqlx.DB, nameOrId string) (Bucket, error) {
+	bucket, err := Resolve(db, nameOrId)
+	if err != nil {
+		return bucket, err
+	}
+
+	if bucket.Archived() {
+		return bucket, fmt.Errorf("Error, bucket '%s' is archived", bucket.BucketName)
+	}
+
+	return bucket, nil
+}
+
+func RenameBucket(db *sqlx.DB, bucketId int64, bucketName string) error {
+	bucketName = strings.TrimSpace(bucketName)
+	if bucketName == "" {
+		return errors.New("Error, bucket name must not be empty")
+	}
+
+	existing, err := FindByName(db, bucketName)
+	if err == nil && existing.BucketId != bucketId {
+		return fmt.Errorf("Error, bucket '%s' already exists", bucketName)
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	return updateBucket(db, "UPDATE Buckets SET bucket_name = ? WHERE bucket_id = ?", bucketName, bucketId)
+}
+
+func UpdateBucketSettings(db *sqlx.DB, bucket Bucket) error {
+	return updateBucket(db, "UPDATE Buckets SET colour = ?, weekly_goal_seconds = ? WHERE bucket_id = ?", bucket.Colour, bucket.WeeklyGoalSeconds, bucket.BucketId)
+}
+
+// ArchiveBucket hides a bucket from new tasks while keeping its history. A zero archivedAt restores it.
+func ArchiveBucket(db *sqlx.DB, bucketId int64, archivedAt time.Time) error {
+	value := sql.NullTime{Time: archivedAt, Valid: !archivedAt.IsZero()}
+	return updateBucket(db, "UPDATE Buckets SET archived_at = ? WHERE bucket_id = ?", value, bucketId)
+}
+
+func updateBucket(db *sqlx.DB, query string, args ...any) error {
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetWeeklyProgress returns focus time this calendar week for every active bucket with a weekly goal.
+func GetWeeklyProgress(db *sqlx.DB, now time.Time) ([]Progress, error) {
+	all, err := GetActiveBuckets(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var totals []struct {
+		BucketId     int64 `db:"bucket_id"`
+		FocusSeconds int64 `db:"focus_seconds"`
+	}
+
+	q := `SELECT bucket_id, COALESCE(SUM(actual_duration_seconds), 0) AS focus_seconds
+	FROM Tasks
+	WHERE bucket_id IS NOT NULL AND deleted_at IS NULL AND julianday(created_at) >= julianday(?)
+	GROUP BY bucket_id`
+
+	start, _ := calendar.Default().WeekBounds(now)
+	err = db.Select(&totals, q, start)
+	if err != nil {
+		return nil, err
+	}
+
+	focusByBucket := make(map[int64]int64)
+	for _, total := range totals {
+		focusByBucket[total.BucketId] = total.FocusSeconds
+	}
+
+	var progress []Progress
+	for _, bucket := range all {
+		if !bucket.WeeklyGoalSeconds.Valid {
+			continue
+		}
+		progress = append(progress, Progress{Bucket: bucket, FocusSeconds: focusByBucket[bucket.BucketId]})
+	}
+
+	return progress, nil
+}
@@ -0,0 +1,142 @@
+// Package calendar places times into days for the configured timezone and day start hour,
+// so history, the daily page and reports agree on which day a task belongs to.
+package calendar
+
+import (
+	"fmt"
+	"time"
+
+	// embedded so a configured timezone loads on systems without a zoneinfo database.
+	_ "time/tzdata"
+)
+
+// Calendar groups times into days in a location. Days begin at StartHour rather than midnight,
+// so with a start hour of 4 a session at 01:30 counts towards the previous day.
+//
+// Days are labelled by their date, held as midnight in the calendar's location. Use Start to
+// turn a date into the instant its day begins.
+type Calendar struct {
+	loc       *time.Location
+	startHour int
+}
+
+var current = Calendar{loc: time.Local}
+
+// Default returns the calendar set by SetDefault, or local time with days starting at midnight.
+func Default() Calendar {
+	return current
+}
+
+// SetDefault sets the calendar returned by Default, usually once at startup from config.
+func SetDefault(c Calendar) {
+	current = c
+}
+
+// New returns a calendar in loc with days starting at startHour, 0 to 23.
+func New(loc *time.Location, startHour int) (Calendar, error) {
+	if startHour < 0 || startHour > 23 {
+		return Calendar{}, fmt.Errorf("Error, day start hour must be between 0 and 23, got %d", startHour)
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	return Calendar{loc: loc, startHour: startHour}, nil
+}
+
+// Load returns a calendar for an IANA timezone such as Australia/Perth. An empty timezone,
+// or Local, uses the system timezone.
+func Load(timezone string, startHour int) (Calendar, error) {
+	loc := time.Local
+	if timezone != "" && timezone != "Local" {
+		var err error
+		loc, err = time.LoadLocation(timezone)
+		if err != nil {
+			return Calendar{}, fmt.Errorf("Error loading timezone '%s': %w", timezone, err)
+		}
+	}
+	return New(loc, startHour)
+}
+
+func (c Calendar) Location() *time.Location {
+	if c.loc == nil {
+		return time.Local
+	}
+	return c.loc
+}
+
+func (c Calendar) StartHour() int {
+	return c.startHour
+}
+
+// In returns t in the calendar's location.
+func (c Calendar) In(t time.Time) time.Time {
+	return t.In(c.Location())
+}
+
+// Date returns the date of the day t belongs to.
+func (c Calendar) Date(t time.Time) time.Time {
+	t = c.In(t)
+	y, m, d := t.Date()
+	if t.Hour() < c.startHour {
+		d--
+	}
+	return c.date(y, m, d)
+}
+
+// Start returns when the day labelled date begins.
+func (c Calendar) Start(date time.Time) time.Time {
+	y, m, d := date.Date()
+	return time.Date(y, m, d, c.startHour, 0, 0, 0, c.Location())
+}
+
+// AddDays returns the date n days after date.
+func (c Calendar) AddDays(date time.Time, n int) time.Time {
+	y, m, d := date.Date()
+	return c.date(y, m, d+n)
+}
+
+// Today returns the date of the day now belongs to.
+func (c Calendar) Today(now time.Time) time.Time {
+	return c.Date(now)
+}
+
+// ParseDate reads a yyyy-mm-dd date.
+func (c Calendar) ParseDate(s string) (time.Time, error) {
+	t, err := time.ParseInLocation("2006-01-02", s, c.Location())
+	if err != nil {
+		return t, err
+	}
+	return c.date(t.Year(), t.Month(), t.Day()), nil
+}
+
+// DayBounds returns when the day containing t begins and ends. A day spanning a daylight
+// saving change lasts 23 or 25 hours.
+func (c Calendar) DayBounds(t time.Time) (time.Time, time.Time) {
+	date := c.Date(t)
+	return c.Start(date), c.Start(c.AddDays(date, 1))
+}
+
+// WeekBounds returns when the week containing t begins and ends, weeks start on Monday.
+func (c Calendar) WeekBounds(t time.Time) (time.Time, time.Time) {
+	date := c.Date(t)
+	monday := c.AddDays(date, -((int(date.Weekday()) + 6) % 7))
+	return c.Start(monday), c.Start(c.AddDays(monday, 7))
+}
+
+// MonthBounds returns when the month containing t begins and ends.
+func (c Calendar) MonthBounds(t time.Time) (time.Time, time.Time) {
+	date := c.Date(t)
+	first := c.date(date.Year(), date.Month(), 1)
+	return c.Start(first), c.Start(c.date(date.Year(), date.Month()+1, 1))
+}
+
+// SameDay reports whether a and b belong to the same day.
+func (c Calendar) SameDay(a, b time.Time) bool {
+	return c.Date(a).Equal(c.Date(b))
+}
+
+func (c Calendar) date(y int, m time.Month, d int) time.Time {
+	// normalise through UTC first, midnight does not exist on some dst changes.
+	n := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	return time.Date(n.Year(), n.Month(), n.Day(), 0, 0, 0, 0, c.Location())
+}
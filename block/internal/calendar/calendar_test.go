@@ -0,0 +1,135 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, timezone string, startHour int) Calendar {
+	t.Helper()
+
+	c, err := Load(timezone, startHour)
+	if err != nil {
+		t.Skip(err)
+	}
+	return c
+}
+
+func TestDate(t *testing.T) {
+	c := mustLoad(t, "Australia/Perth", 4)
+	perth := c.Location()
+
+	testCases := []struct {
+		name string
+		t    time.Time
+		want string
+	}{
+		{name: "after start hour", t: time.Date(2024, 3, 4, 9, 0, 0, 0, perth), want: "2024-03-04"},
+		{name: "at start hour", t: time.Date(2024, 3, 4, 4, 0, 0, 0, perth), want: "2024-03-04"},
+		{name: "before start hour", t: time.Date(2024, 3, 4, 3, 59, 0, 0, perth), want: "2024-03-03"},
+		{name: "before start hour on the 1st", t: time.Date(2024, 3, 1, 1, 0, 0, 0, perth), want: "2024-02-29"},
+		{name: "utc time late the day before", t: time.Date(2024, 3, 3, 23, 0, 0, 0, time.UTC), want: "2024-03-04"},
+		{name: "utc time early the same day", t: time.Date(2024, 3, 3, 19, 0, 0, 0, time.UTC), want: "2024-03-03"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := c.Date(tc.t).Format("2006-01-02"); got != tc.want {
+				t.Errorf("Expected: %s, got: %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDayBoundsAcrossDST(t *testing.T) {
+	testCases := []struct {
+		name      string
+		timezone  string
+		startHour int
+		t         time.Time
+		wantHours float64
+	}{
+		{name: "new york spring forward", timezone: "America/New_York", t: time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC), wantHours: 23},
+		{name: "new york fall back", timezone: "America/New_York", t: time.Date(2024, 11, 3, 12, 0, 0, 0, time.UTC), wantHours: 25},
+		{name: "new york fall back from 4am", timezone: "America/New_York", startHour: 4, t: time.Date(2024, 11, 3, 12, 0, 0, 0, time.UTC), wantHours: 24},
+		{name: "new york day before fall back from 4am", timezone: "America/New_York", startHour: 4, t: time.Date(2024, 11, 2, 12, 0, 0, 0, time.UTC), wantHours: 25},
+		{name: "sydney dst ends", timezone: "Australia/Sydney", t: time.Date(2024, 4, 7, 3, 0, 0, 0, time.UTC), wantHours: 25},
+		{name: "sydney dst starts", timezone: "Australia/Sydney", t: time.Date(2024, 10, 6, 3, 0, 0, 0, time.UTC), wantHours: 23},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := mustLoad(t, tc.timezone, tc.startHour)
+
+			start, end := c.DayBounds(tc.t)
+			if got := end.Sub(start).Hours(); got != tc.wantHours {
+				t.Errorf("Expected a %v hour day, got: %v (%v to %v)", tc.wantHours, got, start, end)
+			}
+			if start.In(c.Location()).Hour() != tc.startHour {
+				t.Errorf("Expected the day to start at %d:00, got: %v", tc.startHour, start)
+			}
+			if tc.t.Before(start) || !tc.t.Before(end) {
+				t.Errorf("Expected %v within [%v, %v)", tc.t, start, end)
+			}
+		})
+	}
+}
+
+func TestWeekAndMonthBounds(t *testing.T) {
+	c := mustLoad(t, "America/New_York", 4)
+	ny := c.Location()
+
+	// sunday 02:00 before the start hour belongs to saturday, still in the week of monday the 4th.
+	start, end := c.WeekBounds(time.Date(2024, 3, 11, 2, 0, 0, 0, ny))
+	if want := time.Date(2024, 3, 4, 4, 0, 0, 0, ny); !start.Equal(want) {
+		t.Errorf("Expected week start: %v, got: %v", want, start)
+	}
+	if want := time.Date(2024, 3, 11, 4, 0, 0, 0, ny); !end.Equal(want) {
+		t.Errorf("Expected week end: %v, got: %v", want, end)
+	}
+	// the week spans the spring forward change.
+	if got := end.Sub(start).Hours(); got != 7*24-1 {
+		t.Errorf("Expected a 167 hour week, got: %v", got)
+	}
+
+	start, end = c.MonthBounds(time.Date(2024, 4, 1, 3, 0, 0, 0, ny))
+	if want := time.Date(2024, 3, 1, 4, 0, 0, 0, ny); !start.Equal(want) {
+		t.Errorf("Expected month start: %v, got: %v", want, start)
+	}
+	if want := time.Date(2024, 4, 1, 4, 0, 0, 0, ny); !end.Equal(want) {
+		t.Errorf("Expected month end: %v, got: %v", want, end)
+	}
+}
+
+func TestAddDaysAndParseDate(t *testing.T) {
+	c := mustLoad(t, "America/New_York", 0)
+
+	date, err := c.ParseDate("2024-03-09")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// adding days to a date keeps midnight across the dst change, adding 24 hours would not.
+	next := c.AddDays(date, 2)
+	if got := next.Format("2006-01-02 15:04"); got != "2024-03-11 00:00" {
+		t.Errorf("Expected 2024-03-11 00:00, got: %s", got)
+	}
+	if !c.SameDay(next, date.Add(48*time.Hour)) {
+		t.Error("Expected 48 hours later to still be the 11th")
+	}
+
+	if _, err := c.ParseDate("09/03/2024"); err == nil {
+		t.Error("Expected error parsing a non iso date, got nil")
+	}
+}
+
+func TestNewRejectsStartHour(t *testing.T) {
+	for _, hour := range []int{-1, 24} {
+		if _, err := New(time.UTC, hour); err == nil {
+			t.Errorf("Expected error for start hour %d, got nil", hour)
+		}
+	}
+	if _, err := Load("Mars/Olympus_Mons", 0); err == nil {
+		t.Error("Expected error for unknown timezone, got nil")
+	}
+}
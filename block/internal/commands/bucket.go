@@ -0,0 +1,244 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/urfave/cli/v2"
+)
+
+var hexColour = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+var bucketSettingsFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "colour",
+		Usage: "Hex colour used to display the bucket, eg. #3b82f6.",
+	},
+	&cli.DurationFlag{
+		Name:  "goal",
+		Usage: "Weekly focus time goal, eg. 10h or 90m.",
+	},
+}
+
+// applyBucketSettings copies any colour or goal flags set on ctx onto the bucket.
+func applyBucketSettings(ctx *cli.Context, bucket *buckets.Bucket) error {
+	if ctx.IsSet("colour") {
+		colour := ctx.String("colour")
+		if colour != "" && !hexColour.MatchString(colour) {
+			return fmt.Errorf("Error, colour '%s' must be a hex colour like #3b82f6", colour)
+		}
+		bucket.SetColour(colour)
+	}
+
+	if ctx.IsSet("goal") {
+		bucket.SetWeeklyGoal(ctx.Duration("goal"))
+	}
+
+	return nil
+}
+
+var BucketCmd = &cli.Command{
+	Name:  "bucket",
+	Usage: "manage buckets used to categorise tasks.",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "add",
+			Usage:     "create a bucket.",
+			ArgsUsage: "[name]",
+			Flags:     bucketSettingsFlags,
+			Action: func(ctx *cli.Context) error {
+				db := ctx.Context.Value("db").(*sqlx.DB)
+
+				if ctx.NArg() < 1 {
+					return errors.New("Error, expected a bucket name")
+				}
+
+				bucket := buckets.NewBucket(ctx.Args().First())
+				if err := applyBucketSettings(ctx, bucket); err != nil {
+					return err
+				}
+
+				if err := buckets.InsertBucket(db, bucket); err != nil {
+					return err
+				}
+
+				fmt.Printf("Created bucket '%s' (id %d).\n", bucket.BucketName, bucket.BucketId)
+				return nil
+			},
+		},
+		{
+			Name:      "set",
+			Usage:     "change a bucket's colour or weekly goal.",
+			ArgsUsage: "[name|id]",
+			Flags:     bucketSettingsFlags,
+			Action: func(ctx *cli.Context) error {
+				db := ctx.Context.Value("db").(*sqlx.DB)
+
+				if ctx.NArg() < 1 {
+					return errors.New("Error, expected a bucket name or id")
+				}
+
+				bucket, err := buckets.Resolve(db, ctx.Args().First())
+				if err != nil {
+					return err
+				}
+
+				if err := applyBucketSettings(ctx, &bucket); err != nil {
+					return err
+				}
+
+				if err := buckets.UpdateBucketSettings(db, bucket); err != nil {
+					return err
+				}
+
+				fmt.Printf("Updated bucket '%s'.\n", bucket.BucketName)
+				return nil
+			},
+		},
+		{
+			Name:      "rename",
+			Usage:     "rename a bucket.",
+			ArgsUsage: "[name|id] [new name]",
+			Action: func(ctx *cli.Context) error {
+				db := ctx.Context.Value("db").(*sqlx.DB)
+
+				if ctx.NArg() < 2 {
+					return errors.New("Error, expected a bucket and its new name")
+				}
+
+				bucket, err := buckets.Resolve(db, ctx.Args().Get(0))
+				if err != nil {
+					return err
+				}
+
+				newName := ctx.Args().Get(1)
+				if err := buckets.RenameBucket(db, bucket.BucketId, newName); err != nil {
+					return err
+				}
+
+				fmt.Printf("Renamed bucket '%s' to '%s'.\n", bucket.BucketName, newName)
+				return nil
+			},
+		},
+		{
+			Name:      "archive",
+			Usage:     "archive a bucket, hiding it from new tasks but keeping its history.",
+			ArgsUsage: "[name|id]",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "restore",
+					Usage: "Unarchive the bucket.",
+				},
+			},
+			Action: func(ctx *cli.Context) error {
+				db := ctx.Context.Value("db").(*sqlx.DB)
+
+				if ctx.NArg() < 1 {
+					return errors.New("Error, expected a bucket name or id")
+				}
+
+				bucket, err := buckets.Resolve(db, ctx.Args().First())
+				if err != nil {
+					return err
+				}
+
+				if ctx.Bool("restore") {
+					if err := buckets.ArchiveBucket(db, bucket.BucketId, time.Time{}); err != nil {
+						return err
+					}
+					fmt.Printf("Restored bucket '%s'.\n", bucket.BucketName)
+					return nil
+				}
+
+				if err := buckets.ArchiveBucket(db, bucket.BucketId, time.Now()); err != nil {
+					return err
+				}
+
+				fmt.Printf("Archived bucket '%s'.\n", bucket.BucketName)
+				return nil
+			},
+		},
+		{
+			Name:  "list",
+			Usage: "list buckets and progress against their weekly goals.",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:    "all",
+					Aliases: []string{"a"},
+					Usage:   "Include archived buckets.",
+				},
+			},
+			Action: func(ctx *cli.Context) error {
+				db := ctx.Context.Value("db").(*sqlx.DB)
+
+				var all []buckets.Bucket
+				var err error
+				if ctx.Bool("all") {
+					all, err = buckets.GetAllBuckets(db)
+				} else {
+					all, err = buckets.GetActiveBuckets(db)
+				}
+				if err != nil {
+					return err
+				}
+
+				progress, err := buckets.GetWeeklyProgress(db, time.Now())
+				if err != nil {
+					return err
+				}
+
+				progressByBucket := make(map[int64]buckets.Progress)
+				for _, p := range progress {
+					progressByBucket[p.Bucket.BucketId] = p
+				}
+
+				table := newTable(os.Stdout, "ID", "Name", "Colour", "Weekly Goal", "This Week", "Archived")
+				for _, bucket := range all {
+					goal, thisWeek, archived := "", "", ""
+					if p, ok := progressByBucket[bucket.BucketId]; ok {
+						goal = utils.SecsToHHMMSS(bucket.WeeklyGoalSeconds.Int64)
+						thisWeek = fmt.Sprintf("%s (%.0f%%)", utils.SecsToHHMMSS(p.FocusSeconds), p.Percent())
+					}
+					if bucket.Archived() {
+						archived = bucket.ArchivedAt.Time.Format("2006-01-02")
+					}
+					table.Append([]string{fmt.Sprint(bucket.BucketId), bucket.BucketName, bucket.Colour.String, goal, thisWeek, archived})
+				}
+				table.Render()
+
+				return nil
+			},
+		},
+	},
+}
+
+// renderGoalProgress prints focus time this week against each bucket's weekly goal.
+func renderGoalProgress(w io.Writer, db *sqlx.DB, now time.Time) error {
+	progress, err := buckets.GetWeeklyProgress(db, now)
+	if err != nil {
+		return err
+	}
+
+	if len(progress) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w, "\nWeekly goals:")
+	for _, p := range progress {
+		fmt.Fprintf(w, "  %s: %s / %s (%.0f%%)\n",
+			p.Bucket.BucketName,
+			utils.SecsToHHMMSS(p.FocusSeconds),
+			utils.SecsToHHMMSS(p.Bucket.WeeklyGoalSeconds.Int64),
+			p.Percent(),
+		)
+	}
+
+	return nil
+}
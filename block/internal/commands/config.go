@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/connorkuljis/block-cli/internal/config"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var ConfigCmd = &cli.Command{
+	Name:  "config",
+	Usage: "show and change settings.",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "get",
+			Usage:     "print the settings in effect, or one setting, after the environment and --set are applied.",
+			ArgsUsage: "[key]",
+			Action: func(ctx *cli.Context) error {
+				cfg, err := config.Read(ctx.Context.Value("paths").(config.Paths), ctx.StringSlice("set"))
+				if err != nil {
+					return err
+				}
+
+				if ctx.NArg() == 0 {
+					data, err := yaml.Marshal(&cfg.Config)
+					if err != nil {
+						return err
+					}
+					fmt.Print(string(data))
+					return nil
+				}
+
+				value, err := cfg.Config.Get(ctx.Args().First())
+				if err != nil {
+					return err
+				}
+				fmt.Println(value)
+
+				return nil
+			},
+		},
+		{
+			Name:      "set",
+			Usage:     "change a setting in the config file.",
+			ArgsUsage: "[key] [value]",
+			Action: func(ctx *cli.Context) error {
+				if ctx.NArg() != 2 {
+					return errors.New("Error, expected a key and a value, such as block config set goals.dailyMinutes 120")
+				}
+				key, value := ctx.Args().Get(0), ctx.Args().Get(1)
+
+				paths := ctx.Context.Value("paths").(config.Paths)
+				cfg, err := config.ReadSaved(paths)
+				if err != nil {
+					return err
+				}
+
+				if err := cfg.Set(key, value); err != nil {
+					return err
+				}
+				if err := cfg.Validate(); err != nil {
+					return err
+				}
+				if err := config.Save(paths, cfg); err != nil {
+					return err
+				}
+
+				if env := config.EnvVar(key); os.Getenv(env) != "" {
+					fmt.Printf("Saved, but %s is set and overrides it.\n", env)
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:  "edit",
+			Usage: "open the config file in $VISUAL or $EDITOR, saving it only once it is valid.",
+			Action: func(ctx *cli.Context) error {
+				paths := ctx.Context.Value("paths").(config.Paths)
+
+				// A broken config file is still opened, so it can be fixed.
+				if _, err := os.Stat(paths.ConfigFile()); os.IsNotExist(err) {
+					if err := paths.MakeDirs(); err != nil {
+						return err
+					}
+					if err := config.Save(paths, config.Default()); err != nil {
+						return err
+					}
+				}
+
+				return editConfig(paths)
+			},
+		},
+		{
+			Name:  "validate",
+			Usage: "check the settings in effect, listing every problem.",
+			Action: func(ctx *cli.Context) error {
+				paths := ctx.Context.Value("paths").(config.Paths)
+				cfg, err := config.Read(paths, ctx.StringSlice("set"))
+				if err != nil {
+					return err
+				}
+
+				if err := cfg.Config.Validate(); err != nil {
+					return fmt.Errorf("%w\nin %s", err, paths.ConfigFile())
+				}
+
+				fmt.Println("Config is valid.")
+				return nil
+			},
+		},
+	},
+}
+
+// editConfig edits a copy of the config file, replacing the file only with a valid copy.
+func editConfig(paths config.Paths) error {
+	original, err := os.ReadFile(paths.ConfigFile())
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp("", "block-config-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(original); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	for {
+		// The editor may be given with arguments, such as code --wait.
+		args := append(strings.Fields(editor), temp.Name())
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("Error running %s: %w", editor, err)
+		}
+
+		edited, err := os.ReadFile(temp.Name())
+		if err != nil {
+			return err
+		}
+
+		cfg := config.Default()
+		err = config.ReadFile(temp.Name(), &cfg)
+		if err == nil {
+			err = cfg.Validate()
+		}
+		if err == nil {
+			if string(edited) == string(original) {
+				fmt.Println("No changes.")
+				return nil
+			}
+			if err := config.WriteFile(paths, edited); err != nil {
+				return err
+			}
+			fmt.Println("Saved", paths.ConfigFile())
+			return nil
+		}
+
+		fmt.Println(err)
+		if answer := prompt(in, os.Stdout, "Edit again? [Y/n] "); strings.EqualFold(answer, "n") {
+			fmt.Println("Discarded changes.")
+			return nil
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/connorkuljis/block-cli/internal/db"
+	"github.com/jmoiron/sqlx"
+	"github.com/urfave/cli/v2"
+)
+
+var DbCmd = &cli.Command{
+	Name:  "db",
+	Usage: "manage the task database.",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "migrate",
+			Usage: "apply pending schema migrations.",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "status",
+					Usage: "List migrations and when they were applied.",
+				},
+			},
+			Action: func(ctx *cli.Context) error {
+				conn := ctx.Context.Value("db").(*sqlx.DB)
+
+				statuses, err := db.Status(conn)
+				if err != nil {
+					return err
+				}
+
+				if !ctx.Bool("status") {
+					pending := 0
+					for _, s := range statuses {
+						if !s.AppliedAt.Valid {
+							pending++
+						}
+					}
+
+					if err := db.Migrate(conn); err != nil {
+						return err
+					}
+
+					if pending > 0 {
+						fmt.Printf("Applied %d migrations. ", pending)
+					}
+					fmt.Println("Database schema is up to date.")
+					return nil
+				}
+
+				table := newTable(os.Stdout, "Version", "Name", "Applied At")
+
+				for _, s := range statuses {
+					appliedAt := "pending"
+					if s.AppliedAt.Valid {
+						appliedAt = s.AppliedAt.Time.Format("2006-01-02 15:04:05")
+					}
+					table.Append([]string{fmt.Sprintf("%04d", s.Version), s.Name, appliedAt})
+				}
+				table.Render()
+
+				return nil
+			},
+		},
+	},
+}
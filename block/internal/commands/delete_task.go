@@ -1,8 +1,13 @@
 package commands
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/connorkuljis/block-cli/internal/tasks"
 	"github.com/jmoiron/sqlx"
@@ -10,27 +15,159 @@ import (
 )
 
 var DeleteTaskCmd = &cli.Command{
-	Name:  "delete",
-	Usage: "Deletes a task by given ID.",
+	Name:      "delete",
+	Usage:     "delete tasks by id, or every task matching the filters. Deleted tasks can be restored.",
+	ArgsUsage: "[id...]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "yes",
+			Aliases: []string{"y"},
+			Usage:   "Delete without asking for confirmation.",
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "Delete tasks created on or after `yyyy-mm-dd`.",
+		},
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "Delete tasks created on or before `yyyy-mm-dd`.",
+		},
+		&cli.StringFlag{
+			Name:    "bucket",
+			Aliases: []string{"b"},
+			Usage:   "Delete tasks in a bucket, by name or id.",
+		},
+		&cli.BoolFlag{
+			Name:  "cancelled",
+			Usage: "Delete tasks stopped before their planned time.",
+		},
+		&cli.StringFlag{
+			Name:    "search",
+			Aliases: []string{"s"},
+			Usage:   "Delete tasks whose name contains `text`.",
+		},
+		&cli.StringSliceFlag{
+			Name:    "tag",
+			Aliases: []string{"t"},
+			Usage:   "Delete tasks with every given tag.",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		db := ctx.Context.Value("db").(*sqlx.DB)
+		store := tasks.NewStore(db)
+
+		filtered := false
+		for _, name := range []string{"from", "to", "bucket", "cancelled", "search", "tag"} {
+			filtered = filtered || ctx.IsSet(name)
+		}
+
+		if ctx.NArg() > 0 && filtered {
+			return errors.New("Error, give task ids or filters, not both")
+		}
+
+		var ids []int64
+		var matched []tasks.Task
+		if ctx.NArg() > 0 {
+			var err error
+			ids, err = parseTaskIds(ctx.Args().Slice())
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				task, err := store.Get(ctx.Context, id)
+				if err != nil {
+					return err
+				}
+				matched = append(matched, task)
+			}
+		} else {
+			if !filtered {
+				return errors.New("Error, expected task ids or a filter such as --from, --bucket or --search")
+			}
+
+			query, err := filterQuery(ctx, db, time.Now(), tasks.NewQuery())
+			if err != nil {
+				return err
+			}
+			matched, err = store.Select(ctx.Context, query)
+			if err != nil {
+				return err
+			}
+			if len(matched) == 0 {
+				fmt.Println("No tasks match, nothing deleted.")
+				return nil
+			}
+			for _, task := range matched {
+				ids = append(ids, task.TaskId)
+			}
+		}
+
+		if !ctx.Bool("yes") {
+			tasks.RenderTable(os.Stdout, matched)
+			answer := prompt(bufio.NewReader(os.Stdin), os.Stdout, fmt.Sprintf("Delete %d tasks? [y/N] ", len(ids)))
+			if !strings.EqualFold(answer, "y") && !strings.EqualFold(answer, "yes") {
+				fmt.Println("Nothing deleted.")
+				return nil
+			}
+		}
+
+		deleted, err := store.Delete(ctx.Context, ids, tasks.SourceCLI, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if err := updateDailyGoals(ctx, db, deleted); err != nil {
+			return err
+		}
+
+		fmt.Printf("Deleted %d tasks, run `block undo` to bring them back.\n", len(deleted))
+
+		return nil
+	},
+}
+
+var RestoreCmd = &cli.Command{
+	Name:      "restore",
+	Usage:     "restore deleted tasks by id, list them with `block history --deleted`.",
+	ArgsUsage: "[id...]",
 	Action: func(ctx *cli.Context) error {
 		db := ctx.Context.Value("db").(*sqlx.DB)
+		store := tasks.NewStore(db)
 
 		if ctx.NArg() < 1 {
-			return errors.New("Empty arguments")
+			return errors.New("Error, expected the ids of deleted tasks, list them with `block history --deleted`")
 		}
 
-		id := ctx.Args().Get(0)
-		rowsAffected, err := tasks.DeleteTaskByID(db, id)
+		ids, err := parseTaskIds(ctx.Args().Slice())
 		if err != nil {
 			return err
 		}
 
-		if rowsAffected > 0 {
-			fmt.Printf("Successfully deleted element by id: %s, (%d rows affected).\n", id, rowsAffected)
-		} else {
-			return errors.New("Unable to delete element with id: " + id)
+		restored, err := store.Restore(ctx.Context, ids, tasks.SourceCLI)
+		if err != nil {
+			return err
+		}
+
+		if err := updateDailyGoals(ctx, db, restored); err != nil {
+			return err
+		}
+
+		for _, task := range restored {
+			fmt.Printf("Restored task %d %q.\n", task.TaskId, task.TaskName)
 		}
 
 		return nil
 	},
 }
+
+func parseTaskIds(args []string) ([]int64, error) {
+	var ids []int64
+	for _, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing task id '%s', flags go before the ids", arg)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/estimates"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/connorkuljis/block-cli/internal/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/urfave/cli/v2"
+)
+
+var EstimatesCmd = &cli.Command{
+	Name:  "estimates",
+	Usage: "compare planned durations with the time work actually took.",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:    "weeks",
+			Aliases: []string{"w"},
+			Value:   12,
+			Usage:   "Analyse the last `n` weeks, including this one.",
+		},
+		&cli.StringFlag{
+			Name:    "bucket",
+			Aliases: []string{"b"},
+			Usage:   "Only analyse tasks in a bucket, by name or id.",
+		},
+		&cli.StringSliceFlag{
+			Name:    "tag",
+			Aliases: []string{"t"},
+			Usage:   "Only analyse tasks with every given tag.",
+		},
+		&cli.StringFlag{
+			Name:    "format",
+			Aliases: []string{"f"},
+			Value:   "table",
+			Usage:   "Output format: table or json.",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		db := ctx.Context.Value("db").(*sqlx.DB)
+
+		if ctx.Int("weeks") < 1 {
+			return errors.New("Error, --weeks must be 1 or more")
+		}
+
+		now := time.Now()
+		query, err := filterQuery(ctx, db, now, tasks.NewQuery())
+		if err != nil {
+			return err
+		}
+
+		a, err := estimates.Generate(ctx.Context, db, calendar.Default(), ctx.Int("weeks"), now, query)
+		if err != nil {
+			return err
+		}
+
+		switch ctx.String("format") {
+		case "table":
+			renderEstimates(os.Stdout, a)
+			return nil
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(a)
+		default:
+			return fmt.Errorf("Error, unknown format '%s', expected table or json", ctx.String("format"))
+		}
+	},
+}
+
+func renderEstimates(w io.Writer, a estimates.Analysis) {
+	fmt.Fprintf(w, "Estimates, %s to %s\n\n", a.Start.Format("Mon Jan 02 2006"), a.End.AddDate(0, 0, -1).Format("Mon Jan 02 2006"))
+
+	if a.Overall.Count == 0 {
+		fmt.Fprintln(w, "No finished work to compare yet.")
+		return
+	}
+
+	fmt.Fprintf(w, "Work:       %d (%d sessions)\n", a.Overall.Count, a.Overall.Sessions)
+	fmt.Fprintf(w, "Estimated:  %s\n", utils.SecsToHHMMSS(a.Overall.EstimatedSeconds))
+	fmt.Fprintf(w, "Actual:     %s (%s of estimates)\n", utils.SecsToHHMMSS(a.Overall.ActualSeconds), formatRatio(a.Overall.Ratio()))
+	fmt.Fprintf(w, "Overran:    %.0f%% took longer than planned\n", a.Overall.OverranPercent())
+	fmt.Fprintf(w, "Underran:   %.0f%% finished early\n", a.Overall.UnderranPercent())
+
+	fmt.Fprintln(w)
+	table := newTable(w, "Bucket", "Work", "Estimated", "Actual", "Ratio", "Overran")
+	for _, b := range a.Buckets {
+		table.Append([]string{b.BucketName, fmt.Sprint(b.Count), utils.SecsToHHMMSS(b.EstimatedSeconds), utils.SecsToHHMMSS(b.ActualSeconds), formatRatio(b.Ratio()), fmt.Sprintf("%.0f%%", b.OverranPercent())})
+	}
+	table.Render()
+
+	if len(a.Patterns) > 0 {
+		fmt.Fprintln(w)
+		table := newTable(w, "Task", "Work", "Typical estimate", "Typical actual", "Ratio", "Overran")
+		for _, p := range a.Patterns {
+			table.Append([]string{p.Pattern, fmt.Sprint(p.Count), utils.SecsToHHMMSS(p.MedianEstimatedSeconds), utils.SecsToHHMMSS(p.MedianActualSeconds), formatRatio(p.Ratio()), fmt.Sprintf("%.0f%%", p.OverranPercent())})
+		}
+		table.Render()
+	}
+
+	if len(a.Weeks) > 1 {
+		fmt.Fprintln(w)
+		table := newTable(w, "Week", "Work", "Estimated", "Actual", "Ratio")
+		for _, week := range a.Weeks {
+			ratio := ""
+			if week.Count > 0 {
+				ratio = formatRatio(week.Ratio())
+			}
+			table.Append([]string{week.Start.Format("Mon Jan 02"), fmt.Sprint(week.Count), utils.SecsToHHMMSS(week.EstimatedSeconds), utils.SecsToHHMMSS(week.ActualSeconds), ratio})
+		}
+		table.Render()
+	}
+}
+
+// formatRatio prints an actual over estimate ratio as a percentage, 100% being spot on.
+func formatRatio(ratio float64) string {
+	return fmt.Sprintf("%.0f%%", ratio*100)
+}
+
+// suggestDuration prints a longer duration for taskName when similar work has typically taken
+// much longer than durationSeconds.
+func suggestDuration(ctx *cli.Context, db *sqlx.DB, w io.Writer, taskName string, durationSeconds int64) error {
+	s, ok, err := estimates.SuggestFor(ctx.Context, db, calendar.Default(), taskName, durationSeconds, time.Now())
+	if err != nil || !ok {
+		return err
+	}
+
+	minutes := int64(math.Ceil(float64(s.TypicalSeconds) / 60))
+	fmt.Fprintf(w, "Tip: %q work typically took %s over the last %d days it was done, consider planning %d minutes.\n", s.Pattern, utils.SecsToHHMMSS(s.TypicalSeconds), s.Samples, minutes)
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/archive"
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/ical"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/jmoiron/sqlx"
+	"github.com/urfave/cli/v2"
+)
+
+var ExportCmd = &cli.Command{
+	Name:  "export",
+	Usage: "export tasks, buckets, tags, notes and idle time.",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "format",
+			Aliases: []string{"f"},
+			Value:   "json",
+			Usage:   "Output format: json (complete, can be imported), csv (tasks only) or ics (calendar events).",
+		},
+		&cli.BoolFlag{
+			Name:  "ics",
+			Usage: "Shorthand for --format ics.",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "Write to `file` instead of stdout.",
+		},
+		&cli.StringFlag{
+			Name:    "bucket",
+			Aliases: []string{"b"},
+			Usage:   "Only export tasks in a bucket, by name or id. csv and ics only.",
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "Only export tasks created on or after `yyyy-mm-dd`. csv and ics only.",
+		},
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "Only export tasks created on or before `yyyy-mm-dd`. csv and ics only.",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		db := ctx.Context.Value("db").(*sqlx.DB)
+
+		format := ctx.String("format")
+		if ctx.Bool("ics") {
+			format = "ics"
+		}
+
+		filtered := ctx.IsSet("bucket") || ctx.IsSet("from") || ctx.IsSet("to")
+		if format == "json" && filtered {
+			return errors.New("Error, json exports are always complete, --bucket, --from and --to apply to csv and ics")
+		}
+
+		query, err := exportQuery(ctx, db, time.Now())
+		if err != nil {
+			return err
+		}
+
+		w := io.Writer(os.Stdout)
+		if path := ctx.String("output"); path != "" {
+			f, err := os.Create(path)
+			if err != nil {
+				return fmt.Errorf("Error creating export file: %w", err)
+			}
+			defer f.Close()
+			w = f
+		}
+
+		switch format {
+		case "json":
+			a, err := archive.Export(ctx.Context, db, time.Now())
+			if err != nil {
+				return err
+			}
+
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(a)
+		case "csv":
+			all, err := tasks.NewStore(db).Select(ctx.Context, query)
+			if err != nil {
+				return err
+			}
+
+			return tasks.RenderCSV(w, all)
+		case "ics":
+			events, err := ical.TaskEvents(ctx.Context, db, query)
+			if err != nil {
+				return err
+			}
+
+			return ical.Write(w, "block", events)
+		default:
+			return fmt.Errorf("Error, unknown format '%s', expected json, csv or ics", format)
+		}
+	},
+}
+
+// exportQuery selects tasks oldest first, narrowed by the --bucket, --from and --to flags.
+func exportQuery(ctx *cli.Context, db *sqlx.DB, now time.Time) (*tasks.Query, error) {
+	query := tasks.NewQuery().OrderBy("date", false)
+
+	if ctx.String("bucket") != "" {
+		bucket, err := buckets.Resolve(db, ctx.String("bucket"))
+		if err != nil {
+			return nil, err
+		}
+		query.Bucket(bucket.BucketId)
+	}
+
+	if from := ctx.String("from"); from != "" {
+		day, err := parseDay(from, now)
+		if err != nil {
+			return nil, err
+		}
+		query.From(calendar.Default().Start(day))
+	}
+
+	if to := ctx.String("to"); to != "" {
+		day, err := parseDay(to, now)
+		if err != nil {
+			return nil, err
+		}
+		cal := calendar.Default()
+		query.Until(cal.Start(cal.AddDays(day, 1)))
+	}
+
+	return query, nil
+}
+
+var ImportCmd = &cli.Command{
+	Name:      "import",
+	Usage:     "merge a json export into the task database.",
+	ArgsUsage: "[file|-]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Report what would be imported and any conflicts without writing.",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		db := ctx.Context.Value("db").(*sqlx.DB)
+
+		if ctx.NArg() < 1 {
+			return errors.New("Error, expected a file exported with `block export`, or - for stdin")
+		}
+
+		r := io.Reader(os.Stdin)
+		if path := ctx.Args().First(); path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				return fmt.Errorf("Error opening import file: %w", err)
+			}
+			defer f.Close()
+			r = f
+		}
+
+		var a archive.Archive
+		if err := json.NewDecoder(r).Decode(&a); err != nil {
+			return fmt.Errorf("Error reading import file: %w", err)
+		}
+
+		dryRun := ctx.Bool("dry-run")
+		result, err := archive.Import(ctx.Context, db, a, dryRun)
+		if err != nil {
+			return err
+		}
+
+		verb := "Imported"
+		if dryRun {
+			verb = "Would import"
+		}
+		fmt.Printf("%s %d tasks and %d buckets, %d tasks already present.\n", verb, result.TasksCreated, result.BucketsCreated, result.TasksUnchanged)
+
+		if len(result.Conflicts) > 0 {
+			fmt.Printf("%d conflicts, the local copy was kept:\n", len(result.Conflicts))
+			for _, c := range result.Conflicts {
+				fmt.Println("  " + c.String())
+			}
+		}
+
+		return nil
+	},
+}
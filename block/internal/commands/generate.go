@@ -6,6 +6,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/config"
 	"github.com/connorkuljis/block-cli/internal/interactive"
 	"github.com/connorkuljis/block-cli/internal/tasks"
 	"github.com/jmoiron/sqlx"
@@ -23,18 +25,19 @@ var GenerateCmd = &cli.Command{
 		}
 
 		arg1 := ctx.Args().First()
+		cal := calendar.Default()
 		var t time.Time
 		if strings.ToLower(arg1) == "today" {
 			t = time.Now()
 		} else {
-			var err error
-			t, err = time.Parse("2006-01-02", arg1)
+			day, err := cal.ParseDate(arg1)
 			if err != nil {
 				return err
 			}
+			t = cal.Start(day)
 		}
 
-		tasks, err := tasks.GetCapturedTasksByDate(db, t)
+		tasks, err := tasks.NewStore(db).Select(ctx.Context, tasks.NewQuery().OnDay(cal, t).Captured().Completed().OrderBy("date", false))
 		if err != nil {
 			return err
 		}
@@ -47,7 +50,7 @@ var GenerateCmd = &cli.Command{
 			}
 		}
 
-		outfile, err := interactive.FfmpegConcatenateScreenRecordings(t, screenCaptureFiles)
+		outfile, err := interactive.FfmpegConcatenateScreenRecordings(ctx.Context.Value("config").(*config.AppConfig).Config.FfmpegRecordingsPath, t, screenCaptureFiles)
 		if err != nil {
 			fmt.Println("Unable to concatenate recordings")
 			return err
@@ -1,54 +1,301 @@
 package commands
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"io"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/notes"
+	"github.com/connorkuljis/block-cli/internal/plugins"
+	"github.com/connorkuljis/block-cli/internal/tags"
 	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/connorkuljis/block-cli/internal/utils"
 	"github.com/jmoiron/sqlx"
 	"github.com/urfave/cli/v2"
 )
 
 var HistoryCmd = &cli.Command{
-	Name:  "history",
-	Usage: "display task history.",
+	Name:      "history",
+	Usage:     "display task history.",
+	ArgsUsage: "[today|yyyy-mm-dd]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "verbose",
+			Aliases: []string{"v"},
+			Usage:   "Show tags, notes and interruptions for each task.",
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "Only show tasks created on or after `yyyy-mm-dd`.",
+		},
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "Only show tasks created on or before `yyyy-mm-dd`.",
+		},
+		&cli.BoolFlag{
+			Name:  "week",
+			Usage: "Only show tasks from this week, starting Monday.",
+		},
+		&cli.BoolFlag{
+			Name:  "month",
+			Usage: "Only show tasks from this month.",
+		},
+		&cli.StringFlag{
+			Name:    "bucket",
+			Aliases: []string{"b"},
+			Usage:   "Only show tasks in a bucket, by name or id.",
+		},
+		&cli.BoolFlag{
+			Name:  "completed",
+			Usage: "Only show tasks that ran for their full planned time.",
+		},
+		&cli.BoolFlag{
+			Name:  "cancelled",
+			Usage: "Only show tasks stopped before their planned time.",
+		},
+		&cli.BoolFlag{
+			Name:  "deleted",
+			Usage: "Show deleted tasks instead, restore them with `block restore`.",
+		},
+		&cli.StringFlag{
+			Name:    "search",
+			Aliases: []string{"s"},
+			Usage:   "Only show tasks whose name contains `text`.",
+		},
+		&cli.StringSliceFlag{
+			Name:    "tag",
+			Aliases: []string{"t"},
+			Usage:   "Only show tasks with every given tag.",
+		},
+		&cli.StringFlag{
+			Name:  "sort",
+			Value: "date",
+			Usage: "Sort by date, duration, estimate, name or completion.",
+		},
+		&cli.BoolFlag{
+			Name:    "reverse",
+			Aliases: []string{"r"},
+			Usage:   "Sort ascending instead of descending.",
+		},
+		&cli.IntFlag{
+			Name:    "limit",
+			Aliases: []string{"n"},
+			Usage:   "Show at most `n` tasks.",
+		},
+		&cli.StringFlag{
+			Name:    "format",
+			Aliases: []string{"f"},
+			Value:   tasks.FormatTable,
+			Usage:   "Output format: table, json, csv or markdown.",
+		},
+	},
 	Action: func(ctx *cli.Context) error {
 		db := ctx.Context.Value("db").(*sqlx.DB)
 
-		var all []tasks.Task
+		query, err := historyQuery(ctx, db, time.Now())
+		if err != nil {
+			return err
+		}
 
-		if ctx.NArg() == 0 {
-			var err error
-			all, err = tasks.GetAllTasks(db)
-			if err != nil {
-				log.Fatal(err)
-			}
+		all, err := tasks.NewStore(db).Select(ctx.Context, query)
+		if err != nil {
+			return err
 		}
 
-		if ctx.NArg() == 1 {
-			switch strings.ToLower(ctx.Args().Get(0)) {
-			case "today":
-				var err error
-				all, err = tasks.GetTasksByDate(db, time.Now())
-				if err != nil {
-					return err
-				}
-			default:
-				indate, err := time.Parse("2006-01-02", ctx.Args().Get(0))
-				if err != nil {
-					log.Fatal("error parsing date: " + ctx.Args().Get(0))
-				}
-
-				all, err = tasks.GetTasksByDate(db, indate)
-				if err != nil {
-					return err
-				}
-			}
+		format := ctx.String("format")
+		if err := tasks.Render(os.Stdout, format, all); err != nil {
+			return err
 		}
 
-		tasks.RenderTable(all)
+		if format != tasks.FormatTable {
+			return nil
+		}
+
+		if err := renderTagSummary(os.Stdout, db, all); err != nil {
+			return err
+		}
+
+		if err := renderGoalProgress(os.Stdout, db, time.Now()); err != nil {
+			return err
+		}
+
+		if ctx.Bool("verbose") {
+			return renderTaskDetails(os.Stdout, db, all)
+		}
 
 		return nil
 	},
 }
+
+// historyQuery builds the task query described by the history flags and optional date argument.
+func historyQuery(ctx *cli.Context, db *sqlx.DB, now time.Time) (*tasks.Query, error) {
+	query := tasks.NewQuery()
+
+	if ctx.NArg() > 0 {
+		day, err := parseDay(ctx.Args().First(), now)
+		if err != nil {
+			return nil, err
+		}
+		cal := calendar.Default()
+		query.OnDay(cal, cal.Start(day))
+	}
+
+	if ctx.Bool("deleted") {
+		query.Deleted()
+	}
+
+	if ctx.Int("limit") < 0 {
+		return nil, errors.New("Error, --limit must not be negative")
+	}
+
+	query.OrderBy(ctx.String("sort"), !ctx.Bool("reverse")).Limit(ctx.Int("limit"))
+
+	return filterQuery(ctx, db, now, query)
+}
+
+// filterQuery narrows query by the date, bucket, status, search and tag flags shared by history and delete.
+// Flags a command does not define are ignored.
+func filterQuery(ctx *cli.Context, db *sqlx.DB, now time.Time, query *tasks.Query) (*tasks.Query, error) {
+	if ctx.Bool("week") && ctx.Bool("month") {
+		return nil, errors.New("Error, --week and --month cannot be used together")
+	}
+	cal := calendar.Default()
+	if ctx.Bool("week") {
+		query.Between(cal.WeekBounds(now))
+	}
+	if ctx.Bool("month") {
+		query.Between(cal.MonthBounds(now))
+	}
+
+	if from := ctx.String("from"); from != "" {
+		day, err := parseDay(from, now)
+		if err != nil {
+			return nil, err
+		}
+		query.From(cal.Start(day))
+	}
+	if to := ctx.String("to"); to != "" {
+		day, err := parseDay(to, now)
+		if err != nil {
+			return nil, err
+		}
+		query.Until(cal.Start(cal.AddDays(day, 1)))
+	}
+
+	if ctx.String("bucket") != "" {
+		bucket, err := buckets.Resolve(db, ctx.String("bucket"))
+		if err != nil {
+			return nil, err
+		}
+		query.Bucket(bucket.BucketId)
+	}
+
+	if ctx.Bool("completed") && ctx.Bool("cancelled") {
+		return nil, errors.New("Error, --completed and --cancelled cannot be used together")
+	}
+	if ctx.Bool("completed") {
+		query.Completed()
+	}
+	if ctx.Bool("cancelled") {
+		query.Cancelled()
+	}
+
+	if search := strings.TrimSpace(ctx.String("search")); search != "" {
+		query.Search(search)
+	}
+
+	query.Tagged(ctx.StringSlice("tag")...)
+
+	return query, nil
+}
+
+// parseDay accepts `today`, `yesterday` or a yyyy-mm-dd date, returning the date in the configured calendar.
+func parseDay(s string, now time.Time) (time.Time, error) {
+	cal := calendar.Default()
+	switch strings.ToLower(s) {
+	case "today":
+		return cal.Today(now), nil
+	case "yesterday":
+		return cal.AddDays(cal.Today(now), -1), nil
+	}
+
+	day, err := cal.ParseDate(s)
+	if err != nil {
+		return day, fmt.Errorf("Error parsing date '%s', expected today, yesterday or yyyy-mm-dd", s)
+	}
+
+	return day, nil
+}
+
+// renderTaskDetails prints the notes, interruptions and plugin data recorded against each task.
+func renderTaskDetails(w io.Writer, db *sqlx.DB, all []tasks.Task) error {
+	for _, task := range all {
+		taskTags, err := tags.GetTagsByTaskId(db, task.TaskId)
+		if err != nil {
+			return err
+		}
+
+		taskNotes, err := notes.GetNotesByTaskId(db, task.TaskId)
+		if err != nil {
+			return err
+		}
+
+		interruptions, err := notes.GetInterruptionsByTaskId(db, task.TaskId)
+		if err != nil {
+			return err
+		}
+
+		pluginData, err := plugins.GetDataByTaskId(db, task.TaskId)
+		if err != nil {
+			return err
+		}
+
+		if len(taskTags) == 0 && len(taskNotes) == 0 && len(interruptions) == 0 && len(pluginData) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "\n#%d %s\n", task.TaskId, task.TaskName)
+		if len(taskTags) > 0 {
+			fmt.Fprintf(w, "  tags: %s\n", strings.Join(taskTags, ", "))
+		}
+		for _, note := range taskNotes {
+			if note.FocusRating.Valid {
+				fmt.Fprintf(w, "  outcome: %s (focus %d/%d)\n", note.Outcome, note.FocusRating.Int64, notes.MaxFocusRating)
+			} else {
+				fmt.Fprintf(w, "  outcome: %s\n", note.Outcome)
+			}
+		}
+		for _, interruption := range interruptions {
+			fmt.Fprintf(w, "  interrupted %s: %s\n", interruption.CreatedAt.Format("15:04"), interruption.Reason)
+		}
+		for _, d := range pluginData {
+			fmt.Fprintf(w, "  %s.%s: %s\n", d.Plugin, d.Key, d.Value)
+		}
+	}
+
+	return nil
+}
+
+// renderTagSummary prints the time spent per tag across the given tasks.
+func renderTagSummary(w io.Writer, db *sqlx.DB, all []tasks.Task) error {
+	summaries, err := tags.Summarise(db, all)
+	if err != nil {
+		return err
+	}
+
+	if len(summaries) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w, "\nTags:")
+	for _, s := range summaries {
+		fmt.Fprintf(w, "  %s: %s (%d tasks)\n", s.TagName, utils.SecsToHHMMSS(s.TotalSeconds), s.TaskCount)
+	}
+
+	return nil
+}
@@ -0,0 +1,217 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/tags"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/connorkuljis/block-cli/internal/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/urfave/cli/v2"
+)
+
+var LogCmd = &cli.Command{
+	Name:      "log",
+	Usage:     "record time worked without running a session.",
+	ArgsUsage: "[duration] [taskname]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "at",
+			Usage: "When the work started, `yyyy-mm-dd hh:mm` or hh:mm today. Defaults to the duration ago.",
+		},
+		&cli.StringFlag{
+			Name:    "bucket",
+			Aliases: []string{"b"},
+			Usage:   "Tag the task with a bucket name or id.",
+		},
+		&cli.StringSliceFlag{
+			Name:    "tag",
+			Aliases: []string{"t"},
+			Usage:   "Label the task, can be repeated: -t client-x -t bug",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		db := ctx.Context.Value("db").(*sqlx.DB)
+
+		opts, args, err := parseLogArgs(ctx.Args().Slice(), logOptions{
+			At:     ctx.String("at"),
+			Bucket: ctx.String("bucket"),
+			Tags:   ctx.StringSlice("tag"),
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(args) < 1 {
+			return errors.New("Error, expected a duration such as 45m, 1h30m or 45")
+		}
+		if len(args) > 2 {
+			return errors.New(`Error, too many arguments, quote the task name: block log 45m "code review"`)
+		}
+
+		if err := tags.Validate(opts.Tags); err != nil {
+			return err
+		}
+
+		if err := tags.Validate(opts.Tags); err != nil {
+			return err
+		}
+
+		duration, err := parseLogDuration(args[0])
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		startedAt := now.Add(-duration)
+		if opts.At != "" {
+			startedAt, err = parseStartTime(opts.At, now)
+			if err != nil {
+				return err
+			}
+		}
+
+		if startedAt.Add(duration).After(now) {
+			return errors.New("Error, logged time must not finish in the future, use `block start` instead")
+		}
+
+		var taskName string
+		if len(args) == 2 {
+			taskName = args[1]
+		}
+		task := tasks.NewLoggedTask(taskName, duration, startedAt)
+
+		if opts.Bucket != "" {
+			bucket, err := buckets.ResolveActive(db, opts.Bucket)
+			if err != nil {
+				return err
+			}
+			task.AddBucketTag(bucket.BucketId)
+		}
+
+		tx, err := db.BeginTxx(ctx.Context, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if err := tasks.NewStore(tx).Log(ctx.Context, task); err != nil {
+			return err
+		}
+		if err := tags.AddTags(tx, task.TaskId, opts.Tags); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+
+		fmt.Printf("Logged task %d %q, %s from %s.\n", task.TaskId, task.TaskName, utils.SecsToHHMMSS(task.ActualDurationSeconds.Int64), startedAt.Format("Mon Jan 02 15:04"))
+
+		progress, err := dailyGoalProgress(ctx, db, startedAt, true)
+		if err != nil {
+			return err
+		}
+		renderDailyGoal(os.Stdout, progress)
+
+		return nil
+	},
+}
+
+// logOptions are the flags of block log.
+type logOptions struct {
+	At     string
+	Bucket string
+	Tags   []string
+}
+
+// parseLogArgs takes the flags given after the duration and task name out of args, as urfave/cli
+// stops parsing flags at the first argument. They are applied over opts, the flags given before
+// them. Everything after -- is an argument.
+func parseLogArgs(args []string, opts logOptions) (logOptions, []string, error) {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i+1:]...)
+			break
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || !slices.Contains([]string{"at", "bucket", "b", "tag", "t"}, name) {
+			rest = append(rest, arg)
+			continue
+		}
+
+		if !hasValue {
+			if i+1 == len(args) {
+				return opts, nil, fmt.Errorf("Error, flag %s needs a value", arg)
+			}
+			i++
+			value = args[i]
+		}
+
+		switch name {
+		case "at":
+			opts.At = value
+		case "bucket", "b":
+			opts.Bucket = value
+		case "tag", "t":
+			opts.Tags = append(opts.Tags, value)
+		}
+	}
+
+	return opts, rest, nil
+}
+
+// parseLogDuration accepts a go duration such as 45m or 1h30m, or a plain number of minutes.
+func parseLogDuration(arg string) (time.Duration, error) {
+	var duration time.Duration
+	if strings.ContainsAny(arg, "hms") {
+		var err error
+		duration, err = time.ParseDuration(arg)
+		if err != nil {
+			return 0, fmt.Errorf("Error parsing duration '%s', expected eg. 45m or 1h30m", arg)
+		}
+	} else {
+		seconds, err := parseDurationMinutes(arg)
+		if err != nil {
+			return 0, err
+		}
+		duration = time.Duration(seconds) * time.Second
+	}
+
+	if duration <= 0 {
+		return 0, errors.New("Error, duration must be positive")
+	}
+
+	return duration, nil
+}
+
+// parseStartTime accepts `yyyy-mm-dd hh:mm`, or `hh:mm` within the day of now, in the calendar's timezone.
+func parseStartTime(s string, now time.Time) (time.Time, error) {
+	cal := calendar.Default()
+	if t, err := time.ParseInLocation("2006-01-02 15:04", s, cal.Location()); err == nil {
+		return t, nil
+	}
+
+	clock, err := time.ParseInLocation("15:04", s, cal.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Error parsing start time '%s', expected yyyy-mm-dd hh:mm or hh:mm", s)
+	}
+
+	// a clock time before the day start hour falls after midnight, at the end of the day.
+	date := cal.Today(now)
+	if clock.Hour() < cal.StartHour() {
+		date = cal.AddDays(date, 1)
+	}
+
+	y, m, d := date.Date()
+	return time.Date(y, m, d, clock.Hour(), clock.Minute(), 0, 0, cal.Location()), nil
+}
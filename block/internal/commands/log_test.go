@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/config"
+	"github.com/connorkuljis/block-cli/internal/db/dbtest"
+	"github.com/connorkuljis/block-cli/internal/tags"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/urfave/cli/v2"
+)
+
+func TestLogTrailingFlags(t *testing.T) {
+	conn := dbtest.Open(t)
+
+	if err := buckets.InsertBucket(conn, buckets.NewBucket("review")); err != nil {
+		t.Fatal(err)
+	}
+
+	app := &cli.App{
+		Before: func(c *cli.Context) error {
+			c.Context = context.WithValue(c.Context, "db", conn)
+			c.Context = context.WithValue(c.Context, "config", &config.AppConfig{Config: config.Default()})
+			return nil
+		},
+		Commands: []*cli.Command{LogCmd},
+	}
+
+	err := app.Run([]string{"block", "log", "45m", "code review", "--at", "2026-10-17 14:00", "--bucket", "review", "-t", "client-x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logged, err := tasks.NewStore(conn).Select(context.Background(), tasks.NewQuery())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logged) != 1 {
+		t.Fatalf("Expected one logged task, got: %d", len(logged))
+	}
+
+	task := logged[0]
+	want := time.Date(2026, 10, 17, 14, 0, 0, 0, calendar.Default().Location())
+	if task.TaskName != "code review" || !task.CreatedAt.Equal(want) || task.ActualDurationSeconds.Int64 != 45*60 {
+		t.Errorf("Expected 45m of code review from 14:00, got: %q at %s for %ds", task.TaskName, task.CreatedAt, task.ActualDurationSeconds.Int64)
+	}
+	if !task.BucketId.Valid {
+		t.Error("Expected the task to be in the review bucket")
+	}
+
+	taskTags, err := tags.GetTagsByTaskId(conn, task.TaskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(taskTags) != 1 || taskTags[0] != "client-x" {
+		t.Errorf("Expected the client-x tag, got: %v", taskTags)
+	}
+}
+
+func TestParseLogArgs(t *testing.T) {
+	opts, args, err := parseLogArgs([]string{"45m", "--at=09:00", "-b", "work", "--", "--not-a-flag"}, logOptions{Tags: []string{"a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.At != "09:00" || opts.Bucket != "work" || len(opts.Tags) != 1 {
+		t.Errorf("Expected --at and -b to be taken, got: %+v", opts)
+	}
+	if len(args) != 2 || args[1] != "--not-a-flag" {
+		t.Errorf("Expected arguments after -- to be kept, got: %v", args)
+	}
+
+	if _, _, err := parseLogArgs([]string{"45m", "--at"}, logOptions{}); err == nil {
+		t.Error("Expected an error for a flag without a value")
+	}
+}
@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/notes"
+	"github.com/jmoiron/sqlx"
+	"github.com/urfave/cli/v2"
+)
+
+// noteFlags are shared by commands that run a session and capture its outcome.
+var noteFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:    "note",
+		Aliases: []string{"n"},
+		Usage:   "Record an outcome note for the session.",
+	},
+	&cli.IntFlag{
+		Name:  "rating",
+		Usage: fmt.Sprintf("Rate your focus from %d to %d.", notes.MinFocusRating, notes.MaxFocusRating),
+	},
+	&cli.BoolFlag{
+		Name:    "reflect",
+		Aliases: []string{"r"},
+		Usage:   "Prompt for an outcome note and focus rating when the session ends.",
+	},
+}
+
+func validateNoteFlags(ctx *cli.Context) error {
+	rating := ctx.Int("rating")
+	if rating != 0 && (rating < notes.MinFocusRating || rating > notes.MaxFocusRating) {
+		return fmt.Errorf("Error, rating must be between %d and %d", notes.MinFocusRating, notes.MaxFocusRating)
+	}
+	return nil
+}
+
+// recordNote saves the outcome note for a finished session from flags, prompting for anything missing when --reflect is set.
+func recordNote(ctx *cli.Context, db *sqlx.DB, taskId int64) error {
+	outcome := ctx.String("note")
+	rating := ctx.Int("rating")
+
+	if ctx.Bool("reflect") {
+		in := bufio.NewReader(os.Stdin)
+		if outcome == "" {
+			outcome = prompt(in, os.Stdout, "What was the outcome? ")
+		}
+		for rating == 0 {
+			answer := prompt(in, os.Stdout, fmt.Sprintf("How focused were you (%d-%d, blank to skip)? ", notes.MinFocusRating, notes.MaxFocusRating))
+			if answer == "" {
+				break
+			}
+			n, err := strconv.Atoi(answer)
+			if err != nil || n < notes.MinFocusRating || n > notes.MaxFocusRating {
+				fmt.Printf("Please enter a number from %d to %d.\n", notes.MinFocusRating, notes.MaxFocusRating)
+				continue
+			}
+			rating = n
+		}
+	}
+
+	if outcome == "" && rating == 0 {
+		return nil
+	}
+
+	note := notes.NewNote(taskId, outcome, rating, time.Now())
+	if err := notes.InsertNote(db, note); err != nil {
+		return fmt.Errorf("Error saving note: %w", err)
+	}
+
+	return nil
+}
+
+func prompt(in *bufio.Reader, w io.Writer, question string) string {
+	fmt.Fprint(w, question)
+	answer, _ := in.ReadString('\n')
+	return strings.TrimSpace(answer)
+}
@@ -0,0 +1,321 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/app"
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/config"
+	"github.com/connorkuljis/block-cli/internal/plan"
+	"github.com/connorkuljis/block-cli/internal/tags"
+	"github.com/connorkuljis/block-cli/internal/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/urfave/cli/v2"
+)
+
+var PlanCmd = &cli.Command{
+	Name:  "plan",
+	Usage: "plan tasks ahead, then work through them with `block next`.",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "add",
+			Usage:     "add a task to the end of the plan.",
+			ArgsUsage: "[duration] [taskname]",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "bucket",
+					Aliases: []string{"b"},
+					Usage:   "Tag the task with a bucket name or id.",
+				},
+				&cli.StringFlag{
+					Name:  "for",
+					Value: "today",
+					Usage: "The day the task is planned for, today, tomorrow or `yyyy-mm-dd`.",
+				},
+			},
+			Action: func(ctx *cli.Context) error {
+				db := ctx.Context.Value("db").(*sqlx.DB)
+
+				if ctx.NArg() < 2 {
+					return errors.New("Error, expected a duration in minutes and a task name")
+				}
+				if ctx.NArg() > 2 {
+					return errors.New(`Error, too many arguments, flags go before the duration: block plan add -b work 45 "code review"`)
+				}
+
+				durationSeconds, err := parseDurationMinutes(ctx.Args().Get(0))
+				if err != nil {
+					return err
+				}
+				if durationSeconds <= 0 {
+					return errors.New("Error, duration must be positive")
+				}
+
+				now := time.Now()
+				var date time.Time
+				if strings.EqualFold(ctx.String("for"), "tomorrow") {
+					cal := calendar.Default()
+					date = cal.AddDays(cal.Today(now), 1)
+				} else {
+					date, err = parseDay(ctx.String("for"), now)
+					if err != nil {
+						return err
+					}
+				}
+
+				item := plan.NewItem(ctx.Args().Get(1), durationSeconds, date, now)
+				if ctx.String("bucket") != "" {
+					bucket, err := buckets.ResolveActive(db, ctx.String("bucket"))
+					if err != nil {
+						return err
+					}
+					item.AddBucketTag(bucket.BucketId)
+				}
+
+				if err := plan.Insert(db, item); err != nil {
+					return err
+				}
+
+				fmt.Printf("Planned %q for %s at position %d (id %d).\n", item.TaskName, utils.SecsToHHMMSS(item.EstimatedDurationSeconds), item.Position, item.PlanId)
+
+				return suggestDuration(ctx, db, os.Stdout, item.TaskName, item.EstimatedDurationSeconds)
+			},
+		},
+		{
+			Name:  "list",
+			Usage: "list planned tasks in the order `block next` starts them.",
+			Action: func(ctx *cli.Context) error {
+				db := ctx.Context.Value("db").(*sqlx.DB)
+
+				backlog, err := plan.Backlog(db)
+				if err != nil {
+					return err
+				}
+
+				if len(backlog) == 0 {
+					fmt.Println("Nothing planned, add tasks with `block plan add`.")
+					return nil
+				}
+
+				return renderBacklog(os.Stdout, db, backlog)
+			},
+		},
+		{
+			Name:      "move",
+			Usage:     "move a planned task to a position, 1 is next.",
+			ArgsUsage: "[id] [position]",
+			Action: func(ctx *cli.Context) error {
+				db := ctx.Context.Value("db").(*sqlx.DB)
+
+				if ctx.NArg() != 2 {
+					return errors.New("Error, expected a planned task id and a position")
+				}
+
+				planId, err := strconv.ParseInt(ctx.Args().Get(0), 10, 64)
+				if err != nil {
+					return fmt.Errorf("Error parsing planned task id '%s'", ctx.Args().Get(0))
+				}
+				position, err := strconv.Atoi(ctx.Args().Get(1))
+				if err != nil {
+					return fmt.Errorf("Error parsing position '%s'", ctx.Args().Get(1))
+				}
+
+				if err := plan.Move(db, planId, position); err != nil {
+					return err
+				}
+
+				backlog, err := plan.Backlog(db)
+				if err != nil {
+					return err
+				}
+
+				return renderBacklog(os.Stdout, db, backlog)
+			},
+		},
+		{
+			Name:      "rm",
+			Usage:     "remove a planned task.",
+			ArgsUsage: "[id]",
+			Action: func(ctx *cli.Context) error {
+				db := ctx.Context.Value("db").(*sqlx.DB)
+
+				if ctx.NArg() < 1 {
+					return errors.New("Error, expected a planned task id")
+				}
+
+				planId, err := strconv.ParseInt(ctx.Args().First(), 10, 64)
+				if err != nil {
+					return fmt.Errorf("Error parsing planned task id '%s'", ctx.Args().First())
+				}
+
+				if err := plan.Remove(db, planId); err != nil {
+					return err
+				}
+
+				fmt.Printf("Removed planned task %d.\n", planId)
+				return nil
+			},
+		},
+		{
+			Name:      "review",
+			Usage:     "compare the plan for a day with the time spent.",
+			ArgsUsage: "[today|yesterday|yyyy-mm-dd]",
+			Action: func(ctx *cli.Context) error {
+				db := ctx.Context.Value("db").(*sqlx.DB)
+
+				cal := calendar.Default()
+				t := time.Now()
+				if ctx.NArg() > 0 {
+					day, err := parseDay(ctx.Args().First(), t)
+					if err != nil {
+						return err
+					}
+					t = cal.Start(day)
+				}
+
+				review, err := plan.ReviewDay(ctx.Context, db, cal, t)
+				if err != nil {
+					return err
+				}
+
+				if len(review.Items) == 0 {
+					fmt.Printf("Nothing was planned for %s.\n", review.Date.Format("2006-01-02"))
+					return nil
+				}
+
+				renderPlanReview(os.Stdout, review)
+				return nil
+			},
+		},
+	},
+}
+
+var NextCmd = &cli.Command{
+	Name:  "next",
+	Usage: "start the next planned task.",
+	Flags: append([]cli.Flag{
+		&cli.BoolFlag{
+			Name:  "no-blocker",
+			Usage: "Disables the blocker.",
+		},
+		&cli.BoolFlag{
+			Name:    "capture",
+			Aliases: []string{"c"},
+			Usage:   "Enables screen capture.",
+		},
+		&cli.StringSliceFlag{
+			Name:    "tag",
+			Aliases: []string{"t"},
+			Usage:   "Label the task, can be repeated: -t client-x -t bug",
+		},
+	}, noteFlags...),
+	Action: func(ctx *cli.Context) error {
+		db := ctx.Context.Value("db").(*sqlx.DB)
+
+		if err := validateNoteFlags(ctx); err != nil {
+			return err
+		}
+
+		if err := tags.Validate(ctx.StringSlice("tag")); err != nil {
+			return err
+		}
+
+		item, err := plan.Next(db)
+		if err != nil {
+			return err
+		}
+
+		currentTask := item.NewTask(time.Now())
+		currentTask.ScreenEnabled = utils.BoolToInt(ctx.Bool("capture"))
+		currentTask.BlockerEnabled = utils.BoolToInt(!ctx.Bool("no-blocker"))
+
+		fmt.Printf("Next up: %q for %s.\n", item.TaskName, utils.SecsToHHMMSS(item.EstimatedDurationSeconds))
+
+		progress, err := dailyGoalProgress(ctx, db, time.Now(), false)
+		if err != nil {
+			return err
+		}
+		renderDailyGoal(os.Stdout, progress)
+
+		err = app.Start(ctx.Context, os.Stdout, db, ctx.Context.Value("config").(*config.AppConfig).Config, pluginHost(ctx), currentTask, func(tx *sqlx.Tx) error {
+			if err := tags.AddTags(tx, currentTask.TaskId, ctx.StringSlice("tag")); err != nil {
+				return err
+			}
+			// the item leaves the backlog as the session starts, so it is not offered again while it runs.
+			return plan.MarkStarted(tx, item.PlanId, currentTask.TaskId, currentTask.CreatedAt)
+		})
+		if err != nil {
+			return err
+		}
+
+		err = recordNote(ctx, db, currentTask.TaskId)
+		if err != nil {
+			return err
+		}
+
+		return printDailySummary(ctx, db, currentTask.CreatedAt)
+	},
+}
+
+// renderBacklog prints the planned tasks in order with their running total.
+func renderBacklog(w io.Writer, db *sqlx.DB, backlog []plan.Item) error {
+	allBuckets, err := buckets.GetAllBuckets(db)
+	if err != nil {
+		return err
+	}
+	bucketNames := make(map[int64]string)
+	for _, bucket := range allBuckets {
+		bucketNames[bucket.BucketId] = bucket.BucketName
+	}
+
+	table := newTable(w, "#", "Id", "Task", "Estimate", "Bucket", "Planned for", "Running total")
+
+	var total int64
+	for i, item := range backlog {
+		total += item.EstimatedDurationSeconds
+		table.Append([]string{
+			fmt.Sprint(i + 1),
+			fmt.Sprint(item.PlanId),
+			item.TaskName,
+			utils.SecsToHHMMSS(item.EstimatedDurationSeconds),
+			bucketNames[item.BucketId.Int64],
+			item.PlannedFor,
+			utils.SecsToHHMMSS(total),
+		})
+	}
+	table.Render()
+
+	return nil
+}
+
+// renderPlanReview prints each planned task's estimate against the time spent, then the day's totals.
+func renderPlanReview(w io.Writer, r plan.Review) {
+	table := newTable(w, "Task", "Estimate", "Actual", "Difference", "Status")
+	for _, c := range r.Items {
+		actual, delta, status := "", "", "not started"
+		if c.Started {
+			actual = utils.SecsToHHMMSS(c.ActualSeconds)
+			delta = formatSignedHHMMSS(c.DeltaSeconds)
+			status = "stopped early"
+			if c.Completed {
+				status = "done"
+			}
+		}
+		table.Append([]string{c.Item.TaskName, utils.SecsToHHMMSS(c.Item.EstimatedDurationSeconds), actual, delta, status})
+	}
+	table.Render()
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Planned:   %s\n", utils.SecsToHHMMSS(r.PlannedSeconds))
+	fmt.Fprintf(w, "Actual:    %s (%s)\n", utils.SecsToHHMMSS(r.ActualSeconds), formatSignedHHMMSS(r.ActualSeconds-r.PlannedSeconds))
+	if r.UnplannedTasks > 0 {
+		fmt.Fprintf(w, "Unplanned: %s across %d tasks\n", utils.SecsToHHMMSS(r.UnplannedSeconds), r.UnplannedTasks)
+	}
+}
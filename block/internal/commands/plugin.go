@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/connorkuljis/block-cli/internal/config"
+	"github.com/connorkuljis/block-cli/internal/plugins"
+	"github.com/jmoiron/sqlx"
+	"github.com/urfave/cli/v2"
+)
+
+var PluginCmd = &cli.Command{
+	Name:  "plugin",
+	Usage: "show the block-plugin-* executables found on PATH.",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "list",
+			Usage: "list plugins with the events they follow and the commands they add.",
+			Action: func(ctx *cli.Context) error {
+				loaded := ctx.Context.Value("plugins").([]plugins.Loaded)
+				if len(loaded) == 0 {
+					fmt.Printf("No plugins found, plugins are executables on PATH named %s<name>.\n", plugins.Prefix)
+					return nil
+				}
+
+				table := newTable(os.Stdout, "Plugin", "Events", "Commands", "Path")
+				for _, p := range loaded {
+					var events, commands []string
+					for _, e := range p.Manifest.Events {
+						events = append(events, string(e))
+					}
+					for _, c := range p.Manifest.Commands {
+						commands = append(commands, c.Name)
+					}
+					table.Append([]string{p.Name, strings.Join(events, ", "), strings.Join(commands, ", "), p.Path})
+				}
+				table.Render()
+
+				return nil
+			},
+		},
+	},
+}
+
+// PluginCommands returns a command for each one the plugins add, leaving out any whose name
+// is already taken by a built-in command or an earlier plugin.
+func PluginCommands(loaded []plugins.Loaded, builtin []*cli.Command) []*cli.Command {
+	taken := make(map[string]bool)
+	for _, cmd := range builtin {
+		for _, name := range cmd.Names() {
+			taken[name] = true
+		}
+	}
+
+	var cmds []*cli.Command
+	for _, p := range loaded {
+		for _, c := range p.Manifest.Commands {
+			if c.Name == "" || taken[c.Name] {
+				slog.Warn(fmt.Sprintf("Plugin %s command '%s' is already taken, skipping it.", p.Name, c.Name))
+				continue
+			}
+			taken[c.Name] = true
+
+			cmds = append(cmds, &cli.Command{
+				Name:            c.Name,
+				Usage:           fmt.Sprintf("%s (plugin %s)", c.Usage, p.Name),
+				SkipFlagParsing: true,
+				Action: func(ctx *cli.Context) error {
+					paths := ctx.Context.Value("paths").(config.Paths)
+					return p.Plugin.Command(c.Name, ctx.Args().Slice(), []string{
+						"BLOCK_WORKSPACE=" + paths.Workspace,
+						"BLOCK_DB=" + paths.DbFile(),
+						"BLOCK_CONFIG=" + paths.ConfigFile(),
+					})
+				},
+			})
+		}
+	}
+
+	return cmds
+}
+
+// pluginHost sends session events to the loaded plugins.
+func pluginHost(ctx *cli.Context) *plugins.Host {
+	loaded := ctx.Context.Value("plugins").([]plugins.Loaded)
+	if len(loaded) == 0 {
+		return nil
+	}
+
+	return plugins.NewHost(ctx.Context.Value("db").(*sqlx.DB), loaded)
+}
@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/config"
+	"github.com/connorkuljis/block-cli/internal/goals"
+	"github.com/connorkuljis/block-cli/internal/report"
+	"github.com/connorkuljis/block-cli/internal/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/urfave/cli/v2"
+)
+
+var ReportCmd = &cli.Command{
+	Name:      "report",
+	Usage:     "summarise focus time for a day, week or month.",
+	ArgsUsage: "[today|yesterday|yyyy-mm-dd]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "period",
+			Aliases: []string{"p"},
+			Value:   report.PeriodWeek,
+			Usage:   "Report on the day, week or month containing the given date.",
+		},
+		&cli.DurationFlag{
+			Name:  "goal",
+			Usage: "Daily focus time a day must reach to count towards a streak. Defaults to the configured daily goal, days with a recorded goal keep theirs.",
+		},
+		&cli.StringFlag{
+			Name:    "format",
+			Aliases: []string{"f"},
+			Value:   "table",
+			Usage:   "Output format: table or json.",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		db := ctx.Context.Value("db").(*sqlx.DB)
+
+		cal := calendar.Default()
+		now := time.Now()
+		if ctx.NArg() > 0 {
+			day, err := parseDay(ctx.Args().First(), now)
+			if err != nil {
+				return err
+			}
+			now = cal.Start(day)
+		}
+
+		goal := ctx.Duration("goal")
+		if !ctx.IsSet("goal") {
+			g, err := goals.NewFromConfig(ctx.Context.Value("config").(*config.AppConfig).Config.Goals)
+			if err != nil {
+				return err
+			}
+			goal = g.Daily
+		}
+
+		r, err := report.Generate(ctx.Context, db, report.Options{
+			Period:    ctx.String("period"),
+			Now:       now,
+			Calendar:  cal,
+			DailyGoal: goal,
+		})
+		if err != nil {
+			return err
+		}
+
+		switch ctx.String("format") {
+		case "table":
+			renderReport(os.Stdout, r)
+			return nil
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(r)
+		default:
+			return fmt.Errorf("Error, unknown format '%s', expected table or json", ctx.String("format"))
+		}
+	},
+}
+
+func renderReport(w io.Writer, r report.Report) {
+	fmt.Fprintf(w, "%s report, %s to %s\n\n", r.Period, r.Start.Format("Mon Jan 02 2006"), r.End.AddDate(0, 0, -1).Format("Mon Jan 02 2006"))
+
+	fmt.Fprintf(w, "Focus time:       %s (%s vs previous %s)\n", utils.SecsToHHMMSS(r.Stats.FocusSeconds), formatDelta(r.DeltaSeconds, r.DeltaPercent, r.Previous.FocusSeconds), r.Period)
+	fmt.Fprintf(w, "Sessions:         %d (%d completed, %.0f%%)\n", r.Stats.Sessions, r.Stats.Completed, r.Stats.CompletionRate)
+	fmt.Fprintf(w, "Average session:  %s\n", utils.SecsToHHMMSS(r.Stats.AverageSessionSeconds))
+	if r.DailyGoalSeconds == 0 {
+		fmt.Fprintln(w, "Daily goal:       none")
+	} else {
+		fmt.Fprintf(w, "Daily goal:       %s\n", utils.SecsToHHMMSS(r.DailyGoalSeconds))
+		fmt.Fprintf(w, "Current streak:   %d days\n", r.CurrentStreak.Days)
+		if r.LongestStreak.Days > 0 {
+			fmt.Fprintf(w, "Longest streak:   %d days (%s to %s)\n", r.LongestStreak.Days, r.LongestStreak.Start.Format("2006-01-02"), r.LongestStreak.End.Format("2006-01-02"))
+		}
+	}
+
+	if len(r.Days) > 1 {
+		fmt.Fprintln(w)
+		table := newTable(w, "Day", "Focus", "Sessions", "Goal")
+		for _, day := range r.Days {
+			var met string
+			if day.MetGoal {
+				met = "✅"
+			}
+			table.Append([]string{day.Start.Format("Mon Jan 02"), utils.SecsToHHMMSS(day.FocusSeconds), fmt.Sprint(day.Sessions), met})
+		}
+		table.Render()
+	}
+
+	if len(r.Weeks) > 1 {
+		fmt.Fprintln(w)
+		table := newTable(w, "Week", "Focus", "Sessions", "Change")
+		for _, week := range r.Weeks {
+			table.Append([]string{week.Start.Format("Mon Jan 02"), utils.SecsToHHMMSS(week.FocusSeconds), fmt.Sprint(week.Sessions), formatSignedHHMMSS(week.DeltaSeconds)})
+		}
+		table.Render()
+	}
+
+	if len(r.Buckets) > 0 {
+		fmt.Fprintln(w)
+		table := newTable(w, "Bucket", "Focus", "Sessions", "Share")
+		for _, b := range r.Buckets {
+			table.Append([]string{b.BucketName, utils.SecsToHHMMSS(b.FocusSeconds), fmt.Sprint(b.Sessions), fmt.Sprintf("%.0f%%", b.Percent)})
+		}
+		table.Render()
+	}
+}
+
+// formatDelta renders a change like "+01:30:00, +50%", omitting the percentage when there is nothing to compare to.
+func formatDelta(deltaSeconds int64, deltaPercent float64, previousSeconds int64) string {
+	if previousSeconds == 0 {
+		return formatSignedHHMMSS(deltaSeconds)
+	}
+	return fmt.Sprintf("%s, %+.0f%%", formatSignedHHMMSS(deltaSeconds), deltaPercent)
+}
+
+func formatSignedHHMMSS(seconds int64) string {
+	if seconds < 0 {
+		return "-" + utils.SecsToHHMMSS(-seconds)
+	}
+	return "+" + utils.SecsToHHMMSS(seconds)
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/app"
+	"github.com/connorkuljis/block-cli/internal/config"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/connorkuljis/block-cli/internal/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/urfave/cli/v2"
+)
+
+var ResumeCmd = &cli.Command{
+	Name:      "resume",
+	Usage:     "continue an interrupted or cancelled task with its remaining planned time.",
+	ArgsUsage: "[taskId]",
+	Flags:     noteFlags,
+	Action: func(ctx *cli.Context) error {
+		db := ctx.Context.Value("db").(*sqlx.DB)
+
+		if err := validateNoteFlags(ctx); err != nil {
+			return err
+		}
+
+		var task tasks.Task
+		var err error
+		if ctx.NArg() > 0 {
+			id, err := strconv.ParseInt(ctx.Args().First(), 10, 64)
+			if err != nil {
+				return fmt.Errorf("Error parsing task id: %w", err)
+			}
+			task, err = tasks.NewStore(db).Get(ctx.Context, id)
+			if err != nil {
+				return err
+			}
+			if task.DeletedAt.Valid {
+				return fmt.Errorf("Error, task %d is deleted, run `block restore %d` first", id, id)
+			}
+		} else {
+			task, err = tasks.NewStore(db).First(ctx.Context, tasks.NewQuery().Incomplete())
+			if errors.Is(err, tasks.ErrNotFound) {
+				return errors.New("Error, no incomplete task to resume")
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		fmt.Printf("Resuming task %d %q, %s remaining.\n", task.TaskId, task.TaskName, utils.SecsToHHMMSS(task.RemainingSeconds()))
+
+		err = app.Resume(ctx.Context, os.Stdout, db, ctx.Context.Value("config").(*config.AppConfig).Config, pluginHost(ctx), &task)
+		if err != nil {
+			return err
+		}
+
+		err = recordNote(ctx, db, task.TaskId)
+		if err != nil {
+			return err
+		}
+
+		return printDailySummary(ctx, db, time.Now())
+	},
+}
@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/connorkuljis/block-cli/internal/utils"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/urfave/cli/v2"
+)
+
+var SearchCmd = &cli.Command{
+	Name:      "search",
+	Usage:     "search task names and notes, best matches first.",
+	ArgsUsage: "<words...>",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:    "limit",
+			Aliases: []string{"n"},
+			Value:   20,
+			Usage:   "Show at most `n` matches, 0 for all.",
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "Only search tasks created on or after `yyyy-mm-dd`.",
+		},
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "Only search tasks created on or before `yyyy-mm-dd`.",
+		},
+		&cli.StringFlag{
+			Name:    "bucket",
+			Aliases: []string{"b"},
+			Usage:   "Only search tasks in a bucket, by name or id.",
+		},
+		&cli.StringSliceFlag{
+			Name:    "tag",
+			Aliases: []string{"t"},
+			Usage:   "Only search tasks with every given tag.",
+		},
+		&cli.BoolFlag{
+			Name:  "deleted",
+			Usage: "Search deleted tasks instead.",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		db := ctx.Context.Value("db").(*sqlx.DB)
+
+		if ctx.NArg() < 1 {
+			return errors.New("Error, expected words to search for")
+		}
+		if ctx.Int("limit") < 0 {
+			return errors.New("Error, --limit must not be negative")
+		}
+
+		query := tasks.NewQuery().Limit(ctx.Int("limit"))
+		if ctx.Bool("deleted") {
+			query.Deleted()
+		}
+
+		query, err := filterQuery(ctx, db, time.Now(), query)
+		if err != nil {
+			return err
+		}
+
+		matches, err := tasks.NewStore(db).Search(ctx.Context, strings.Join(ctx.Args().Slice(), " "), query)
+		if err != nil {
+			return err
+		}
+
+		renderMatches(os.Stdout, matches)
+
+		return nil
+	},
+}
+
+// renderMatches prints each match with its matched words highlighted, followed by the matching notes.
+func renderMatches(w io.Writer, matches []tasks.Match) {
+	if len(matches) == 0 {
+		fmt.Fprintln(w, "No matching tasks.")
+		return
+	}
+
+	bold := color.New(color.Bold, color.FgYellow)
+	mark := func(s string) string { return bold.Sprint(s) }
+	plain := func(s string) string { return s }
+
+	for _, m := range matches {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n",
+			m.TaskId,
+			calendar.Default().In(m.CreatedAt).Format("Mon Jan 02 2006 15:04"),
+			utils.SecsToHHMMSS(m.ActualDurationSeconds.Int64),
+			tasks.Highlight(m.Name, plain, mark),
+		)
+		if m.Notes != "" {
+			fmt.Fprintf(w, "\t%s\n", tasks.Highlight(m.Notes, plain, mark))
+		}
+	}
+
+	fmt.Fprintln(w)
+	color.New(color.FgCyan).Fprintf(w, "%d matches\n", len(matches))
+}
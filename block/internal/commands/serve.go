@@ -3,6 +3,7 @@ package commands
 import (
 	"embed"
 
+	"github.com/connorkuljis/block-cli/internal/config"
 	"github.com/connorkuljis/block-cli/internal/server"
 	"github.com/jmoiron/sqlx"
 	"github.com/urfave/cli/v2"
@@ -14,10 +15,11 @@ var ServeCmd = &cli.Command{
 	Action: func(ctx *cli.Context) error {
 		db := ctx.Context.Value("db").(*sqlx.DB)
 		www := ctx.Context.Value("www").(embed.FS)
+		cfg := ctx.Context.Value("config").(*config.AppConfig)
 
 		templatesPath := "www/templates"
 		staticPath := "www/static"
-		s, err := server.NewServer(www, db, "8080", templatesPath, staticPath)
+		s, err := server.NewServer(www, db, cfg.Config, "8080", templatesPath, staticPath)
 
 		s.Routes()
 
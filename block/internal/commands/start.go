@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"log"
@@ -9,7 +10,11 @@ import (
 	"time"
 
 	"github.com/connorkuljis/block-cli/internal/app"
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/config"
+	"github.com/connorkuljis/block-cli/internal/tags"
 	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/connorkuljis/block-cli/internal/templates"
 	"github.com/connorkuljis/block-cli/internal/utils"
 	"github.com/jmoiron/sqlx"
 	"github.com/urfave/cli/v2"
@@ -19,8 +24,8 @@ var StartCmd = &cli.Command{
 	Name:      "start",
 	Usage:     "start the blocker.",
 	Args:      true,
-	ArgsUsage: "[duration] [taskname]",
-	Flags: []cli.Flag{
+	ArgsUsage: "[duration] [taskname] | @template",
+	Flags: append([]cli.Flag{
 		&cli.BoolFlag{
 			Name:  "no-blocker",
 			Usage: "Disables the blocker.",
@@ -30,64 +35,125 @@ var StartCmd = &cli.Command{
 			Aliases: []string{"c"},
 			Usage:   "Enables screen capture.",
 		},
-		&cli.Int64Flag{
+		&cli.StringFlag{
 			Name:    "bucket",
 			Aliases: []string{"b"},
-			Usage:   "Tag a task with bucket id",
+			Usage:   "Tag a task with a bucket name or id",
 		},
-	},
+		&cli.StringSliceFlag{
+			Name:    "tag",
+			Aliases: []string{"t"},
+			Usage:   "Label a task, can be repeated: -t client-x -t bug",
+		},
+	}, noteFlags...),
 	Action: func(ctx *cli.Context) error {
 		db := ctx.Context.Value("db").(*sqlx.DB)
-		// sqlx.DB
 
 		if ctx.NArg() < 1 {
 			return errors.New("Error, no arguments provided")
 		}
 
-		argDurationMinutes := ctx.Args().Get(0)
-		argTaskName := ctx.Args().Get(1) // empty string is ok.
+		if err := validateNoteFlags(ctx); err != nil {
+			return err
+		}
 
-		var floatDurationMinutes float64
-		floatDurationMinutes, err := strconv.ParseFloat(argDurationMinutes, 64)
-		if err != nil {
+		if err := tags.Validate(ctx.StringSlice("tag")); err != nil {
 			return err
 		}
 
-		durationSeconds := int64(floatDurationMinutes * 60)
+		var currentTask *tasks.Task
+		if arg := ctx.Args().Get(0); templates.IsReference(arg) {
+			t, err := templates.GetTemplateByName(db, arg)
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("Error, no template named '%s'", arg)
+			}
+			if err != nil {
+				return err
+			}
 
-		capture := ctx.Bool("capture")
-		blocker := !ctx.Bool("no-blocker")
-		bucketId := ctx.Int64("bucket")
+			currentTask = t.NewTask(time.Now())
+		} else {
+			durationSeconds, err := parseDurationMinutes(arg)
+			if err != nil {
+				return err
+			}
 
-		currentTask := tasks.NewTask(argTaskName, durationSeconds, blocker, capture, time.Now())
+			argTaskName := ctx.Args().Get(1) // empty string is ok.
+			currentTask = tasks.NewTask(argTaskName, durationSeconds, true, false, time.Now())
+		}
 
-		if bucketId != 0 {
-			currentTask.AddBucketTag(bucketId)
+		// flags override template settings.
+		if ctx.IsSet("capture") {
+			currentTask.ScreenEnabled = utils.BoolToInt(ctx.Bool("capture"))
+		}
+		if ctx.IsSet("no-blocker") {
+			currentTask.BlockerEnabled = utils.BoolToInt(!ctx.Bool("no-blocker"))
+		}
+		if ctx.String("bucket") != "" {
+			bucket, err := buckets.ResolveActive(db, ctx.String("bucket"))
+			if err != nil {
+				return err
+			}
+			currentTask.AddBucketTag(bucket.BucketId)
 		}
 
-		err = app.Start(os.Stdout, db, *currentTask)
+		err := suggestDuration(ctx, db, os.Stdout, currentTask.TaskName, currentTask.EstimatedDurationSeconds)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 
-		var totalSecondsToday int64
-		today := currentTask.CreatedAt.Truncate(24 * time.Hour)
-		tasks, _ := tasks.GetRecentTasks(db, today, 0)
-		for _, task := range tasks {
-			totalSecondsToday += task.ActualDurationSeconds.Int64
+		progress, err := dailyGoalProgress(ctx, db, time.Now(), false)
+		if err != nil {
+			return err
 		}
+		renderDailyGoal(os.Stdout, progress)
 
-		// take a break for 1/3 of time worked.
-		var breakRatio float64
-		var totalBreakSecondsToday int64
-		breakRatio = 1.0 / 3
-		totalBreakSecondsToday = int64(float64(totalSecondsToday) * breakRatio)
+		err = app.Start(ctx.Context, os.Stdout, db, ctx.Context.Value("config").(*config.AppConfig).Config, pluginHost(ctx), currentTask, func(tx *sqlx.Tx) error {
+			return tags.AddTags(tx, currentTask.TaskId, ctx.StringSlice("tag"))
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
 
-		fmt.Println("---")
-		fmt.Println("Total focus time today ==>", utils.SecsToHHMMSS(totalSecondsToday))
-		fmt.Println("Cumulative break time today ==>", utils.SecsToHHMMSS(totalBreakSecondsToday))
-		fmt.Println("Goodbye.")
+		err = recordNote(ctx, db, currentTask.TaskId)
+		if err != nil {
+			return err
+		}
 
-		return nil
+		return printDailySummary(ctx, db, currentTask.CreatedAt)
 	},
 }
+
+// printDailySummary prints the total focus time, break allowance and goal progress for the day of t,
+// and records the day's goal attainment.
+func printDailySummary(ctx *cli.Context, db *sqlx.DB, t time.Time) error {
+	progress, err := dailyGoalProgress(ctx, db, t, true)
+	if err != nil {
+		return err
+	}
+	totalSecondsToday := progress.FocusSeconds
+
+	// take a break for 1/3 of time worked.
+	var breakRatio float64
+	var totalBreakSecondsToday int64
+	breakRatio = 1.0 / 3
+	totalBreakSecondsToday = int64(float64(totalSecondsToday) * breakRatio)
+
+	fmt.Println("---")
+	fmt.Println("Total focus time today ==>", utils.SecsToHHMMSS(totalSecondsToday))
+	fmt.Println("Cumulative break time today ==>", utils.SecsToHHMMSS(totalBreakSecondsToday))
+	renderDailyGoal(os.Stdout, progress)
+	fmt.Println("Goodbye.")
+
+	return nil
+}
+
+// parseDurationMinutes parses a (possibly fractional) number of minutes into seconds.
+func parseDurationMinutes(arg string) (int64, error) {
+	minutes, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Error parsing duration '%s', expected minutes: %w", arg, err)
+	}
+
+	return int64(minutes * 60), nil
+}
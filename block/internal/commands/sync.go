@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+
+	"github.com/connorkuljis/block-cli/internal/config"
+	"github.com/connorkuljis/block-cli/internal/syncer"
+	"github.com/jmoiron/sqlx"
+	"github.com/urfave/cli/v2"
+)
+
+var SyncCmd = &cli.Command{
+	Name:  "sync",
+	Usage: "sync tasks and buckets with a sync server.",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "server",
+			Usage: "Sync server `url`, defaults to sync.server in the config.",
+		},
+		&cli.StringFlag{
+			Name:  "token",
+			Usage: "Token the sync server expects, defaults to sync.token in the config.",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		db := ctx.Context.Value("db").(*sqlx.DB)
+
+		cfg := ctx.Context.Value("config").(*config.AppConfig).Config.Sync
+		server, token := cfg.Server, cfg.Token
+		if ctx.IsSet("server") {
+			server = ctx.String("server")
+		}
+		if ctx.IsSet("token") {
+			token = ctx.String("token")
+		}
+		if server == "" {
+			return errors.New("Error, no sync server, pass --server or set sync.server in the config")
+		}
+
+		client, err := syncer.NewClient(server, token)
+		if err != nil {
+			return err
+		}
+
+		result, err := syncer.Sync(ctx.Context, db, client, client.URL)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Sent %d changes (%d accepted), received %d (%d applied).\n", result.Sent, result.Accepted, result.Received, result.Applied)
+
+		return nil
+	},
+	Subcommands: []*cli.Command{
+		{
+			Name:  "serve",
+			Usage: "run a sync server for your devices.",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "addr",
+					Value: "localhost:8090",
+					Usage: "Address to listen on.",
+				},
+				&cli.StringFlag{
+					Name:  "db",
+					Usage: "Server database `file`, defaults to sync_server.db next to the task database.",
+				},
+				&cli.StringFlag{
+					Name:  "token",
+					Usage: "Token clients must send, defaults to sync.token in the config. Leave empty only on a trusted network.",
+				},
+			},
+			Action: func(ctx *cli.Context) error {
+				cfg := ctx.Context.Value("config").(*config.AppConfig)
+
+				dsn := filepath.Join(cfg.Paths.DataDir, "sync_server.db") + config.DbOptions
+				if path := ctx.String("db"); path != "" {
+					dsn = path + config.DbOptions
+				}
+
+				token := cfg.Config.Sync.Token
+				if ctx.IsSet("token") {
+					token = ctx.String("token")
+				}
+
+				server, err := syncer.OpenServer(dsn, token)
+				if err != nil {
+					return err
+				}
+				defer server.Close()
+
+				if token == "" {
+					log.Println("Warning, sync server running without a token.")
+				}
+				log.Printf("Sync server listening on http://%s\n", ctx.String("addr"))
+
+				return http.ListenAndServe(ctx.String("addr"), server.Handler())
+			},
+		},
+	},
+}
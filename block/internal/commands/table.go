@@ -0,0 +1,22 @@
+package commands
+
+import (
+	"io"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// newTable returns a borderless, left aligned table matching the style of `block history`.
+func newTable(w io.Writer, header ...string) *tablewriter.Table {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(header)
+	table.SetAutoWrapText(false)
+	table.SetBorder(false)
+	table.SetHeaderLine(false)
+	table.SetColumnSeparator("")
+	table.SetCenterSeparator("")
+	table.SetRowSeparator("")
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	return table
+}
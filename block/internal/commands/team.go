@@ -0,0 +1,197 @@
+package commands
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/config"
+	"github.com/connorkuljis/block-cli/internal/server"
+	"github.com/connorkuljis/block-cli/internal/team"
+	"github.com/jmoiron/sqlx"
+	"github.com/urfave/cli/v2"
+)
+
+// teamDBFlag selects the team server's database, for the subcommands run on the server.
+var teamDBFlag = &cli.StringFlag{
+	Name:  "db",
+	Usage: "Team server database `file`, defaults to team_server.db next to the task database.",
+}
+
+var TeamCmd = &cli.Command{
+	Name:  "team",
+	Usage: "share focus time with a team server.",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "push",
+			Usage: "send finished sessions to the team server.",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "server",
+					Usage: "Team server `url`, defaults to team.server in the config.",
+				},
+				&cli.StringFlag{
+					Name:  "token",
+					Usage: "Your member token, defaults to team.token in the config.",
+				},
+				&cli.BoolFlag{
+					Name:  "all",
+					Usage: "Send every session again, such as after changing what is shared.",
+				},
+			},
+			Action: func(ctx *cli.Context) error {
+				db := ctx.Context.Value("db").(*sqlx.DB)
+
+				cfg := ctx.Context.Value("config").(*config.AppConfig).Config.Team
+				if ctx.IsSet("server") {
+					cfg.Server = ctx.String("server")
+				}
+				if ctx.IsSet("token") {
+					cfg.Token = ctx.String("token")
+				}
+				if cfg.Server == "" {
+					return errors.New("Error, no team server, pass --server or set team.server in the config")
+				}
+
+				client, err := team.NewClient(cfg.Server, cfg.Token)
+				if err != nil {
+					return err
+				}
+
+				result, err := team.Push(ctx.Context, db, client, team.NewSharing(cfg), ctx.Bool("all"))
+				if err != nil {
+					return err
+				}
+
+				fmt.Printf("Shared %d sessions, removed %d.\n", result.Stored, result.Removed)
+				if !cfg.ShareTaskNames {
+					fmt.Println("Task names were not shared, set team.shareTaskNames to share them.")
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:  "serve",
+			Usage: "run a team server.",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "addr",
+					Value: "localhost:8095",
+					Usage: "Address to listen on.",
+				},
+				teamDBFlag,
+			},
+			Action: func(ctx *cli.Context) error {
+				www := ctx.Context.Value("www").(embed.FS)
+
+				store, err := openTeamStore(ctx)
+				if err != nil {
+					return err
+				}
+				defer store.Close()
+
+				s, err := server.NewTeamServer(www, store, ctx.String("addr"), "www/templates", "www/static")
+				if err != nil {
+					return err
+				}
+
+				s.Routes()
+
+				return s.ListenAndServe()
+			},
+		},
+		{
+			Name:  "member",
+			Usage: "manage the team server's members.",
+			Subcommands: []*cli.Command{
+				{
+					Name:      "add",
+					Usage:     "add a member and print their token.",
+					ArgsUsage: "[name]",
+					Flags:     []cli.Flag{teamDBFlag},
+					Action: func(ctx *cli.Context) error {
+						if ctx.NArg() != 1 {
+							return errors.New("Error, expected a member name")
+						}
+
+						store, err := openTeamStore(ctx)
+						if err != nil {
+							return err
+						}
+						defer store.Close()
+
+						token, err := store.AddMember(ctx.Context, ctx.Args().First(), time.Now())
+						if err != nil {
+							return err
+						}
+
+						fmt.Printf("Added '%s'. Their token, shown only once:\n%s\n", ctx.Args().First(), token)
+						return nil
+					},
+				},
+				{
+					Name:  "list",
+					Usage: "list members.",
+					Flags: []cli.Flag{teamDBFlag},
+					Action: func(ctx *cli.Context) error {
+						store, err := openTeamStore(ctx)
+						if err != nil {
+							return err
+						}
+						defer store.Close()
+
+						members, err := store.Members(ctx.Context)
+						if err != nil {
+							return err
+						}
+
+						table := newTable(os.Stdout, "Name", "Added")
+						for _, m := range members {
+							table.Append([]string{m.Name, m.CreatedAt.Local().Format("2006-01-02")})
+						}
+						table.Render()
+
+						return nil
+					},
+				},
+				{
+					Name:      "remove",
+					Usage:     "remove a member and every session they shared.",
+					ArgsUsage: "[name]",
+					Flags:     []cli.Flag{teamDBFlag},
+					Action: func(ctx *cli.Context) error {
+						if ctx.NArg() != 1 {
+							return errors.New("Error, expected a member name")
+						}
+
+						store, err := openTeamStore(ctx)
+						if err != nil {
+							return err
+						}
+						defer store.Close()
+
+						if err := store.RemoveMember(ctx.Context, ctx.Args().First()); err != nil {
+							return err
+						}
+
+						fmt.Printf("Removed '%s'.\n", ctx.Args().First())
+						return nil
+					},
+				},
+			},
+		},
+	},
+}
+
+func openTeamStore(ctx *cli.Context) (*team.Store, error) {
+	path := filepath.Join(ctx.Context.Value("config").(*config.AppConfig).Paths.DataDir, "team_server.db")
+	if ctx.String("db") != "" {
+		path = ctx.String("db")
+	}
+
+	return team.OpenStore(path + config.DbOptions)
+}
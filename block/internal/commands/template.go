@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/templates"
+	"github.com/connorkuljis/block-cli/internal/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/urfave/cli/v2"
+)
+
+var TemplateCmd = &cli.Command{
+	Name:  "template",
+	Usage: "manage task templates for recurring work, started with `block start @name`.",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "add",
+			Usage:     "save a task template.",
+			ArgsUsage: "[name] [duration] [taskname]",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "no-blocker",
+					Usage: "Disables the blocker.",
+				},
+				&cli.BoolFlag{
+					Name:    "capture",
+					Aliases: []string{"c"},
+					Usage:   "Enables screen capture.",
+				},
+				&cli.StringFlag{
+					Name:    "bucket",
+					Aliases: []string{"b"},
+					Usage:   "Tag a task with a bucket name or id",
+				},
+			},
+			Action: func(ctx *cli.Context) error {
+				db := ctx.Context.Value("db").(*sqlx.DB)
+
+				if ctx.NArg() < 2 {
+					return errors.New("Error, expected a template name and duration")
+				}
+
+				name := ctx.Args().Get(0)
+				durationSeconds, err := parseDurationMinutes(ctx.Args().Get(1))
+				if err != nil {
+					return err
+				}
+
+				t := templates.NewTemplate(name, ctx.Args().Get(2), durationSeconds, !ctx.Bool("no-blocker"), ctx.Bool("capture"), time.Now())
+				if ctx.String("bucket") != "" {
+					bucket, err := buckets.ResolveActive(db, ctx.String("bucket"))
+					if err != nil {
+						return err
+					}
+					t.AddBucketTag(bucket.BucketId)
+				}
+
+				err = templates.InsertTemplate(db, t)
+				if err != nil {
+					return fmt.Errorf("Error saving template '%s': %w", t.TemplateName, err)
+				}
+
+				fmt.Printf("Saved template, start it with `block start %s%s`.\n", templates.Prefix, t.TemplateName)
+				return nil
+			},
+		},
+		{
+			Name:  "list",
+			Usage: "list task templates.",
+			Action: func(ctx *cli.Context) error {
+				db := ctx.Context.Value("db").(*sqlx.DB)
+
+				all, err := templates.GetAllTemplates(db)
+				if err != nil {
+					return err
+				}
+
+				table := newTable(os.Stdout, "Name", "Task", "Duration", "Blocker", "Capture", "Bucket")
+
+				for _, t := range all {
+					bucket := ""
+					if t.BucketId.Valid {
+						bucket = fmt.Sprint(t.BucketId.Int64)
+					}
+					table.Append([]string{
+						templates.Prefix + t.TemplateName,
+						t.TaskName,
+						utils.SecsToHHMMSS(t.DurationSeconds),
+						fmt.Sprint(t.BlockerEnabled == 1),
+						fmt.Sprint(t.ScreenEnabled == 1),
+						bucket,
+					})
+				}
+				table.Render()
+
+				return nil
+			},
+		},
+		{
+			Name:      "rm",
+			Usage:     "remove a task template.",
+			ArgsUsage: "[name]",
+			Action: func(ctx *cli.Context) error {
+				db := ctx.Context.Value("db").(*sqlx.DB)
+
+				if ctx.NArg() < 1 {
+					return errors.New("Error, expected a template name")
+				}
+
+				name := ctx.Args().First()
+				rowsAffected, err := templates.DeleteTemplateByName(db, name)
+				if err != nil {
+					return err
+				}
+
+				if rowsAffected == 0 {
+					return fmt.Errorf("Error, no template named '%s'", name)
+				}
+
+				fmt.Printf("Removed template '%s'.\n", name)
+				return nil
+			},
+		},
+	},
+}
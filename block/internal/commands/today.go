@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/config"
+	"github.com/connorkuljis/block-cli/internal/goals"
+	"github.com/connorkuljis/block-cli/internal/plan"
+	"github.com/connorkuljis/block-cli/internal/report"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/connorkuljis/block-cli/internal/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/urfave/cli/v2"
+)
+
+var TodayCmd = &cli.Command{
+	Name:  "today",
+	Usage: "summarise today's focus time against the daily goal.",
+	Action: func(ctx *cli.Context) error {
+		db := ctx.Context.Value("db").(*sqlx.DB)
+
+		cal := calendar.Default()
+		now := time.Now()
+
+		progress, err := dailyGoalProgress(ctx, db, now, true)
+		if err != nil {
+			return err
+		}
+
+		all, err := tasks.NewStore(db).Select(ctx.Context, tasks.NewQuery().OnDay(cal, now).OrderBy("date", false))
+		if err != nil {
+			return err
+		}
+
+		r, err := report.Generate(ctx.Context, db, report.Options{
+			Period:    report.PeriodDay,
+			Now:       now,
+			Calendar:  cal,
+			DailyGoal: time.Duration(progress.GoalSeconds) * time.Second,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s\n\n", progress.Date.Format("Monday 02 January"))
+		if len(all) > 0 {
+			tasks.RenderTable(os.Stdout, all)
+			fmt.Println()
+		}
+
+		fmt.Printf("Sessions:   %d\n", progress.Sessions)
+		fmt.Printf("Focus time: %s\n", utils.SecsToHHMMSS(progress.FocusSeconds))
+		renderDailyGoal(os.Stdout, progress)
+		if r.CurrentStreak.Days > 0 {
+			fmt.Printf("Streak:     %d days\n", r.CurrentStreak.Days)
+		}
+
+		review, err := plan.ReviewDay(ctx.Context, db, cal, now)
+		if err != nil {
+			return err
+		}
+		if len(review.Items) > 0 {
+			fmt.Println()
+			renderPlanReview(os.Stdout, review)
+		}
+
+		return nil
+	},
+}
+
+// dailyGoalProgress measures the day of t against the configured goals. With record set the
+// result is stored for streak reporting.
+func dailyGoalProgress(ctx *cli.Context, db *sqlx.DB, t time.Time, record bool) (goals.Progress, error) {
+	g, err := goals.NewFromConfig(ctx.Context.Value("config").(*config.AppConfig).Config.Goals)
+	if err != nil {
+		return goals.Progress{}, err
+	}
+
+	if record {
+		return goals.Update(ctx.Context, db, calendar.Default(), g, t, time.Now())
+	}
+	return goals.ForDay(ctx.Context, db, calendar.Default(), g, t)
+}
+
+// updateDailyGoals re-records the daily goal for the days holding changed tasks.
+func updateDailyGoals(ctx *cli.Context, db *sqlx.DB, changed []tasks.Task) error {
+	g, err := goals.NewFromConfig(ctx.Context.Value("config").(*config.AppConfig).Config.Goals)
+	if err != nil {
+		return err
+	}
+
+	return goals.UpdateDays(ctx.Context, db, calendar.Default(), g, changed, time.Now())
+}
+
+// renderDailyGoal prints progress towards the daily goal and any bucket goals. Nothing is printed without a goal.
+func renderDailyGoal(w io.Writer, p goals.Progress) {
+	if p.GoalSeconds <= 0 {
+		return
+	}
+
+	if p.Met() {
+		fmt.Fprintf(w, "Daily goal: met, %s of %s\n", utils.SecsToHHMMSS(p.FocusSeconds), utils.SecsToHHMMSS(p.GoalSeconds))
+	} else {
+		fmt.Fprintf(w, "Daily goal: %s of %s (%.0f%%), %s to go\n", utils.SecsToHHMMSS(p.FocusSeconds), utils.SecsToHHMMSS(p.GoalSeconds), p.Percent(), utils.SecsToHHMMSS(p.RemainingSeconds()))
+	}
+
+	for _, b := range p.Buckets {
+		status := fmt.Sprintf("%s to go", utils.SecsToHHMMSS(b.RemainingSeconds()))
+		if b.Met() {
+			status = "met"
+		}
+		fmt.Fprintf(w, "  %-10s %s of %s (%.0f%%), %s\n", b.BucketName, utils.SecsToHHMMSS(b.FocusSeconds), utils.SecsToHHMMSS(b.GoalSeconds), b.Percent(), status)
+	}
+}
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/jmoiron/sqlx"
+	"github.com/urfave/cli/v2"
+)
+
+var UndoCmd = &cli.Command{
+	Name:  "undo",
+	Usage: "revert the last delete, restore or edit, run again to step further back.",
+	Action: func(ctx *cli.Context) error {
+		db := ctx.Context.Value("db").(*sqlx.DB)
+
+		store := tasks.NewStore(db)
+
+		entries, err := store.Undo(ctx.Context, tasks.SourceCLI, time.Now())
+		if err != nil {
+			return err
+		}
+
+		var ids []string
+		var changed []tasks.Task
+		for _, entry := range entries {
+			ids = append(ids, strconv.FormatInt(entry.TaskId, 10))
+
+			task, err := store.Get(ctx.Context, entry.TaskId)
+			if err != nil {
+				return err
+			}
+			changed = append(changed, task)
+		}
+
+		if err := updateDailyGoals(ctx, db, changed); err != nil {
+			return err
+		}
+
+		fmt.Printf("Undid %s of %d tasks: %s.\n", entries[0].Action, len(entries), strings.Join(ids, ", "))
+
+		return nil
+	},
+}
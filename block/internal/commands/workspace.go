@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/connorkuljis/block-cli/internal/config"
+	"github.com/urfave/cli/v2"
+)
+
+var WorkspaceCmd = &cli.Command{
+	Name:  "workspace",
+	Usage: "show workspaces, each with their own database and config. Pick one with block --workspace name.",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "list",
+			Usage: "list workspaces, marking the one in use.",
+			Action: func(ctx *cli.Context) error {
+				names, err := config.Workspaces()
+				if err != nil {
+					return err
+				}
+
+				table := newTable(os.Stdout, "", "Workspace")
+				for _, name := range append([]string{""}, names...) {
+					current := ""
+					if name == ctx.Context.Value("paths").(config.Paths).Workspace {
+						current = "*"
+					}
+					if name == "" {
+						name = "(default)"
+					}
+					table.Append([]string{current, name})
+				}
+				table.Render()
+
+				return nil
+			},
+		},
+		{
+			Name:  "show",
+			Usage: "show where the workspace in use keeps its database and config.",
+			Action: func(ctx *cli.Context) error {
+				paths := ctx.Context.Value("paths").(config.Paths)
+
+				name := paths.Workspace
+				if name == "" {
+					name = "(default)"
+				}
+
+				fmt.Println("Workspace:", name)
+				fmt.Println("Database: ", paths.DbFile())
+				fmt.Println("Config:   ", paths.ConfigFile())
+
+				return nil
+			},
+		},
+	},
+}
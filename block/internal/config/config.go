@@ -1,115 +1,126 @@
+// Package config loads a workspace's settings in layers, each overriding the last: the
+// defaults, the config file, BLOCK_* environment variables and --set flags.
 package config
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
+// AppConfig is a workspace's settings with where its files are kept.
 type AppConfig struct {
-	HiddenConfig *HiddenConfig
-	RootConfig   *RootConfig
+	Paths  Paths
+	Config Config
 }
 
-var Cfg AppConfig
-
-func InitConfig() error {
-	homeDir, err := os.UserHomeDir()
+// Load reads the workspace's settings and validates them. overrides are key=value pairs, as
+// given to --set.
+func Load(paths Paths, overrides []string) (*AppConfig, error) {
+	cfg, err := Read(paths, overrides)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	Cfg = AppConfig{
-		HiddenConfig: NewHiddenConfig(homeDir),
-		RootConfig:   NewRootConfig(homeDir),
+	if err := cfg.Config.Validate(); err != nil {
+		return nil, fmt.Errorf("%w\nFix %s, or run block config validate", err, paths.ConfigFile())
 	}
 
-	if err := makeDirIfNotExists(Cfg.HiddenConfig.Path); err != nil {
-		return err
-	}
+	return cfg, nil
+}
 
-	if err := makeDirIfNotExists(Cfg.RootConfig.Path); err != nil {
-		return err
+// Read layers the workspace's settings without validating them. A missing config file is
+// created with the defaults.
+func Read(paths Paths, overrides []string) (*AppConfig, error) {
+	cfg, err := ReadSaved(paths)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := loadOrMakeConfigFileIfNotExists(Cfg.HiddenConfig); err != nil {
-		return err
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
 	}
 
-	return nil
-}
-
-func makeDirIfNotExists(path string) error {
-	_, err := os.Stat(path)
-	if os.IsNotExist(err) {
-		if err := os.MkdirAll(path, os.ModePerm); err != nil {
-			return err
+	for _, override := range overrides {
+		key, value, ok := strings.Cut(override, "=")
+		if !ok {
+			return nil, fmt.Errorf("Error, --set '%s' must be key=value", override)
+		}
+		if err := cfg.Set(key, value); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
+	return &AppConfig{Paths: paths, Config: cfg}, nil
 }
 
-func loadOrMakeConfigFileIfNotExists(h *HiddenConfig) error {
-	_, err := os.Stat(filepath.Join(h.Path, h.ConfigFilename))
-	if os.IsNotExist(err) {
-		if err = makeDefaultConfigFile(h); err != nil {
-			return err
-		}
-		return nil
-	} else {
-		err := loadConfig(h)
-		if err != nil {
-			return err
-		}
+// ReadSaved returns the defaults with the config file applied, leaving out the environment
+// and --set. A missing config file is created with the defaults.
+func ReadSaved(paths Paths) (Config, error) {
+	cfg := Default()
+	if err := paths.MakeDirs(); err != nil {
+		return cfg, err
+	}
 
-		err = sanitiseConfigValues(h)
-		if err != nil {
-			return err
-		}
+	_, err := os.Stat(paths.ConfigFile())
+	if os.IsNotExist(err) {
+		err = Save(paths, cfg)
+	} else if err == nil {
+		err = ReadFile(paths.ConfigFile(), &cfg)
 	}
 
-	return nil
+	return cfg, err
 }
 
-// create config file and write default values
-func makeDefaultConfigFile(h *HiddenConfig) error {
-	configFile, err := os.Create(filepath.Join(h.Path, h.ConfigFilename))
+// ReadFile applies the settings in a config file onto cfg. Unknown keys are an error, so a
+// misspelt setting is not silently ignored.
+func ReadFile(path string, cfg *Config) error {
+	contents, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
-	defer configFile.Close()
 
-	data, err := yaml.Marshal(&h.Config)
-	if err != nil {
-		return err
+	decoder := yaml.NewDecoder(bytes.NewReader(contents))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("Error reading %s: %w", path, err)
 	}
 
-	_, err = configFile.Write(data)
+	return nil
+}
+
+// Save writes cfg to the workspace's config file, replacing it whole.
+func Save(paths Paths, cfg Config) error {
+	data, err := yaml.Marshal(&cfg)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return WriteFile(paths, data)
 }
 
-func loadConfig(h *HiddenConfig) error {
-	contents, err := os.ReadFile(filepath.Join(h.Path, h.ConfigFilename))
+// WriteFile replaces the workspace's config file with data. The file is written beside it
+// first, so a failed write leaves the old file in place.
+func WriteFile(paths Paths, data []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(paths.ConfigFile()), ConfigFileName+".*")
 	if err != nil {
 		return err
 	}
+	defer os.Remove(temp.Name())
 
-	if err = yaml.Unmarshal(contents, &h.Config); err != nil {
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
 		return err
 	}
-
-	return nil
-}
-
-func sanitiseConfigValues(h *HiddenConfig) error {
-	if _, err := os.Stat(h.Config.FfmpegRecordingsPath); err != nil {
+	if err := temp.Close(); err != nil {
 		return err
 	}
-	return nil
+
+	return os.Rename(temp.Name(), paths.ConfigFile())
 }
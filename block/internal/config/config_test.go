@@ -0,0 +1,141 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testPaths(t *testing.T) Paths {
+	dir := t.TempDir()
+	return Paths{ConfigDir: filepath.Join(dir, "config"), DataDir: filepath.Join(dir, "data")}
+}
+
+func TestReadLayers(t *testing.T) {
+	paths := testPaths(t)
+	if err := os.MkdirAll(paths.ConfigDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	file := "goals:\n  dailyMinutes: 90\ndays:\n  startHour: 4\nidle:\n  thresholdSeconds: 60\n"
+	if err := os.WriteFile(paths.ConfigFile(), []byte(file), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("BLOCK_DAYS_START_HOUR", "5")
+	t.Setenv("BLOCK_IDLE_THRESHOLD_SECONDS", "120")
+
+	cfg, err := Read(paths, []string{"idle.thresholdSeconds=300", "team.excludeBuckets=personal, health"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := cfg.Config
+	if c.Goals.DailyMinutes != 90 {
+		t.Errorf("Expected the file to set dailyMinutes to 90, got: %d", c.Goals.DailyMinutes)
+	}
+	if c.Days.StartHour != 5 {
+		t.Errorf("Expected the environment to set startHour to 5, got: %d", c.Days.StartHour)
+	}
+	if c.Idle.ThresholdSeconds != 300 {
+		t.Errorf("Expected --set to set thresholdSeconds to 300, got: %d", c.Idle.ThresholdSeconds)
+	}
+	if strings.Join(c.Team.ExcludeBuckets, ",") != "personal,health" {
+		t.Errorf("Expected two excluded buckets, got: %v", c.Team.ExcludeBuckets)
+	}
+	if c.AvfoundationDevice != DefaultAvfoundationDevice {
+		t.Errorf("Expected the default device, got: %s", c.AvfoundationDevice)
+	}
+}
+
+func TestReadCreatesDefaults(t *testing.T) {
+	paths := testPaths(t)
+
+	if _, err := Load(paths, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var c Config
+	if err := ReadFile(paths.ConfigFile(), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Goals.DailyMinutes != DefaultDailyGoalMinutes {
+		t.Errorf("Expected the defaults to be saved, got: %+v", c)
+	}
+}
+
+func TestReadFileRejectsUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ConfigFileName)
+	if err := os.WriteFile(path, []byte("goals:\n  dailyMinuts: 90\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Default()
+	if err := ReadFile(path, &c); err == nil {
+		t.Error("Expected an error for a misspelt setting")
+	}
+}
+
+func TestGetSet(t *testing.T) {
+	c := Default()
+
+	if err := c.Set("goals.buckets", "{work: 180}"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("TEAM.SHAREBUCKETS", "false"); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, _ := c.Get("goals.buckets"); v != "work: 180" {
+		t.Errorf("Expected buckets as yaml, got: %q", v)
+	}
+	if v, _ := c.Get("team.shareBuckets"); v != "false" {
+		t.Errorf("Expected false, got: %q", v)
+	}
+
+	if v, _ := c.Get("days"); v != "timezone: \"\"\nstartHour: 0" {
+		t.Errorf("Expected the days section as yaml, got: %q", v)
+	}
+
+	if err := c.Set("days.startHour", "four"); err == nil {
+		t.Error("Expected an error setting a number to a word")
+	}
+	if _, err := c.Get("goals.weekly"); err == nil {
+		t.Error("Expected an error for an unknown setting")
+	}
+}
+
+func TestEnvVar(t *testing.T) {
+	cases := map[string]string{
+		"days.startHour":       "BLOCK_DAYS_START_HOUR",
+		"ffmpegRecordingsPath": "BLOCK_FFMPEG_RECORDINGS_PATH",
+		"team.server":          "BLOCK_TEAM_SERVER",
+	}
+	for key, want := range cases {
+		if got := EnvVar(key); got != want {
+			t.Errorf("EnvVar(%s): expected %s, got: %s", key, want, got)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("Expected the defaults to be valid, got: %s", err)
+	}
+
+	c := Default()
+	c.Days.StartHour = 24
+	c.Days.Timezone = "Mars/Olympus"
+	c.Sync.Server = "localhost:8090"
+	c.Notifications.Backends = append(c.Notifications.Backends, NotifierConfig{Type: "webhook"})
+
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("Expected errors")
+	}
+	for _, key := range []string{"days.startHour", "days.timezone", "sync.server", "notifications.backends[2]"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Expected an error naming %s, got: %s", key, err)
+		}
+	}
+}
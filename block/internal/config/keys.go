@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Settings are addressed by their dotted path in config.yaml, such as days.startHour. Each
+// can also be set with an environment variable named after it: BLOCK_DAYS_START_HOUR.
+
+// Keys lists every setting, in the order they appear in config.yaml.
+func Keys() []string {
+	var keys []string
+	walk(reflect.TypeOf(Config{}), "", nil, func(key string, field reflect.StructField, _ []int) {
+		if field.Type.Kind() != reflect.Struct {
+			keys = append(keys, key)
+		}
+	})
+	return keys
+}
+
+// EnvVar is the environment variable that sets key.
+func EnvVar(key string) string {
+	var b strings.Builder
+	b.WriteString("BLOCK_")
+	for i, r := range key {
+		switch {
+		case r == '.':
+			b.WriteRune('_')
+		case unicode.IsUpper(r) && i > 0 && key[i-1] != '.':
+			b.WriteRune('_')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return b.String()
+}
+
+// walk calls fn with the key and field index of every setting and section within t.
+func walk(t reflect.Type, prefix string, index []int, fn func(key string, field reflect.StructField, index []int)) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		key := prefix + name
+		fieldIndex := append(append([]int{}, index...), i)
+		fn(key, field, fieldIndex)
+		if field.Type.Kind() == reflect.Struct {
+			walk(field.Type, key+".", fieldIndex, fn)
+		}
+	}
+}
+
+// field finds a setting or section by key, ignoring case, returning its canonical key.
+func (c *Config) field(key string) (reflect.Value, string, error) {
+	var found []int
+	var canonical string
+	walk(reflect.TypeOf(*c), "", nil, func(k string, _ reflect.StructField, index []int) {
+		if strings.EqualFold(k, key) {
+			found, canonical = index, k
+		}
+	})
+	if found == nil {
+		return reflect.Value{}, "", fmt.Errorf("Error, unknown setting '%s', run block config get to list settings", key)
+	}
+
+	return reflect.ValueOf(c).Elem().FieldByIndex(found), canonical, nil
+}
+
+// Get returns a setting's value, formatted as it would be given to Set.
+func (c *Config) Get(key string) (string, error) {
+	v, _, err := c.field(key)
+	if err != nil {
+		return "", err
+	}
+
+	switch v.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int:
+		return fmt.Sprint(v.Interface()), nil
+	}
+
+	data, err := yaml.Marshal(v.Interface())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Set parses value into a setting. Lists and maps are given as yaml, such as [a, b] or
+// {work: 180}, and a list of words may also be given separated by commas.
+func (c *Config) Set(key, value string) error {
+	v, key, err := c.field(key)
+	if err != nil {
+		return err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("Error, %s expects true or false, got '%s'", key, value)
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("Error, %s expects a whole number, got '%s'", key, value)
+		}
+		v.SetInt(int64(n))
+	default:
+		if v.Type() == reflect.TypeOf([]string{}) && !strings.HasPrefix(strings.TrimSpace(value), "[") {
+			var words []string
+			for _, word := range strings.Split(value, ",") {
+				if word = strings.TrimSpace(word); word != "" {
+					words = append(words, word)
+				}
+			}
+			v.Set(reflect.ValueOf(words))
+			return nil
+		}
+
+		parsed := reflect.New(v.Type())
+		if err := yaml.Unmarshal([]byte(value), parsed.Interface()); err != nil {
+			return fmt.Errorf("Error, %s expects yaml: %w", key, err)
+		}
+		v.Set(parsed.Elem())
+	}
+
+	return nil
+}
+
+// applyEnv sets each setting whose environment variable is set.
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	for _, key := range Keys() {
+		if value, ok := lookup(EnvVar(key)); ok {
+			if err := c.Set(key, value); err != nil {
+				return fmt.Errorf("%w (from %s)", err, EnvVar(key))
+			}
+		}
+	}
+	return nil
+}
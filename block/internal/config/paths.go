@@ -0,0 +1,152 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+const (
+	HiddenConfigDirName = ".config/block-cli"
+	RootConfigDirName   = ".block-cli"
+	ConfigFileName      = "config.yaml"
+	DbName              = "app_data.db"
+	DbOptions           = "?_time_format=sqlite"
+
+	// WorkspacesDirName holds a directory for each named workspace, within both the config
+	// and data directories.
+	WorkspacesDirName = "workspaces"
+)
+
+var workspaceName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// Options choose which workspace to use. The zero value is the default workspace.
+type Options struct {
+	Workspace string // a named workspace, with its own config and database.
+	DbPath    string // a database file to use instead of the workspace's.
+}
+
+// Paths locate a workspace's config file and database.
+type Paths struct {
+	Workspace string // empty for the default workspace.
+	ConfigDir string
+	DataDir   string
+	DbPath    string // a database file given with --db or BLOCK_DB, used instead of the workspace's.
+}
+
+// Resolve finds where the workspace chosen by opts keeps its config and data.
+func Resolve(opts Options) (Paths, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return Paths{}, err
+	}
+
+	paths := Paths{
+		Workspace: opts.Workspace,
+		ConfigDir: ConfigDir(homeDir),
+		DataDir:   DataDir(homeDir),
+		DbPath:    opts.DbPath,
+	}
+	if opts.Workspace != "" {
+		if !workspaceName.MatchString(opts.Workspace) {
+			return paths, fmt.Errorf("Error, workspace '%s' must be letters, digits, - or _", opts.Workspace)
+		}
+		paths.ConfigDir = filepath.Join(paths.ConfigDir, WorkspacesDirName, opts.Workspace)
+		paths.DataDir = filepath.Join(paths.DataDir, WorkspacesDirName, opts.Workspace)
+	}
+
+	return paths, nil
+}
+
+// ConfigFile is the path of the workspace's config file.
+func (p Paths) ConfigFile() string {
+	return filepath.Join(p.ConfigDir, ConfigFileName)
+}
+
+// DbFile is the path of the task database.
+func (p Paths) DbFile() string {
+	if p.DbPath != "" {
+		return p.DbPath
+	}
+	return filepath.Join(p.DataDir, DbName)
+}
+
+// DSN is the data source name to open the task database with.
+func (p Paths) DSN() string {
+	return p.DbFile() + DbOptions
+}
+
+// MakeDirs creates the directories the workspace's files go in.
+func (p Paths) MakeDirs() error {
+	for _, dir := range []string{p.ConfigDir, p.DataDir, filepath.Dir(p.DbFile())} {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConfigDir is where config.yaml is kept: $XDG_CONFIG_HOME/block-cli when set, otherwise
+// ~/.config/block-cli.
+func ConfigDir(homeDir string) string {
+	return xdgDir("XDG_CONFIG_HOME", filepath.Join(homeDir, HiddenConfigDirName))
+}
+
+// DataDir is where the database is kept: $XDG_DATA_HOME/block-cli when set, otherwise
+// ~/.block-cli.
+func DataDir(homeDir string) string {
+	return xdgDir("XDG_DATA_HOME", filepath.Join(homeDir, RootConfigDirName))
+}
+
+// xdgDir is block-cli's directory under the XDG base directory in env. A legacy directory that
+// already exists is kept while the XDG one does not, so setting XDG_* later does not leave
+// existing tasks and settings behind.
+func xdgDir(env, legacy string) string {
+	base := os.Getenv(env)
+	if !filepath.IsAbs(base) {
+		return legacy
+	}
+
+	dir := filepath.Join(base, "block-cli")
+	if exists(dir) || !exists(legacy) {
+		return dir
+	}
+
+	return legacy
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Workspaces lists the named workspaces with a config or database.
+func Workspaces() ([]string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, dir := range []string{ConfigDir(homeDir), DataDir(homeDir)} {
+		entries, err := os.ReadDir(filepath.Join(dir, WorkspacesDirName))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && !seen[entry.Name()] {
+				seen[entry.Name()] = true
+				names = append(names, entry.Name())
+			}
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	testCases := []struct {
+		name       string
+		opts       Options
+		xdg        bool     // set XDG_CONFIG_HOME and XDG_DATA_HOME.
+		existing   []string // directories made under home before resolving.
+		wantConfig string   // relative to home.
+		wantDb     string   // relative to home unless absolute.
+		wantErr    bool
+	}{
+		{
+			name:       "default",
+			wantConfig: ".config/block-cli",
+			wantDb:     ".block-cli/app_data.db",
+		},
+		{
+			name:       "workspace",
+			opts:       Options{Workspace: "client-a"},
+			wantConfig: ".config/block-cli/workspaces/client-a",
+			wantDb:     ".block-cli/workspaces/client-a/app_data.db",
+		},
+		{
+			name:    "invalid workspace",
+			opts:    Options{Workspace: "../client-a"},
+			wantErr: true,
+		},
+		{
+			name:       "db path",
+			opts:       Options{DbPath: "/srv/block/tasks.db"},
+			wantConfig: ".config/block-cli",
+			wantDb:     "/srv/block/tasks.db",
+		},
+		{
+			name:       "xdg",
+			xdg:        true,
+			wantConfig: "xdg-config/block-cli",
+			wantDb:     "xdg-data/block-cli/app_data.db",
+		},
+		{
+			name:       "xdg workspace",
+			opts:       Options{Workspace: "personal"},
+			xdg:        true,
+			wantConfig: "xdg-config/block-cli/workspaces/personal",
+			wantDb:     "xdg-data/block-cli/workspaces/personal/app_data.db",
+		},
+		{
+			name:       "xdg keeps legacy directories",
+			xdg:        true,
+			existing:   []string{".config/block-cli", ".block-cli"},
+			wantConfig: ".config/block-cli",
+			wantDb:     ".block-cli/app_data.db",
+		},
+		{
+			name:       "xdg directories win once they exist",
+			xdg:        true,
+			existing:   []string{".config/block-cli", ".block-cli", "xdg-config/block-cli", "xdg-data/block-cli"},
+			wantConfig: "xdg-config/block-cli",
+			wantDb:     "xdg-data/block-cli/app_data.db",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			t.Setenv("XDG_CONFIG_HOME", "")
+			t.Setenv("XDG_DATA_HOME", "")
+			if tc.xdg {
+				t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg-config"))
+				t.Setenv("XDG_DATA_HOME", filepath.Join(home, "xdg-data"))
+			}
+			for _, dir := range tc.existing {
+				if err := os.MkdirAll(filepath.Join(home, dir), os.ModePerm); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			paths, err := Resolve(tc.opts)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got: %+v", paths)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			wantDb := tc.wantDb
+			if !filepath.IsAbs(wantDb) {
+				wantDb = filepath.Join(home, wantDb)
+			}
+			if got, want := paths.ConfigFile(), filepath.Join(home, tc.wantConfig, ConfigFileName); got != want {
+				t.Errorf("Expected config file %s, got: %s", want, got)
+			}
+			if got := paths.DbFile(); got != wantDb {
+				t.Errorf("Expected database %s, got: %s", wantDb, got)
+			}
+			if got := paths.DSN(); got != wantDb+DbOptions {
+				t.Errorf("Expected dsn %s, got: %s", wantDb+DbOptions, got)
+			}
+		})
+	}
+}
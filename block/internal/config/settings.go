@@ -0,0 +1,119 @@
+package config
+
+// Config is the settings of a workspace, as kept in its config.yaml.
+type Config struct {
+	FfmpegRecordingsPath string `yaml:"ffmpegRecordingsPath"`
+	AvfoundationDevice   string `yaml:"avfoundationDevice"`
+
+	Notifications NotificationsConfig `yaml:"notifications"`
+	Idle          IdleConfig          `yaml:"idle"`
+	Days          DaysConfig          `yaml:"days"`
+	Goals         GoalsConfig         `yaml:"goals"`
+	Sync          SyncConfig          `yaml:"sync"`
+	Team          TeamConfig          `yaml:"team"`
+}
+
+// TeamConfig points `block team push` at a team server and sets what it may see. Durations are
+// always shared, buckets when ShareBuckets is set and task names when ShareTaskNames is set.
+// Sessions in ExcludeBuckets are never shared.
+type TeamConfig struct {
+	Server         string   `yaml:"server"`
+	Token          string   `yaml:"token,omitempty"`
+	ShareTaskNames bool     `yaml:"shareTaskNames"`
+	ShareBuckets   bool     `yaml:"shareBuckets"`
+	ExcludeBuckets []string `yaml:"excludeBuckets,omitempty"`
+}
+
+// SyncConfig points `block sync` at a sync server, such as one started with `block sync serve`.
+// Token is sent as a bearer token and must match the server's.
+type SyncConfig struct {
+	Server string `yaml:"server"`
+	Token  string `yaml:"token,omitempty"`
+}
+
+// GoalsConfig sets how much focus time to aim for each day, in minutes. Buckets maps bucket
+// names to a daily goal of their own, counted within the overall goal.
+type GoalsConfig struct {
+	DailyMinutes int            `yaml:"dailyMinutes"`
+	Buckets      map[string]int `yaml:"buckets,omitempty"`
+}
+
+// DaysConfig sets which day a task belongs to. Timezone is an IANA name such as Australia/Perth,
+// empty for the system timezone. Days roll over at StartHour, so with 4 work until 03:59 counts
+// towards the previous day.
+type DaysConfig struct {
+	Timezone  string `yaml:"timezone"`
+	StartHour int    `yaml:"startHour"`
+}
+
+// IdleConfig controls automatic pausing when the user walks away.
+//
+// Provider is one of auto, x11, wayland or darwin.
+type IdleConfig struct {
+	Enabled          bool   `yaml:"enabled"`
+	Provider         string `yaml:"provider"`
+	ThresholdSeconds int    `yaml:"thresholdSeconds"`
+}
+
+// NotificationsConfig lists the notification backends and optional message templates keyed by event name.
+type NotificationsConfig struct {
+	Backends  []NotifierConfig  `yaml:"backends"`
+	Templates map[string]string `yaml:"templates,omitempty"`
+}
+
+// NotifierConfig configures a single notification backend.
+//
+// Type is one of desktop, bell, webhook, ntfy or command. An empty Events list subscribes to every event.
+type NotifierConfig struct {
+	Type    string   `yaml:"type"`
+	URL     string   `yaml:"url,omitempty"`
+	Token   string   `yaml:"token,omitempty"`
+	Command []string `yaml:"command,omitempty"`
+	Events  []string `yaml:"events,omitempty"`
+}
+
+const (
+	DefaultFfmpegRecordingsPath = "."
+	DefaultAvfoundationDevice   = "1:0"
+
+	DefaultIdleProvider         = "auto"
+	DefaultIdleThresholdSeconds = 5 * 60
+
+	DefaultDailyGoalMinutes = 120
+)
+
+// Default is the settings before the config file, environment and flags are applied.
+func Default() Config {
+	return Config{
+		FfmpegRecordingsPath: DefaultFfmpegRecordingsPath,
+		AvfoundationDevice:   DefaultAvfoundationDevice,
+		Notifications:        DefaultNotifications(),
+		Idle: IdleConfig{
+			Enabled:          false,
+			Provider:         DefaultIdleProvider,
+			ThresholdSeconds: DefaultIdleThresholdSeconds,
+		},
+		Days: DaysConfig{
+			Timezone:  "",
+			StartHour: 0,
+		},
+		Goals: GoalsConfig{
+			DailyMinutes: DefaultDailyGoalMinutes,
+		},
+		Team: TeamConfig{
+			ShareTaskNames: false,
+			ShareBuckets:   true,
+		},
+	}
+}
+
+// DefaultNotifications beeps and shows a desktop notification when a session finishes,
+// and shows desktop notifications at the halfway and five-minutes-left marks.
+func DefaultNotifications() NotificationsConfig {
+	return NotificationsConfig{
+		Backends: []NotifierConfig{
+			{Type: "desktop", Events: []string{"halfway", "five-minutes-left", "finished"}},
+			{Type: "bell", Events: []string{"finished"}},
+		},
+	}
+}
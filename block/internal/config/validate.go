@@ -0,0 +1,95 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var (
+	notifierTypes = []string{"desktop", "bell", "webhook", "ntfy", "command"}
+	notifyEvents  = []string{"halfway", "five-minutes-left", "finished"}
+	idleProviders = []string{"auto", "x11", "wayland", "darwin"}
+)
+
+// Validate checks every setting, returning an error listing each one that is wrong.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("Error, "+format, args...))
+		}
+	}
+
+	if info, err := os.Stat(c.FfmpegRecordingsPath); err != nil || !info.IsDir() {
+		check(false, "ffmpegRecordingsPath '%s' is not a directory", c.FfmpegRecordingsPath)
+	}
+	check(c.AvfoundationDevice != "", "avfoundationDevice must not be empty, the default is '%s'", DefaultAvfoundationDevice)
+
+	for i, backend := range c.Notifications.Backends {
+		key := fmt.Sprintf("notifications.backends[%d]", i)
+		check(slices.Contains(notifierTypes, backend.Type), "%s.type '%s' must be one of %s", key, backend.Type, strings.Join(notifierTypes, ", "))
+		if backend.Type == "webhook" || backend.Type == "ntfy" {
+			check(validURL(backend.URL, true), "%s needs an http or https url for %s, got '%s'", key, backend.Type, backend.URL)
+		}
+		if backend.Type == "command" {
+			check(len(backend.Command) > 0, "%s needs a command to run", key)
+		}
+		for _, event := range backend.Events {
+			check(slices.Contains(notifyEvents, event), "%s has unknown event '%s', expected one of %s", key, event, strings.Join(notifyEvents, ", "))
+		}
+	}
+	for _, event := range sortedKeys(c.Notifications.Templates) {
+		text := c.Notifications.Templates[event]
+		check(slices.Contains(notifyEvents, event), "notifications.templates has unknown event '%s', expected one of %s", event, strings.Join(notifyEvents, ", "))
+		if _, err := template.New(event).Parse(text); err != nil {
+			check(false, "notifications.templates.%s is not a valid template: %s", event, err)
+		}
+	}
+
+	check(c.Idle.Provider == "" || slices.Contains(idleProviders, c.Idle.Provider), "idle.provider '%s' must be one of %s", c.Idle.Provider, strings.Join(idleProviders, ", "))
+	check(c.Idle.ThresholdSeconds > 0, "idle.thresholdSeconds must be positive, got %d", c.Idle.ThresholdSeconds)
+
+	if c.Days.Timezone != "" {
+		_, err := time.LoadLocation(c.Days.Timezone)
+		check(err == nil, "days.timezone '%s' is not an IANA timezone such as Australia/Perth", c.Days.Timezone)
+	}
+	check(c.Days.StartHour >= 0 && c.Days.StartHour <= 23, "days.startHour must be between 0 and 23, got %d", c.Days.StartHour)
+
+	check(c.Goals.DailyMinutes >= 0 && c.Goals.DailyMinutes <= 24*60, "goals.dailyMinutes must be between 0 and 1440, got %d", c.Goals.DailyMinutes)
+	for _, name := range sortedKeys(c.Goals.Buckets) {
+		minutes := c.Goals.Buckets[name]
+		check(minutes > 0, "goals.buckets.%s must be a positive number of minutes, got %d", name, minutes)
+	}
+
+	check(validURL(c.Sync.Server, false), "sync.server must be an http or https url, got '%s'", c.Sync.Server)
+	check(validURL(c.Team.Server, false), "team.server must be an http or https url, got '%s'", c.Team.Server)
+	for _, name := range c.Team.ExcludeBuckets {
+		check(strings.TrimSpace(name) != "", "team.excludeBuckets must not contain empty names")
+	}
+
+	return errors.Join(errs...)
+}
+
+func validURL(s string, required bool) bool {
+	if s == "" {
+		return !required
+	}
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -3,27 +3,28 @@ package db
 import (
 	"fmt"
 
-	"github.com/connorkuljis/block-cli/internal/buckets"
-	"github.com/connorkuljis/block-cli/internal/config"
-	"github.com/connorkuljis/block-cli/internal/tasks"
 	"github.com/jmoiron/sqlx"
+
+	_ "modernc.org/sqlite"
 )
 
-func InitDB() (*sqlx.DB, error) {
-	db, err := sqlx.Connect("sqlite", config.GetDBPath())
+// Open connects to the sqlite database at dataSourceName and brings its schema up to date.
+func Open(dataSourceName string) (*sqlx.DB, error) {
+	db, err := Connect(dataSourceName)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = db.Exec(buckets.BucketsSchema)
-	if err != nil {
-		return nil, fmt.Errorf("Error initalising db schema: %w", err)
-	}
-
-	_, err = db.Exec(tasks.TasksSchema)
+	err = Migrate(db)
 	if err != nil {
+		db.Close()
 		return nil, fmt.Errorf("Error initalising db schema: %w", err)
 	}
 
 	return db, nil
 }
+
+// Connect connects to the sqlite database at dataSourceName as it is, without migrating it.
+func Connect(dataSourceName string) (*sqlx.DB, error) {
+	return sqlx.Connect("sqlite", dataSourceName)
+}
@@ -0,0 +1,25 @@
+// Package dbtest opens migrated databases for tests.
+package dbtest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/connorkuljis/block-cli/internal/config"
+	"github.com/connorkuljis/block-cli/internal/db"
+	"github.com/jmoiron/sqlx"
+)
+
+// Open returns a migrated database in a file of its own, closed when the test ends. Each call
+// opens a separate database, such as for two devices syncing.
+func Open(t testing.TB) *sqlx.DB {
+	t.Helper()
+
+	conn, err := db.Open(filepath.Join(t.TempDir(), "block.db") + config.DbOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
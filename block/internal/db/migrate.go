@@ -0,0 +1,201 @@
+package db
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+const migrationsDir = "migrations"
+
+const schemaVersionSchema = `
+	CREATE TABLE IF NOT EXISTS schema_version
+	(
+      version    INTEGER PRIMARY KEY
+    , name       TEXT NOT NULL
+    , applied_at TIMESTAMP NOT NULL
+	);
+`
+
+// Migration is a single up-migration loaded from a file named NNNN_name.sql.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// MigrationStatus reports whether a migration has been applied, and when.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt sql.NullTime
+}
+
+// Migrate applies every pending embedded migration in version order.
+func Migrate(db *sqlx.DB) error {
+	return migrate(db, migrationsFS)
+}
+
+// Status lists every embedded migration alongside when it was applied.
+func Status(db *sqlx.DB) ([]MigrationStatus, error) {
+	return status(db, migrationsFS)
+}
+
+// LoadMigrations reads and orders the migration files in the migrations directory of fsys.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, migrationsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	seen := make(map[int]string)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		version, name, err := parseMigrationFilename(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("Error, migrations %s and %s share version %d", other, entry.Name(), version)
+		}
+		seen[version] = entry.Name()
+
+		contents, err := fs.ReadFile(fsys, path.Join(migrationsDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, Migration{Version: version, Name: name, SQL: string(contents)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// parseMigrationFilename splits "0002_add_bucket_goals.sql" into 2 and "add_bucket_goals".
+func parseMigrationFilename(filename string) (int, string, error) {
+	base := strings.TrimSuffix(filename, path.Ext(filename))
+
+	prefix, name, ok := strings.Cut(base, "_")
+	if !ok {
+		return 0, "", fmt.Errorf("Error, migration '%s' must be named NNNN_name.sql", filename)
+	}
+
+	version, err := strconv.Atoi(prefix)
+	if err != nil || version < 1 {
+		return 0, "", fmt.Errorf("Error, migration '%s' must start with a positive version number", filename)
+	}
+
+	return version, name, nil
+}
+
+func migrate(db *sqlx.DB, fsys fs.FS) error {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(schemaVersionSchema); err != nil {
+		return fmt.Errorf("Error creating schema_version table: %w", err)
+	}
+
+	current, err := currentVersion(db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+
+		if err := apply(db, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// apply runs a migration and records it in a single transaction, so a failing migration leaves no trace.
+func apply(db *sqlx.DB, m Migration) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.SQL); err != nil {
+		return fmt.Errorf("Error applying migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+
+	_, err = tx.Exec("INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)", m.Version, m.Name, time.Now())
+	if err != nil {
+		return fmt.Errorf("Error recording migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+
+	return tx.Commit()
+}
+
+func currentVersion(db *sqlx.DB) (int, error) {
+	var version sql.NullInt64
+	if err := db.Get(&version, "SELECT MAX(version) FROM schema_version"); err != nil {
+		return 0, err
+	}
+
+	return int(version.Int64), nil
+}
+
+func status(db *sqlx.DB, fsys fs.FS) ([]MigrationStatus, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(schemaVersionSchema); err != nil {
+		return nil, fmt.Errorf("Error creating schema_version table: %w", err)
+	}
+
+	var applied []struct {
+		Version   int       `db:"version"`
+		AppliedAt time.Time `db:"applied_at"`
+	}
+	if err := db.Select(&applied, "SELECT version, applied_at FROM schema_version"); err != nil {
+		return nil, err
+	}
+
+	appliedAt := make(map[int]time.Time)
+	for _, a := range applied {
+		appliedAt[a.Version] = a.AppliedAt
+	}
+
+	var statuses []MigrationStatus
+	for _, m := range migrations {
+		s := MigrationStatus{Version: m.Version, Name: m.Name}
+		if t, ok := appliedAt[m.Version]; ok {
+			s.AppliedAt = sql.NullTime{Time: t, Valid: true}
+		}
+		statuses = append(statuses, s)
+	}
+
+	return statuses, nil
+}
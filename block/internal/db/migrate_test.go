@@ -0,0 +1,234 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// legacySchema is the schema created by `db.InitDB` before versioned migrations were introduced.
+const legacySchema = `
+	CREATE TABLE IF NOT EXISTS Buckets (
+		bucket_id INTEGER PRIMARY KEY AUTOINCREMENT,
+		bucket_name string
+	);
+
+	CREATE TABLE IF NOT EXISTS Tasks
+	(
+      task_id                    INTEGER PRIMARY KEY AUTOINCREMENT
+    , task_name                  TEXT NOT NULL
+    , estimated_duration_seconds INTEGER NOT NULL
+    , actual_duration_seconds    INTEGER
+    , blocker_enabled            INTEGER DEFAULT 0
+    , screen_enabled             INTEGER DEFAULT 0
+    , screen_url                 TEXT
+    , created_at                 TIMESTAMP NOT NULL
+    , finished_at                TIMESTAMP
+    , completed                  INTEGER
+    , completion_percent         REAL
+    , status                     TEXT
+    , bucket_id                  INTEGER
+    , FOREIGN KEY (bucket_id) REFERENCES Buckets(bucket_id)
+	);
+
+	INSERT INTO Buckets (bucket_name) VALUES ('work');
+	INSERT INTO Tasks (task_name, estimated_duration_seconds, actual_duration_seconds, created_at, finished_at, completed, completion_percent, bucket_id)
+	VALUES ('legacy task', 1500, 1500, '2024-03-01 09:00:00+00:00', '2024-03-01 09:25:00+00:00', 1, 100.0, 1);
+`
+
+func openFixture(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "app_data.db") + "?_time_format=sqlite"
+	conn, err := sqlx.Connect("sqlite", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	if _, err := conn.Exec(legacySchema); err != nil {
+		t.Fatal(err)
+	}
+
+	return conn
+}
+
+func TestMigrateLegacyDatabase(t *testing.T) {
+	conn := openFixture(t)
+
+	if err := Migrate(conn); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	migrations, err := LoadMigrations(migrationsFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := currentVersion(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := migrations[len(migrations)-1].Version; version != want {
+		t.Errorf("Expected version: %d, got: %d", want, version)
+	}
+
+	var taskName string
+	if err := conn.Get(&taskName, "SELECT task_name FROM Tasks WHERE task_id = 1"); err != nil {
+		t.Fatal(err)
+	}
+	if taskName != "legacy task" {
+		t.Errorf("Expected legacy task to survive migration, got: %q", taskName)
+	}
+
+	var taskUUID string
+	if err := conn.Get(&taskUUID, "SELECT task_uuid FROM Tasks WHERE task_id = 1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(taskUUID) != 36 || taskUUID[14] != '4' {
+		t.Errorf("Expected legacy task to be backfilled with a v4 uuid, got: %q", taskUUID)
+	}
+
+	for _, table := range []string{"Segments", "IdleIntervals", "Notes", "Interruptions", "Templates"} {
+		var n int
+		if err := conn.Get(&n, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table); err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Errorf("Expected table %s to exist after migration", table)
+		}
+	}
+
+	// running again is a no-op.
+	if err := Migrate(conn); err != nil {
+		t.Fatalf("second Migrate: %v", err)
+	}
+
+	var applied int
+	if err := conn.Get(&applied, "SELECT COUNT(*) FROM schema_version"); err != nil {
+		t.Fatal(err)
+	}
+	if applied != len(migrations) {
+		t.Errorf("Expected %d applied migrations, got: %d", len(migrations), applied)
+	}
+}
+
+func TestMigrateRollsBackFailedMigration(t *testing.T) {
+	conn := openFixture(t)
+
+	fsys := fstest.MapFS{
+		"migrations/0001_baseline.sql":   {Data: []byte("CREATE TABLE IF NOT EXISTS Example (id INTEGER PRIMARY KEY);")},
+		"migrations/0002_add_column.sql": {Data: []byte("ALTER TABLE Example ADD COLUMN name TEXT; ALTER TABLE Missing ADD COLUMN name TEXT;")},
+	}
+
+	if err := migrate(conn, fsys); err == nil {
+		t.Fatal("Expected error from failing migration, got nil")
+	}
+
+	version, err := currentVersion(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 1 {
+		t.Errorf("Expected version: 1, got: %d", version)
+	}
+
+	// the first statement of the failed migration must have been rolled back.
+	if _, err := conn.Exec("ALTER TABLE Example ADD COLUMN name TEXT"); err != nil {
+		t.Errorf("Expected column from failed migration to be rolled back: %v", err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	conn := openFixture(t)
+
+	fsys := fstest.MapFS{
+		"migrations/0001_baseline.sql": {Data: []byte("SELECT 1;")},
+		"migrations/0002_next.sql":     {Data: []byte("SELECT 1;")},
+	}
+
+	statuses, err := status(conn, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.AppliedAt.Valid {
+			t.Errorf("Expected migration %d to be pending", s.Version)
+		}
+	}
+
+	if err := migrate(conn, fsys); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err = status(conn, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if !s.AppliedAt.Valid {
+			t.Errorf("Expected migration %d to be applied", s.Version)
+		}
+	}
+}
+
+func TestParseMigrationFilename(t *testing.T) {
+	testCases := []struct {
+		filename    string
+		wantVersion int
+		wantName    string
+		wantErr     bool
+	}{
+		{filename: "0001_baseline.sql", wantVersion: 1, wantName: "baseline"},
+		{filename: "0012_add_bucket_goals.sql", wantVersion: 12, wantName: "add_bucket_goals"},
+		{filename: "baseline.sql", wantErr: true},
+		{filename: "abcd_baseline.sql", wantErr: true},
+		{filename: "0000_zero.sql", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.filename, func(t *testing.T) {
+			version, name, err := parseMigrationFilename(tc.filename)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error: %v, got: %v", tc.wantErr, err)
+			}
+			if version != tc.wantVersion || name != tc.wantName {
+				t.Errorf("Expected: %d %q, got: %d %q", tc.wantVersion, tc.wantName, version, name)
+			}
+		})
+	}
+}
+
+func TestConnectLeavesMigrationsPending(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "pending.db") + "?_time_format=sqlite"
+
+	conn, err := Connect(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	statuses, err := Status(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.AppliedAt.Valid {
+			t.Fatalf("Expected every migration to be pending, %04d was applied", s.Version)
+		}
+	}
+
+	if err := Migrate(conn); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err = Status(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := statuses[len(statuses)-1]; !last.AppliedAt.Valid {
+		t.Errorf("Expected %04d to be applied after migrating", last.Version)
+	}
+}
@@ -0,0 +1,71 @@
+-- Baseline schema, matching databases created before versioned migrations.
+-- Every statement is idempotent so existing databases are adopted as-is.
+
+CREATE TABLE IF NOT EXISTS Buckets (
+    bucket_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    bucket_name string
+);
+
+CREATE TABLE IF NOT EXISTS Tasks (
+    task_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_name TEXT NOT NULL,
+    estimated_duration_seconds INTEGER NOT NULL,
+    actual_duration_seconds INTEGER,
+    blocker_enabled INTEGER DEFAULT 0,
+    screen_enabled INTEGER DEFAULT 0,
+    screen_url TEXT,
+    created_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP,
+    completed INTEGER,
+    completion_percent REAL,
+    status TEXT,
+    bucket_id INTEGER,
+    FOREIGN KEY (bucket_id) REFERENCES Buckets(bucket_id)
+);
+
+CREATE TABLE IF NOT EXISTS Segments (
+    segment_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL,
+    duration_seconds INTEGER NOT NULL,
+    FOREIGN KEY (task_id) REFERENCES Tasks(task_id)
+);
+
+CREATE TABLE IF NOT EXISTS IdleIntervals (
+    idle_interval_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP NOT NULL,
+    kept INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY (task_id) REFERENCES Tasks(task_id)
+);
+
+CREATE TABLE IF NOT EXISTS Notes (
+    note_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    outcome TEXT NOT NULL,
+    focus_rating INTEGER,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (task_id) REFERENCES Tasks(task_id)
+);
+
+CREATE TABLE IF NOT EXISTS Interruptions (
+    interruption_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (task_id) REFERENCES Tasks(task_id)
+);
+
+CREATE TABLE IF NOT EXISTS Templates (
+    template_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    template_name TEXT NOT NULL UNIQUE,
+    task_name TEXT NOT NULL,
+    duration_seconds INTEGER NOT NULL,
+    blocker_enabled INTEGER DEFAULT 1,
+    screen_enabled INTEGER DEFAULT 0,
+    bucket_id INTEGER,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (bucket_id) REFERENCES Buckets(bucket_id)
+);
@@ -0,0 +1,17 @@
+-- Rebuild Buckets to fix the bucket_name column type and add colour, weekly goal and archive columns.
+
+CREATE TABLE Buckets_new (
+    bucket_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    bucket_name TEXT NOT NULL,
+    colour TEXT,
+    weekly_goal_seconds INTEGER,
+    archived_at TIMESTAMP
+);
+
+INSERT INTO Buckets_new (bucket_id, bucket_name)
+SELECT bucket_id, COALESCE(bucket_name, 'bucket-' || bucket_id)
+FROM Buckets;
+
+DROP TABLE Buckets;
+
+ALTER TABLE Buckets_new RENAME TO Buckets;
@@ -0,0 +1,17 @@
+-- Free-form tags, many per task.
+
+CREATE TABLE IF NOT EXISTS Tags (
+    tag_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tag_name TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS TaskTags (
+    task_id INTEGER NOT NULL,
+    tag_id INTEGER NOT NULL,
+    PRIMARY KEY (task_id, tag_id),
+    FOREIGN KEY (task_id) REFERENCES Tasks(task_id),
+    FOREIGN KEY (tag_id) REFERENCES Tags(tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_tags_tag_id ON TaskTags(tag_id);
@@ -0,0 +1,15 @@
+-- A stable identifier per task, so exported history can be merged into another database
+-- without relying on the autoincrement task_id. Existing tasks are backfilled with random v4 uuids.
+
+ALTER TABLE Tasks ADD COLUMN task_uuid TEXT;
+
+UPDATE Tasks SET task_uuid = lower(
+    hex(randomblob(4)) || '-' ||
+    hex(randomblob(2)) || '-' ||
+    '4' || substr(hex(randomblob(2)), 2) || '-' ||
+    substr('89ab', 1 + (abs(random()) % 4), 1) || substr(hex(randomblob(2)), 2) || '-' ||
+    hex(randomblob(6))
+)
+WHERE task_uuid IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tasks_task_uuid ON Tasks(task_uuid);
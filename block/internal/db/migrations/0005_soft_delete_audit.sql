@@ -0,0 +1,21 @@
+-- Deleting a task marks it with deleted_at instead of removing the row, so it can be restored.
+-- AuditLog keeps a json snapshot of each task before and after every edit, delete and restore.
+-- Rows written by one command share a batch_id so `block undo` can revert them together.
+
+ALTER TABLE Tasks ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS AuditLog (
+    audit_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    batch_id TEXT NOT NULL,
+    task_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    source TEXT NOT NULL,
+    before_json TEXT NOT NULL,
+    after_json TEXT NOT NULL,
+    undone_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (task_id) REFERENCES Tasks(task_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_task_id ON AuditLog(task_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_batch_id ON AuditLog(batch_id);
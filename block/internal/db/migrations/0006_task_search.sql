@@ -0,0 +1,42 @@
+-- TaskSearch is a full-text index over each task's name and the outcomes of its notes.
+-- Rows share their rowid with Tasks.task_id and are kept in sync by the triggers below.
+-- Searches match words by prefix and ignore case and accents.
+
+CREATE VIRTUAL TABLE IF NOT EXISTS TaskSearch USING fts5(name, notes, tokenize = 'unicode61 remove_diacritics 2');
+
+INSERT INTO TaskSearch (rowid, name, notes)
+SELECT t.task_id, t.task_name, COALESCE((SELECT group_concat(n.outcome, ' ') FROM Notes n WHERE n.task_id = t.task_id), '')
+FROM Tasks t;
+
+CREATE TRIGGER IF NOT EXISTS task_search_tasks_insert AFTER INSERT ON Tasks BEGIN
+    INSERT INTO TaskSearch (rowid, name, notes) VALUES (new.task_id, new.task_name, '');
+END;
+
+CREATE TRIGGER IF NOT EXISTS task_search_tasks_update AFTER UPDATE OF task_name ON Tasks BEGIN
+    UPDATE TaskSearch SET name = new.task_name WHERE rowid = new.task_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS task_search_tasks_delete AFTER DELETE ON Tasks BEGIN
+    DELETE FROM TaskSearch WHERE rowid = old.task_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS task_search_notes_insert AFTER INSERT ON Notes BEGIN
+    UPDATE TaskSearch
+    SET notes = COALESCE((SELECT group_concat(outcome, ' ') FROM Notes WHERE task_id = new.task_id), '')
+    WHERE rowid = new.task_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS task_search_notes_update AFTER UPDATE OF outcome, task_id ON Notes BEGIN
+    UPDATE TaskSearch
+    SET notes = COALESCE((SELECT group_concat(outcome, ' ') FROM Notes WHERE task_id = old.task_id), '')
+    WHERE rowid = old.task_id;
+    UPDATE TaskSearch
+    SET notes = COALESCE((SELECT group_concat(outcome, ' ') FROM Notes WHERE task_id = new.task_id), '')
+    WHERE rowid = new.task_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS task_search_notes_delete AFTER DELETE ON Notes BEGIN
+    UPDATE TaskSearch
+    SET notes = COALESCE((SELECT group_concat(outcome, ' ') FROM Notes WHERE task_id = old.task_id), '')
+    WHERE rowid = old.task_id;
+END;
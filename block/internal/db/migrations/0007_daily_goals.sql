@@ -0,0 +1,11 @@
+-- DailyGoals records the daily goal in force on each day and how much focus time went towards it,
+-- so streaks keep counting against the goal of the day even after the configured goal changes.
+-- day is a yyyy-mm-dd date in the configured timezone.
+
+CREATE TABLE IF NOT EXISTS DailyGoals (
+    day TEXT PRIMARY KEY,
+    goal_seconds INTEGER NOT NULL,
+    focus_seconds INTEGER NOT NULL,
+    met INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,19 @@
+-- Plan is the backlog of tasks planned ahead, worked through in position order by `block next`.
+-- planned_for is the yyyy-mm-dd day the task was planned for. Starting an item links it to the
+-- task it became, so the day's plan can be compared with the time actually spent.
+
+CREATE TABLE IF NOT EXISTS Plan (
+    plan_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_name TEXT NOT NULL,
+    estimated_duration_seconds INTEGER NOT NULL,
+    bucket_id INTEGER,
+    position INTEGER NOT NULL,
+    planned_for TEXT NOT NULL,
+    task_id INTEGER,
+    created_at TIMESTAMP NOT NULL,
+    started_at TIMESTAMP,
+    FOREIGN KEY (bucket_id) REFERENCES Buckets(bucket_id),
+    FOREIGN KEY (task_id) REFERENCES Tasks(task_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_plan_planned_for ON Plan(planned_for);
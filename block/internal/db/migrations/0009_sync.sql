@@ -0,0 +1,67 @@
+-- Sync between devices. Tasks and buckets record when they last changed in updated_at, and every
+-- change adds the row's key (task uuid or lower-case bucket name) to SyncOutbox until `block sync`
+-- has sent it. Adding tags, segments, idle intervals, notes or interruptions to a task counts as
+-- a change to the task. Statements that set updated_at themselves, as sync does when applying a
+-- change from another device, keep the value they set.
+-- SyncState holds this device's id and how far it has read each sync server.
+
+ALTER TABLE Tasks ADD COLUMN updated_at TIMESTAMP;
+ALTER TABLE Buckets ADD COLUMN updated_at TIMESTAMP;
+
+UPDATE Tasks SET updated_at = COALESCE(finished_at, created_at);
+UPDATE Buckets SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now');
+
+CREATE TABLE IF NOT EXISTS SyncOutbox (
+    outbox_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL,
+    key TEXT NOT NULL,
+    UNIQUE (kind, key)
+);
+
+CREATE TABLE IF NOT EXISTS SyncState (
+    name TEXT PRIMARY KEY,
+    value TEXT NOT NULL
+);
+
+INSERT OR IGNORE INTO SyncOutbox (kind, key) SELECT 'bucket', lower(bucket_name) FROM Buckets;
+INSERT OR IGNORE INTO SyncOutbox (kind, key) SELECT 'task', task_uuid FROM Tasks;
+
+CREATE TRIGGER IF NOT EXISTS sync_tasks_insert AFTER INSERT ON Tasks BEGIN
+    UPDATE Tasks SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE task_id = new.task_id AND new.updated_at IS NULL;
+    INSERT OR REPLACE INTO SyncOutbox (kind, key) VALUES ('task', new.task_uuid);
+END;
+
+CREATE TRIGGER IF NOT EXISTS sync_tasks_update AFTER UPDATE ON Tasks BEGIN
+    UPDATE Tasks SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE task_id = new.task_id AND new.updated_at IS old.updated_at;
+    INSERT OR REPLACE INTO SyncOutbox (kind, key) VALUES ('task', new.task_uuid);
+END;
+
+CREATE TRIGGER IF NOT EXISTS sync_buckets_insert AFTER INSERT ON Buckets BEGIN
+    UPDATE Buckets SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE bucket_id = new.bucket_id AND new.updated_at IS NULL;
+    INSERT OR REPLACE INTO SyncOutbox (kind, key) VALUES ('bucket', lower(new.bucket_name));
+END;
+
+CREATE TRIGGER IF NOT EXISTS sync_buckets_update AFTER UPDATE ON Buckets BEGIN
+    UPDATE Buckets SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE bucket_id = new.bucket_id AND new.updated_at IS old.updated_at;
+    INSERT OR REPLACE INTO SyncOutbox (kind, key) VALUES ('bucket', lower(new.bucket_name));
+END;
+
+CREATE TRIGGER IF NOT EXISTS sync_task_tags_insert AFTER INSERT ON TaskTags BEGIN
+    UPDATE Tasks SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE task_id = new.task_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS sync_segments_insert AFTER INSERT ON Segments BEGIN
+    UPDATE Tasks SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE task_id = new.task_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS sync_idle_intervals_insert AFTER INSERT ON IdleIntervals BEGIN
+    UPDATE Tasks SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE task_id = new.task_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS sync_notes_insert AFTER INSERT ON Notes BEGIN
+    UPDATE Tasks SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE task_id = new.task_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS sync_interruptions_insert AFTER INSERT ON Interruptions BEGIN
+    UPDATE Tasks SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE task_id = new.task_id;
+END;
@@ -0,0 +1,13 @@
+-- TaskPluginData holds the values plugins attach to a task when answering a session event, one
+-- row per plugin and key, such as the issue a session was logged against. A plugin setting a key
+-- again replaces its value. The values stay on this device: they are not synced or exported.
+
+CREATE TABLE IF NOT EXISTS TaskPluginData (
+    task_id INTEGER NOT NULL,
+    plugin TEXT NOT NULL,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (task_id, plugin, key),
+    FOREIGN KEY (task_id) REFERENCES Tasks(task_id)
+);
@@ -0,0 +1,33 @@
+-- A stable identifier per bucket, so sync follows a bucket across devices when it is renamed.
+-- Existing buckets are backfilled with random v4 uuids. Devices that backfilled the same bucket
+-- differently settle on the smaller uuid when they sync. The bucket sync triggers now add the
+-- bucket uuid to SyncOutbox instead of the lower-case name, and every bucket is sent again.
+
+DROP TRIGGER IF EXISTS sync_buckets_insert;
+DROP TRIGGER IF EXISTS sync_buckets_update;
+
+ALTER TABLE Buckets ADD COLUMN bucket_uuid TEXT;
+
+UPDATE Buckets SET bucket_uuid = lower(
+    hex(randomblob(4)) || '-' ||
+    hex(randomblob(2)) || '-' ||
+    '4' || substr(hex(randomblob(2)), 2) || '-' ||
+    substr('89ab', 1 + (abs(random()) % 4), 1) || substr(hex(randomblob(2)), 2) || '-' ||
+    hex(randomblob(6))
+)
+WHERE bucket_uuid IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_buckets_bucket_uuid ON Buckets(bucket_uuid);
+
+CREATE TRIGGER IF NOT EXISTS sync_buckets_insert AFTER INSERT ON Buckets BEGIN
+    UPDATE Buckets SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE bucket_id = new.bucket_id AND new.updated_at IS NULL;
+    INSERT OR REPLACE INTO SyncOutbox (kind, key) VALUES ('bucket', new.bucket_uuid);
+END;
+
+CREATE TRIGGER IF NOT EXISTS sync_buckets_update AFTER UPDATE ON Buckets BEGIN
+    UPDATE Buckets SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE bucket_id = new.bucket_id AND new.updated_at IS old.updated_at;
+    INSERT OR REPLACE INTO SyncOutbox (kind, key) VALUES ('bucket', new.bucket_uuid);
+END;
+
+DELETE FROM SyncOutbox WHERE kind = 'bucket';
+INSERT OR IGNORE INTO SyncOutbox (kind, key) SELECT 'bucket', bucket_uuid FROM Buckets;
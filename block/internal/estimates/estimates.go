@@ -0,0 +1,326 @@
+// Package estimates compares planned durations with the time tasks actually took, for
+// `block estimates`, the /estimates page and the duration suggestion in `block start`.
+//
+// A session is capped at its planned time, so work that overruns shows up as follow-up sessions.
+// Sessions of the same task pattern on the same day are therefore measured together as one piece
+// of work, planned by the estimate of its first session.
+package estimates
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// Tolerance is how far actual time may stray from the estimate before work counts as over or under run.
+	Tolerance = 0.1
+	// MinSamples is how many pieces of work a pattern needs to be listed, or to suggest a duration.
+	MinSamples = 3
+	// SuggestRatio is how many times the estimate typical work must take before a longer duration is suggested.
+	SuggestRatio = 1.5
+	// SuggestWindowDays is how far back suggestions look for similar work.
+	SuggestWindowDays = 90
+)
+
+// Pattern reduces a task name to the words that identify similar work: lower case, without
+// punctuation, numbers or words containing digits, so "Review PR #42" and "review pr 57" match.
+func Pattern(taskName string) string {
+	words := strings.FieldsFunc(strings.ToLower(taskName), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var kept []string
+	for _, word := range words {
+		if strings.IndexFunc(word, unicode.IsDigit) == -1 {
+			kept = append(kept, word)
+		}
+	}
+
+	return strings.Join(kept, " ")
+}
+
+// Work is the sessions of one task pattern on one day.
+type Work struct {
+	Date             time.Time // the day, as a calendar date.
+	Pattern          string
+	BucketId         int64 // of the first session, 0 without a bucket.
+	Sessions         int
+	EstimatedSeconds int64 // the first session's estimate.
+	ActualSeconds    int64
+}
+
+// Ratio is actual time over the estimate, above 1 when the work took longer than planned.
+func (w Work) Ratio() float64 {
+	if w.EstimatedSeconds <= 0 {
+		return 0
+	}
+	return float64(w.ActualSeconds) / float64(w.EstimatedSeconds)
+}
+
+// Collect groups worked sessions into pieces of work, in order of their first session.
+// Unnamed sessions have nothing to match on, so each is a piece of work of its own.
+func Collect(all []tasks.Task, cal calendar.Calendar) []Work {
+	sorted := append([]tasks.Task(nil), all...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+
+	type key struct {
+		date    time.Time
+		pattern string
+	}
+
+	var out []Work
+	index := make(map[key]int)
+	for _, task := range sorted {
+		if task.EstimatedDurationSeconds <= 0 || (!task.FinishedAt.Valid && task.ActualDurationSeconds.Int64 == 0) {
+			continue
+		}
+
+		k := key{date: cal.Date(task.CreatedAt), pattern: Pattern(task.TaskName)}
+		if i, ok := index[k]; ok && k.pattern != "" {
+			out[i].Sessions++
+			out[i].ActualSeconds += task.ActualDurationSeconds.Int64
+			continue
+		}
+
+		index[k] = len(out)
+		out = append(out, Work{
+			Date:             k.date,
+			Pattern:          k.pattern,
+			BucketId:         task.BucketId.Int64,
+			Sessions:         1,
+			EstimatedSeconds: task.EstimatedDurationSeconds,
+			ActualSeconds:    task.ActualDurationSeconds.Int64,
+		})
+	}
+
+	return out
+}
+
+// Accuracy summarises how a set of work compared with its estimates.
+type Accuracy struct {
+	Count                  int // pieces of work.
+	Sessions               int
+	EstimatedSeconds       int64
+	ActualSeconds          int64
+	MedianEstimatedSeconds int64
+	MedianActualSeconds    int64
+	Overran                int // took longer than the estimate, beyond the tolerance.
+	Underran               int // finished sooner than the estimate, beyond the tolerance.
+}
+
+// Ratio is total actual time over total estimated time.
+func (a Accuracy) Ratio() float64 {
+	if a.EstimatedSeconds <= 0 {
+		return 0
+	}
+	return float64(a.ActualSeconds) / float64(a.EstimatedSeconds)
+}
+
+func (a Accuracy) OverranPercent() float64 {
+	if a.Count == 0 {
+		return 0
+	}
+	return float64(a.Overran) / float64(a.Count) * 100
+}
+
+func (a Accuracy) UnderranPercent() float64 {
+	if a.Count == 0 {
+		return 0
+	}
+	return float64(a.Underran) / float64(a.Count) * 100
+}
+
+func measure(work []Work) Accuracy {
+	var a Accuracy
+	var estimated, actual []int64
+	for _, w := range work {
+		a.Count++
+		a.Sessions += w.Sessions
+		a.EstimatedSeconds += w.EstimatedSeconds
+		a.ActualSeconds += w.ActualSeconds
+		if w.Ratio() > 1+Tolerance {
+			a.Overran++
+		}
+		if w.Ratio() < 1-Tolerance {
+			a.Underran++
+		}
+		estimated = append(estimated, w.EstimatedSeconds)
+		actual = append(actual, w.ActualSeconds)
+	}
+
+	a.MedianEstimatedSeconds = median(estimated)
+	a.MedianActualSeconds = median(actual)
+
+	return a
+}
+
+func median(values []int64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+type BucketAccuracy struct {
+	BucketId   int64 // 0 for work without a bucket.
+	BucketName string
+	Accuracy
+}
+
+type PatternAccuracy struct {
+	Pattern string
+	Accuracy
+}
+
+type WeekAccuracy struct {
+	Start time.Time
+	Accuracy
+}
+
+// Analysis is estimate accuracy over a span of weeks, overall and broken down.
+type Analysis struct {
+	Start    time.Time
+	End      time.Time
+	Overall  Accuracy
+	Buckets  []BucketAccuracy  // by amount of work, most first.
+	Patterns []PatternAccuracy // patterns with at least MinSamples pieces of work, most first.
+	Weeks    []WeekAccuracy    // every week in [Start, End), oldest first.
+}
+
+// Analyse measures work within the weeks [start, end), which must fall on week starts.
+func Analyse(work []Work, allBuckets []buckets.Bucket, cal calendar.Calendar, start, end time.Time) Analysis {
+	a := Analysis{Start: start, End: end, Overall: measure(work)}
+
+	bucketNames := map[int64]string{0: "(none)"}
+	for _, bucket := range allBuckets {
+		bucketNames[bucket.BucketId] = bucket.BucketName
+	}
+
+	byBucket := make(map[int64][]Work)
+	byPattern := make(map[string][]Work)
+	byWeek := make(map[time.Time][]Work)
+	for _, w := range work {
+		byBucket[w.BucketId] = append(byBucket[w.BucketId], w)
+		if w.Pattern != "" {
+			byPattern[w.Pattern] = append(byPattern[w.Pattern], w)
+		}
+		week, _ := cal.WeekBounds(cal.Start(w.Date))
+		byWeek[week] = append(byWeek[week], w)
+	}
+
+	for id, work := range byBucket {
+		a.Buckets = append(a.Buckets, BucketAccuracy{BucketId: id, BucketName: bucketNames[id], Accuracy: measure(work)})
+	}
+	sort.Slice(a.Buckets, func(i, j int) bool {
+		if a.Buckets[i].Count != a.Buckets[j].Count {
+			return a.Buckets[i].Count > a.Buckets[j].Count
+		}
+		return a.Buckets[i].BucketName < a.Buckets[j].BucketName
+	})
+
+	for pattern, work := range byPattern {
+		if len(work) >= MinSamples {
+			a.Patterns = append(a.Patterns, PatternAccuracy{Pattern: pattern, Accuracy: measure(work)})
+		}
+	}
+	sort.Slice(a.Patterns, func(i, j int) bool {
+		if a.Patterns[i].Count != a.Patterns[j].Count {
+			return a.Patterns[i].Count > a.Patterns[j].Count
+		}
+		return a.Patterns[i].Pattern < a.Patterns[j].Pattern
+	})
+
+	for week := start; week.Before(end); {
+		_, next := cal.WeekBounds(week)
+		a.Weeks = append(a.Weeks, WeekAccuracy{Start: week, Accuracy: measure(byWeek[week])})
+		week = next
+	}
+
+	return a
+}
+
+// Generate analyses tasks matching query over the given number of weeks, the last being the week containing now.
+func Generate(ctx context.Context, db *sqlx.DB, cal calendar.Calendar, weeks int, now time.Time, query *tasks.Query) (Analysis, error) {
+	start, end := cal.WeekBounds(now)
+	for i := 1; i < weeks; i++ {
+		start, _ = cal.WeekBounds(start.Add(-time.Nanosecond))
+	}
+
+	all, err := tasks.NewStore(db).Select(ctx, query.Between(start, end))
+	if err != nil {
+		return Analysis{}, err
+	}
+
+	allBuckets, err := buckets.GetAllBuckets(db)
+	if err != nil {
+		return Analysis{}, err
+	}
+
+	return Analyse(Collect(all, cal), allBuckets, cal, start, end), nil
+}
+
+// Suggestion is a longer duration for a task, from the time similar work typically took.
+type Suggestion struct {
+	Pattern        string
+	Samples        int
+	TypicalSeconds int64 // median time similar work took.
+}
+
+// Suggest proposes a duration when work matching taskName typically took at least SuggestRatio
+// times durationSeconds.
+func Suggest(work []Work, taskName string, durationSeconds int64) (Suggestion, bool) {
+	pattern := Pattern(taskName)
+	if pattern == "" || durationSeconds <= 0 {
+		return Suggestion{}, false
+	}
+
+	var similar []Work
+	for _, w := range work {
+		if w.Pattern == pattern {
+			similar = append(similar, w)
+		}
+	}
+	if len(similar) < MinSamples {
+		return Suggestion{}, false
+	}
+
+	typical := measure(similar).MedianActualSeconds
+	if float64(typical) < float64(durationSeconds)*SuggestRatio {
+		return Suggestion{}, false
+	}
+
+	return Suggestion{Pattern: pattern, Samples: len(similar), TypicalSeconds: typical}, true
+}
+
+// SuggestFor looks for similar work over the SuggestWindowDays before today.
+func SuggestFor(ctx context.Context, db *sqlx.DB, cal calendar.Calendar, taskName string, durationSeconds int64, now time.Time) (Suggestion, bool, error) {
+	if Pattern(taskName) == "" {
+		return Suggestion{}, false, nil
+	}
+
+	today := cal.Today(now)
+	query := tasks.NewQuery().Between(cal.Start(cal.AddDays(today, -SuggestWindowDays)), cal.Start(today))
+	all, err := tasks.NewStore(db).Select(ctx, query)
+	if err != nil {
+		return Suggestion{}, false, err
+	}
+
+	s, ok := Suggest(Collect(all, cal), taskName, durationSeconds)
+	return s, ok, nil
+}
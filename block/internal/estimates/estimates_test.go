@@ -0,0 +1,107 @@
+package estimates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/connorkuljis/block-cli/internal/tasks/taskstest"
+)
+
+func day(d int, hour int) time.Time {
+	return time.Date(2024, 3, d, hour, 0, 0, 0, time.UTC)
+}
+
+func TestPattern(t *testing.T) {
+	tests := map[string]string{
+		"Review PR #42":        "review pr",
+		"review pr 57":         "review pr",
+		"standup 2024-03-04":   "standup",
+		"  Write  the report ": "write the report",
+		"v2 migration":         "migration",
+		"1234":                 "",
+	}
+
+	for name, want := range tests {
+		if got := Pattern(name); got != want {
+			t.Errorf("Expected %q for %q, got: %q", want, name, got)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	cal, _ := calendar.New(time.UTC, 0)
+
+	all := []tasks.Task{
+		taskstest.Finished(day(4, 9), 25, taskstest.Named("Review PR #42"), taskstest.Estimated(25), taskstest.InBucket(1)),
+		taskstest.Finished(day(4, 14), 20, taskstest.Named("review pr 43"), taskstest.Estimated(25)), // a follow-up session the same day.
+		taskstest.Finished(day(5, 9), 10, taskstest.Named("review pr"), taskstest.Estimated(25), taskstest.InBucket(1)),
+		taskstest.Finished(day(4, 10), 30, taskstest.Named(""), taskstest.Estimated(30)),
+		taskstest.Finished(day(4, 11), 15, taskstest.Named(""), taskstest.Estimated(30)),
+		taskstest.Finished(day(4, 12), 0, taskstest.Named("email"), taskstest.Estimated(10), taskstest.Abandoned),
+	}
+
+	work := Collect(all, cal)
+	if len(work) != 4 {
+		t.Fatalf("Expected 4 pieces of work, got: %+v", work)
+	}
+
+	first := work[0]
+	if first.Pattern != "review pr" || first.Sessions != 2 || first.EstimatedSeconds != 25*60 || first.ActualSeconds != 45*60 || first.BucketId != 1 {
+		t.Errorf("Expected the 4th's reviews as one piece of 45m against 25m, got: %+v", first)
+	}
+	if work[1].Pattern != "" || work[2].Pattern != "" || work[1].ActualSeconds == work[2].ActualSeconds {
+		t.Errorf("Expected unnamed sessions kept apart, got: %+v %+v", work[1], work[2])
+	}
+}
+
+func TestAnalyse(t *testing.T) {
+	cal, _ := calendar.New(time.UTC, 0)
+	start, _ := cal.WeekBounds(day(4, 0))
+	end := start.AddDate(0, 0, 14)
+
+	work := []Work{
+		{Date: day(4, 0), Pattern: "review pr", BucketId: 1, Sessions: 2, EstimatedSeconds: 1500, ActualSeconds: 3000},
+		{Date: day(5, 0), Pattern: "review pr", BucketId: 1, Sessions: 1, EstimatedSeconds: 1500, ActualSeconds: 1500},
+		{Date: day(12, 0), Pattern: "review pr", BucketId: 1, Sessions: 1, EstimatedSeconds: 1500, ActualSeconds: 600},
+		{Date: day(12, 0), Pattern: "email", Sessions: 1, EstimatedSeconds: 600, ActualSeconds: 600},
+	}
+
+	a := Analyse(work, []buckets.Bucket{{BucketId: 1, BucketName: "work"}}, cal, start, end)
+
+	if a.Overall.Count != 4 || a.Overall.Overran != 1 || a.Overall.Underran != 1 {
+		t.Errorf("Expected 4 pieces of work, one overran and one underran, got: %+v", a.Overall)
+	}
+	if len(a.Buckets) != 2 || a.Buckets[0].BucketName != "work" || a.Buckets[0].Ratio() != 5100.0/4500 {
+		t.Errorf("Expected work first with a ratio of 5100/4500, got: %+v", a.Buckets)
+	}
+	if len(a.Patterns) != 1 || a.Patterns[0].Pattern != "review pr" || a.Patterns[0].MedianActualSeconds != 1500 {
+		t.Errorf("Expected only review pr listed with a typical 25m, got: %+v", a.Patterns)
+	}
+	if len(a.Weeks) != 2 || a.Weeks[0].Count != 2 || a.Weeks[1].Count != 2 {
+		t.Errorf("Expected two weeks of two pieces of work, got: %+v", a.Weeks)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	work := []Work{
+		{Pattern: "write report", EstimatedSeconds: 1500, ActualSeconds: 3600},
+		{Pattern: "write report", EstimatedSeconds: 1500, ActualSeconds: 4500},
+		{Pattern: "write report", EstimatedSeconds: 1500, ActualSeconds: 5400},
+		{Pattern: "email", EstimatedSeconds: 600, ActualSeconds: 3600},
+	}
+
+	s, ok := Suggest(work, "Write report #3", 25*60)
+	if !ok || s.TypicalSeconds != 4500 || s.Samples != 3 {
+		t.Errorf("Expected a suggestion of 75m from 3 samples, got: %+v %v", s, ok)
+	}
+
+	if _, ok := Suggest(work, "write report", 60*60); ok {
+		t.Error("Expected no suggestion when the duration is close to the typical time")
+	}
+	if _, ok := Suggest(work, "email", 5*60); ok {
+		t.Error("Expected no suggestion with too few samples")
+	}
+}
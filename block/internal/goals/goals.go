@@ -0,0 +1,194 @@
+// Package goals measures the day's focus time against the daily goal, and records each day's
+// result so streaks are judged against the goal that applied on the day.
+package goals
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/config"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/jmoiron/sqlx"
+)
+
+// Goals is the focus time to aim for each day, overall and for named buckets.
+type Goals struct {
+	Daily   time.Duration
+	Buckets map[string]time.Duration
+}
+
+func NewFromConfig(cfg config.GoalsConfig) (Goals, error) {
+	if cfg.DailyMinutes < 0 {
+		return Goals{}, fmt.Errorf("Error, daily goal must not be negative, got %d minutes", cfg.DailyMinutes)
+	}
+
+	g := Goals{Daily: time.Duration(cfg.DailyMinutes) * time.Minute, Buckets: make(map[string]time.Duration)}
+	for name, minutes := range cfg.Buckets {
+		if minutes <= 0 {
+			return Goals{}, fmt.Errorf("Error, daily goal for bucket '%s' must be positive, got %d minutes", name, minutes)
+		}
+		g.Buckets[name] = time.Duration(minutes) * time.Minute
+	}
+
+	return g, nil
+}
+
+// Target is focus time measured against a goal.
+type Target struct {
+	GoalSeconds  int64
+	FocusSeconds int64
+}
+
+func (t Target) RemainingSeconds() int64 {
+	return max(t.GoalSeconds-t.FocusSeconds, 0)
+}
+
+// Met reports whether the goal was reached. A goal of zero is never met.
+func (t Target) Met() bool {
+	return t.GoalSeconds > 0 && t.FocusSeconds >= t.GoalSeconds
+}
+
+// Percent is the share of the goal reached, capped at 100.
+func (t Target) Percent() float64 {
+	if t.GoalSeconds <= 0 {
+		return 0
+	}
+	return min(float64(t.FocusSeconds)/float64(t.GoalSeconds)*100, 100)
+}
+
+type BucketProgress struct {
+	BucketName string
+	Target
+}
+
+// Progress is a day's focus time against the daily goal.
+type Progress struct {
+	Date     time.Time // the day, as a calendar date.
+	Sessions int
+	Target
+	Buckets []BucketProgress // buckets with a goal of their own, by name.
+}
+
+// Compute measures the tasks of the day labelled date against g.
+func Compute(dayTasks []tasks.Task, allBuckets []buckets.Bucket, g Goals, date time.Time) Progress {
+	p := Progress{Date: date, Target: Target{GoalSeconds: int64(g.Daily.Seconds())}}
+
+	focusByBucket := make(map[int64]int64)
+	for _, task := range dayTasks {
+		if !task.FinishedAt.Valid && task.ActualDurationSeconds.Int64 == 0 {
+			continue
+		}
+		p.Sessions++
+		p.FocusSeconds += task.ActualDurationSeconds.Int64
+		if task.BucketId.Valid {
+			focusByBucket[task.BucketId.Int64] += task.ActualDurationSeconds.Int64
+		}
+	}
+
+	// goals for buckets that do not exist yet show as untouched. Bucket names ignore case, as everywhere else.
+	for name, goal := range g.Buckets {
+		bp := BucketProgress{BucketName: name, Target: Target{GoalSeconds: int64(goal.Seconds())}}
+		for _, bucket := range allBuckets {
+			if strings.EqualFold(bucket.BucketName, name) {
+				bp.FocusSeconds = focusByBucket[bucket.BucketId]
+			}
+		}
+		p.Buckets = append(p.Buckets, bp)
+	}
+	sort.Slice(p.Buckets, func(i, j int) bool { return p.Buckets[i].BucketName < p.Buckets[j].BucketName })
+
+	return p
+}
+
+// ForDay measures the day containing t against g.
+func ForDay(ctx context.Context, db *sqlx.DB, cal calendar.Calendar, g Goals, t time.Time) (Progress, error) {
+	dayTasks, err := tasks.NewStore(db).Select(ctx, tasks.NewQuery().OnDay(cal, t))
+	if err != nil {
+		return Progress{}, err
+	}
+
+	allBuckets, err := buckets.GetAllBuckets(db)
+	if err != nil {
+		return Progress{}, err
+	}
+
+	return Compute(dayTasks, allBuckets, g, cal.Date(t)), nil
+}
+
+// Update measures the day containing t and records the result. A day recorded before keeps
+// the goal it was first recorded with, and the progress returned is measured against it.
+func Update(ctx context.Context, db *sqlx.DB, cal calendar.Calendar, g Goals, t, now time.Time) (Progress, error) {
+	p, err := ForDay(ctx, db, cal, g, t)
+	if err != nil {
+		return p, err
+	}
+
+	return Record(ctx, db, p, now)
+}
+
+// UpdateDays re-records the days holding changed, such as after they are deleted, restored or
+// edited, so each day's recorded focus time and result stay current.
+func UpdateDays(ctx context.Context, db *sqlx.DB, cal calendar.Calendar, g Goals, changed []tasks.Task, now time.Time) error {
+	seen := make(map[time.Time]bool)
+	for _, task := range changed {
+		date := cal.Date(task.CreatedAt)
+		if seen[date] {
+			continue
+		}
+		seen[date] = true
+
+		if _, err := Update(ctx, db, cal, g, task.CreatedAt, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Record stores the day's focus time and whether it met the goal. The first record of a day
+// stores p's goal, later records keep it, so changing the configured goal does not rewrite days
+// already recorded. It returns p with the goal kept.
+func Record(ctx context.Context, db sqlx.QueryerContext, p Progress, now time.Time) (Progress, error) {
+	q := `INSERT INTO DailyGoals (day, goal_seconds, focus_seconds, met, updated_at) VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT (day) DO UPDATE SET
+	focus_seconds = excluded.focus_seconds,
+	met = DailyGoals.goal_seconds > 0 AND excluded.focus_seconds >= DailyGoals.goal_seconds,
+	updated_at = excluded.updated_at
+	RETURNING goal_seconds`
+
+	err := sqlx.GetContext(ctx, db, &p.GoalSeconds, q, p.Date.Format("2006-01-02"), p.GoalSeconds, p.FocusSeconds, p.Met(), now)
+	if err != nil {
+		return p, fmt.Errorf("Error recording daily goal: %w", err)
+	}
+
+	return p, nil
+}
+
+// Recorded returns the goal in seconds recorded for each day, keyed by calendar date.
+func Recorded(ctx context.Context, db sqlx.QueryerContext, cal calendar.Calendar) (map[time.Time]int64, error) {
+	var rows []struct {
+		Day         string `db:"day"`
+		GoalSeconds int64  `db:"goal_seconds"`
+	}
+
+	err := sqlx.SelectContext(ctx, db, &rows, "SELECT day, goal_seconds FROM DailyGoals")
+	if err != nil {
+		return nil, fmt.Errorf("Error loading daily goals: %w", err)
+	}
+
+	recorded := make(map[time.Time]int64, len(rows))
+	for _, row := range rows {
+		date, err := cal.ParseDate(row.Day)
+		if err != nil {
+			return nil, err
+		}
+		recorded[date] = row.GoalSeconds
+	}
+
+	return recorded, nil
+}
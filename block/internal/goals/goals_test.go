@@ -0,0 +1,154 @@
+package goals
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/config"
+	"github.com/connorkuljis/block-cli/internal/db/dbtest"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/connorkuljis/block-cli/internal/tasks/taskstest"
+)
+
+func TestCompute(t *testing.T) {
+	g, err := NewFromConfig(config.GoalsConfig{DailyMinutes: 240, Buckets: map[string]int{"work": 120, "study": 60, "gym": 30}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	dayTasks := []tasks.Task{
+		taskstest.Finished(date, 90, taskstest.InBucket(1)),
+		taskstest.Finished(date, 60, taskstest.InBucket(1)),
+		taskstest.Finished(date, 30, taskstest.InBucket(2)),
+		taskstest.Finished(date, 15),
+	}
+	// the study goal counts the Study bucket, as bucket names ignore case.
+	allBuckets := []buckets.Bucket{{BucketId: 1, BucketName: "work"}, {BucketId: 2, BucketName: "Study"}}
+
+	p := Compute(dayTasks, allBuckets, g, date)
+
+	if p.Sessions != 4 || p.FocusSeconds != 195*60 {
+		t.Errorf("Expected 4 sessions and 3h15m, got: %d sessions, %d seconds", p.Sessions, p.FocusSeconds)
+	}
+	if p.Met() || p.RemainingSeconds() != 45*60 || p.Percent() != 81.25 {
+		t.Errorf("Expected 45m to go at 81.25%%, got: met %v, %d remaining, %v%%", p.Met(), p.RemainingSeconds(), p.Percent())
+	}
+
+	want := map[string]struct {
+		focus int64
+		met   bool
+	}{
+		"gym":   {focus: 0, met: false},
+		"study": {focus: 30 * 60, met: false},
+		"work":  {focus: 150 * 60, met: true},
+	}
+	if len(p.Buckets) != len(want) {
+		t.Fatalf("Expected %d bucket goals, got: %+v", len(want), p.Buckets)
+	}
+	for _, b := range p.Buckets {
+		if w := want[b.BucketName]; b.FocusSeconds != w.focus || b.Met() != w.met {
+			t.Errorf("Expected %s: %d seconds met %v, got: %d met %v", b.BucketName, w.focus, w.met, b.FocusSeconds, b.Met())
+		}
+	}
+	if p.Buckets[2].Percent() != 100 {
+		t.Errorf("Expected percent capped at 100, got: %v", p.Buckets[2].Percent())
+	}
+
+	if _, err := NewFromConfig(config.GoalsConfig{DailyMinutes: -1}); err == nil {
+		t.Error("Expected error for a negative goal, got nil")
+	}
+}
+
+func TestRecord(t *testing.T) {
+	ctx := context.Background()
+	conn := dbtest.Open(t)
+
+	cal, _ := calendar.New(time.UTC, 0)
+	date := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	now := date.Add(10 * time.Hour)
+
+	// recording the same day twice keeps the latest result.
+	for _, focus := range []int64{1800, 7200} {
+		p := Progress{Date: date, Target: Target{GoalSeconds: 3600, FocusSeconds: focus}}
+		if _, err := Record(ctx, conn, p, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var met bool
+	if err := conn.Get(&met, "SELECT met FROM DailyGoals WHERE day = '2024-03-04'"); err != nil {
+		t.Fatal(err)
+	}
+	if !met {
+		t.Error("Expected the day to be recorded as met")
+	}
+
+	recorded, err := Recorded(ctx, conn, cal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded) != 1 || recorded[cal.Date(now)] != 3600 {
+		t.Errorf("Expected a one hour goal on the 4th, got: %v", recorded)
+	}
+}
+
+func TestUpdateKeepsRecordedGoal(t *testing.T) {
+	ctx := context.Background()
+	conn := dbtest.Open(t)
+	store := tasks.NewStore(conn)
+
+	cal, _ := calendar.New(time.UTC, 0)
+	nine := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	now := nine.AddDate(0, 0, 2)
+
+	if err := store.Log(ctx, tasks.NewLoggedTask("write report", 45*time.Minute, nine)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Update(ctx, conn, cal, Goals{Daily: time.Hour}, nine, now); err != nil {
+		t.Fatal(err)
+	}
+
+	// the goal is raised to two hours, then more time is logged on the 4th.
+	email := tasks.NewLoggedTask("email", 30*time.Minute, nine.Add(time.Hour))
+	if err := store.Log(ctx, email); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Update(ctx, conn, cal, Goals{Daily: 2 * time.Hour}, email.CreatedAt, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.GoalSeconds != 3600 || !p.Met() {
+		t.Errorf("Expected 75m to meet the one hour goal of the 4th, got: %d of %d", p.FocusSeconds, p.GoalSeconds)
+	}
+
+	var row struct {
+		GoalSeconds  int64 `db:"goal_seconds"`
+		FocusSeconds int64 `db:"focus_seconds"`
+		Met          bool  `db:"met"`
+	}
+	if err := conn.Get(&row, "SELECT goal_seconds, focus_seconds, met FROM DailyGoals WHERE day = '2024-03-04'"); err != nil {
+		t.Fatal(err)
+	}
+	if row.GoalSeconds != 3600 || row.FocusSeconds != 75*60 || !row.Met {
+		t.Errorf("Expected the one hour goal kept and met, got: %+v", row)
+	}
+
+	// deleting the email takes the day back under its goal.
+	deleted, err := store.Delete(ctx, []int64{email.TaskId}, tasks.SourceCLI, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateDays(ctx, conn, cal, Goals{Daily: 2 * time.Hour}, deleted, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Get(&row, "SELECT goal_seconds, focus_seconds, met FROM DailyGoals WHERE day = '2024-03-04'"); err != nil {
+		t.Fatal(err)
+	}
+	if row.GoalSeconds != 3600 || row.FocusSeconds != 45*60 || row.Met {
+		t.Errorf("Expected 45m against the one hour goal after the delete, got: %+v", row)
+	}
+}
@@ -0,0 +1,100 @@
+// Package ical writes iCalendar (RFC 5545) files.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	ProductId = "-//block-cli//focus sessions//EN"
+
+	timestampFormat = "20060102T150405Z"
+	maxLineOctets   = 75
+)
+
+// Event is a single VEVENT. Times are written in utc.
+type Event struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Categories  []string
+	Created     time.Time
+}
+
+// Write writes a VCALENDAR containing events to w.
+func Write(w io.Writer, name string, events []Event) error {
+	bw := bufio.NewWriter(w)
+
+	writeLine(bw, "BEGIN:VCALENDAR")
+	writeLine(bw, "VERSION:2.0")
+	writeLine(bw, "PRODID:"+ProductId)
+	writeLine(bw, "CALSCALE:GREGORIAN")
+	if name != "" {
+		writeLine(bw, "X-WR-CALNAME:"+escape(name))
+	}
+
+	stamp := time.Now().UTC().Format(timestampFormat)
+	for _, event := range events {
+		writeLine(bw, "BEGIN:VEVENT")
+		writeLine(bw, "UID:"+escape(event.UID))
+		writeLine(bw, "DTSTAMP:"+stamp)
+		if !event.Created.IsZero() {
+			writeLine(bw, "CREATED:"+event.Created.UTC().Format(timestampFormat))
+		}
+		writeLine(bw, "DTSTART:"+event.Start.UTC().Format(timestampFormat))
+		writeLine(bw, "DTEND:"+event.End.UTC().Format(timestampFormat))
+		writeLine(bw, "SUMMARY:"+escape(event.Summary))
+		if event.Description != "" {
+			writeLine(bw, "DESCRIPTION:"+escape(event.Description))
+		}
+		if len(event.Categories) > 0 {
+			categories := make([]string, len(event.Categories))
+			for i, category := range event.Categories {
+				categories[i] = escape(category)
+			}
+			writeLine(bw, "CATEGORIES:"+strings.Join(categories, ","))
+		}
+		writeLine(bw, "END:VEVENT")
+	}
+
+	writeLine(bw, "END:VCALENDAR")
+
+	return bw.Flush()
+}
+
+// escape escapes text values as required by RFC 5545 section 3.3.11.
+func escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// writeLine writes a content line terminated by CRLF, folding it at 75 octets without splitting utf-8 sequences.
+func writeLine(w *bufio.Writer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		fmt.Fprint(w, line[:cut], "\r\n ")
+		line = line[cut:]
+		// continuation lines start with a space, which counts towards the limit.
+		limit = maxLineOctets - 1
+	}
+	fmt.Fprint(w, line, "\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
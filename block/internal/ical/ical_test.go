@@ -0,0 +1,50 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.FixedZone("AEDT", 11*60*60))
+	event := Event{
+		UID:         "abc@block-cli",
+		Start:       start,
+		End:         start.Add(25 * time.Minute),
+		Summary:     "review; fix, ship",
+		Description: strings.Repeat("é", 60) + "\nsecond line",
+		Categories:  []string{"work"},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, "block", []Event{event}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"DTSTART:20240303T220000Z\r\n",
+		"DTEND:20240303T222500Z\r\n",
+		`SUMMARY:review\; fix\, ship` + "\r\n",
+		"CATEGORIES:work\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q", want)
+		}
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("Expected lines of at most %d octets, got %d: %q", maxLineOctets, len(line), line)
+		}
+	}
+
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+	if !strings.Contains(unfolded, "DESCRIPTION:"+strings.Repeat("é", 60)+`\nsecond line`) {
+		t.Error("Expected folded description to unfold to the original text")
+	}
+}
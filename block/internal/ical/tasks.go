@@ -0,0 +1,83 @@
+package ical
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/connorkuljis/block-cli/internal/utils"
+	"github.com/jmoiron/sqlx"
+)
+
+// TaskEvent maps a task to an event from its creation to when it finished.
+// Unfinished tasks run for their actual duration so far, or their planned duration if none was recorded.
+func TaskEvent(task tasks.Task, bucketName string) Event {
+	end := task.CreatedAt.Add(secondsDuration(task.EstimatedDurationSeconds))
+	if task.ActualDurationSeconds.Valid {
+		end = task.CreatedAt.Add(secondsDuration(task.ActualDurationSeconds.Int64))
+	}
+	if task.FinishedAt.Valid && task.FinishedAt.Time.After(task.CreatedAt) {
+		end = task.FinishedAt.Time
+	}
+
+	summary := task.TaskName
+	if summary == "" {
+		summary = "Focus session"
+	}
+
+	var description []string
+	if bucketName != "" {
+		description = append(description, "Bucket: "+bucketName)
+	}
+	description = append(description,
+		"Planned: "+utils.SecsToHHMMSS(task.EstimatedDurationSeconds),
+		"Actual: "+utils.SecsToHHMMSS(task.ActualDurationSeconds.Int64),
+		fmt.Sprintf("Completion: %.0f%%", task.CompletionPercent.Float64),
+	)
+
+	event := Event{
+		UID:         fmt.Sprintf("%s@block-cli", task.TaskUUID),
+		Start:       task.CreatedAt,
+		End:         end,
+		Summary:     summary,
+		Description: strings.Join(description, "\n"),
+		Created:     task.CreatedAt,
+	}
+	if bucketName != "" {
+		event.Categories = []string{bucketName}
+	}
+
+	return event
+}
+
+func secondsDuration(seconds int64) time.Duration {
+	return time.Duration(seconds) * time.Second
+}
+
+// TaskEvents runs query and maps every matching task to an event, naming each task's bucket.
+func TaskEvents(ctx context.Context, db *sqlx.DB, query *tasks.Query) ([]Event, error) {
+	all, err := tasks.NewStore(db).Select(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	allBuckets, err := buckets.GetAllBuckets(db)
+	if err != nil {
+		return nil, err
+	}
+
+	bucketNames := make(map[int64]string)
+	for _, b := range allBuckets {
+		bucketNames[b.BucketId] = b.BucketName
+	}
+
+	events := make([]Event, 0, len(all))
+	for _, task := range all {
+		events = append(events, TaskEvent(task, bucketNames[task.BucketId.Int64]))
+	}
+
+	return events, nil
+}
@@ -0,0 +1,143 @@
+package idle
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider reports how long the user has been idle (no keyboard or mouse input).
+type Provider interface {
+	IdleTime() (time.Duration, error)
+}
+
+// NewProvider returns the named provider. "auto" picks one based on the platform and display server.
+func NewProvider(name string) (Provider, error) {
+	switch name {
+	case "", "auto":
+		return detectProvider()
+	case "x11":
+		return X11Provider{}, nil
+	case "wayland":
+		return WaylandProvider{}, nil
+	case "darwin":
+		return DarwinProvider{}, nil
+	default:
+		return nil, fmt.Errorf("Error, unknown idle provider '%s'", name)
+	}
+}
+
+func detectProvider() (Provider, error) {
+	switch {
+	case runtime.GOOS == "darwin":
+		return DarwinProvider{}, nil
+	case os.Getenv("WAYLAND_DISPLAY") != "":
+		return WaylandProvider{}, nil
+	case os.Getenv("DISPLAY") != "":
+		return X11Provider{}, nil
+	default:
+		return nil, errors.New("Error, could not detect an idle provider for this session")
+	}
+}
+
+// X11Provider reads the X11 screensaver idle time using `xprintidle`.
+type X11Provider struct{}
+
+func (p X11Provider) IdleTime() (time.Duration, error) {
+	out, err := exec.Command("xprintidle").Output()
+	if err != nil {
+		return 0, fmt.Errorf("Error running xprintidle: %w", err)
+	}
+
+	ms, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Error parsing xprintidle output: %w", err)
+	}
+
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+var gdbusUint64 = regexp.MustCompile(`uint64 (\d+)`)
+
+// WaylandProvider queries the Mutter idle monitor over D-Bus using `gdbus`.
+// This covers GNOME, the most common Wayland compositor.
+type WaylandProvider struct{}
+
+func (p WaylandProvider) IdleTime() (time.Duration, error) {
+	out, err := exec.Command("gdbus", "call", "--session",
+		"--dest", "org.gnome.Mutter.IdleMonitor",
+		"--object-path", "/org/gnome/Mutter/IdleMonitor/Core",
+		"--method", "org.gnome.Mutter.IdleMonitor.GetIdletime",
+	).Output()
+	if err != nil {
+		return 0, fmt.Errorf("Error querying idle monitor: %w", err)
+	}
+
+	match := gdbusUint64.FindSubmatch(out)
+	if match == nil {
+		return 0, fmt.Errorf("Error parsing idle monitor output: %q", out)
+	}
+
+	ms, err := strconv.ParseInt(string(match[1]), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+var hidIdleTime = regexp.MustCompile(`"HIDIdleTime" = (\d+)`)
+
+// DarwinProvider reads HIDIdleTime from the IOHIDSystem registry using `ioreg`.
+type DarwinProvider struct{}
+
+func (p DarwinProvider) IdleTime() (time.Duration, error) {
+	out, err := exec.Command("ioreg", "-c", "IOHIDSystem", "-d", "4").Output()
+	if err != nil {
+		return 0, fmt.Errorf("Error running ioreg: %w", err)
+	}
+
+	match := hidIdleTime.FindSubmatch(out)
+	if match == nil {
+		return 0, errors.New("Error, HIDIdleTime not found in ioreg output")
+	}
+
+	ns, err := strconv.ParseInt(string(match[1]), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(ns), nil
+}
+
+// FakeProvider returns a fixed idle time, for tests.
+type FakeProvider struct {
+	mu   sync.Mutex
+	idle time.Duration
+	err  error
+}
+
+func (p *FakeProvider) Set(idle time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.idle = idle
+}
+
+func (p *FakeProvider) SetErr(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+func (p *FakeProvider) IdleTime() (time.Duration, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.idle, p.err
+}
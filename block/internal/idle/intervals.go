@@ -0,0 +1,50 @@
+package idle
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Interval is a period during a task where the user was away from the computer.
+// Kept records whether the user chose to count the time towards the task.
+type Interval struct {
+	IdleIntervalId int64     `db:"idle_interval_id"`
+	TaskId         int64     `db:"task_id"`
+	StartedAt      time.Time `db:"started_at"`
+	EndedAt        time.Time `db:"ended_at"`
+	Kept           int       `db:"kept"`
+}
+
+func (i Interval) Duration() time.Duration {
+	return i.EndedAt.Sub(i.StartedAt)
+}
+
+func InsertInterval(db sqlx.Ext, interval *Interval) error {
+	query := `INSERT INTO IdleIntervals (task_id, started_at, ended_at, kept) VALUES (:task_id, :started_at, :ended_at, :kept)`
+
+	result, err := sqlx.NamedExec(db, query, interval)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	interval.IdleIntervalId = id
+
+	return nil
+}
+
+func GetIntervalsByTaskId(db *sqlx.DB, taskId int64) ([]Interval, error) {
+	var intervals []Interval
+
+	err := db.Select(&intervals, "SELECT * FROM IdleIntervals WHERE task_id = ? ORDER BY started_at ASC", taskId)
+	if err != nil {
+		return intervals, err
+	}
+
+	return intervals, nil
+}
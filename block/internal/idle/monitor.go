@@ -0,0 +1,59 @@
+package idle
+
+import (
+	"time"
+)
+
+type TransitionKind int
+
+const (
+	None TransitionKind = iota
+	// Away is reported once when the idle time crosses the threshold.
+	Away
+	// Returned is reported once when input resumes after being Away.
+	Returned
+)
+
+// Transition describes a change in the user's presence.
+// Since is when the user went idle; Until is only set for Returned.
+type Transition struct {
+	Kind  TransitionKind
+	Since time.Time
+	Until time.Time
+}
+
+// Monitor turns idle time samples from a Provider into Away/Returned transitions.
+type Monitor struct {
+	Provider  Provider
+	Threshold time.Duration
+
+	away  bool
+	since time.Time
+}
+
+func NewMonitor(provider Provider, threshold time.Duration) *Monitor {
+	return &Monitor{
+		Provider:  provider,
+		Threshold: threshold,
+	}
+}
+
+// Check samples the provider at time now and reports a transition if the user's presence changed.
+func (m *Monitor) Check(now time.Time) (Transition, error) {
+	idleTime, err := m.Provider.IdleTime()
+	if err != nil {
+		return Transition{}, err
+	}
+
+	switch {
+	case !m.away && idleTime >= m.Threshold:
+		m.away = true
+		m.since = now.Add(-idleTime)
+		return Transition{Kind: Away, Since: m.since}, nil
+	case m.away && idleTime < m.Threshold:
+		m.away = false
+		return Transition{Kind: Returned, Since: m.since, Until: now.Add(-idleTime)}, nil
+	}
+
+	return Transition{Kind: None}, nil
+}
@@ -0,0 +1,62 @@
+package idle
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMonitorTransitions(t *testing.T) {
+	provider := &FakeProvider{}
+	monitor := NewMonitor(provider, 5*time.Minute)
+
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		idle      time.Duration
+		at        time.Time
+		wantKind  TransitionKind
+		wantSince time.Time
+		wantUntil time.Time
+	}{
+		{name: "Active", idle: 10 * time.Second, at: now, wantKind: None},
+		{name: "Below threshold", idle: 4 * time.Minute, at: now.Add(4 * time.Minute), wantKind: None},
+		{name: "Crosses threshold", idle: 6 * time.Minute, at: now.Add(6 * time.Minute), wantKind: Away, wantSince: now},
+		{name: "Still away", idle: 20 * time.Minute, at: now.Add(20 * time.Minute), wantKind: None},
+		{name: "Returns", idle: 2 * time.Second, at: now.Add(30 * time.Minute), wantKind: Returned, wantSince: now, wantUntil: now.Add(30*time.Minute - 2*time.Second)},
+		{name: "Active again", idle: 1 * time.Second, at: now.Add(31 * time.Minute), wantKind: None},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider.Set(tc.idle)
+
+			got, err := monitor.Check(tc.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Kind != tc.wantKind {
+				t.Fatalf("Expected kind: %v, got: %v", tc.wantKind, got.Kind)
+			}
+			if !got.Since.Equal(tc.wantSince) {
+				t.Errorf("Expected since: %v, got: %v", tc.wantSince, got.Since)
+			}
+			if !got.Until.Equal(tc.wantUntil) {
+				t.Errorf("Expected until: %v, got: %v", tc.wantUntil, got.Until)
+			}
+		})
+	}
+}
+
+func TestMonitorProviderError(t *testing.T) {
+	provider := &FakeProvider{}
+	provider.SetErr(errors.New("no display"))
+
+	monitor := NewMonitor(provider, time.Minute)
+
+	if _, err := monitor.Check(time.Now()); err == nil {
+		t.Error("Expected error from provider, got nil")
+	}
+}
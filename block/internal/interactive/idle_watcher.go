@@ -0,0 +1,89 @@
+package interactive
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/idle"
+)
+
+const idlePollInterval = 5 * time.Second
+
+// WatchIdle polls the idle monitor and forwards presence changes to PollInput.
+func WatchIdle(remote *Remote) {
+	defer remote.Wg.Done()
+
+	ticker := time.NewTicker(idlePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-remote.Finish:
+			return
+		case <-remote.Cancel:
+			return
+		case now := <-ticker.C:
+			transition, err := remote.Monitor.Check(now)
+			if err != nil {
+				log.Printf("Error checking idle time, disabling idle detection: %v", err)
+				return
+			}
+
+			if transition.Kind == idle.None {
+				continue
+			}
+
+			select {
+			case remote.Idle <- transition:
+			case <-remote.Finish:
+				return
+			case <-remote.Cancel:
+				return
+			}
+		}
+	}
+}
+
+// idleState tracks an automatic pause from going idle until the user decides what to do with the time.
+type idleState struct {
+	since    time.Time // when the user went idle.
+	pausedAt time.Time // when the session was auto-paused.
+	until    time.Time // when the user came back, zero while still away.
+}
+
+func (s *idleState) returned() bool {
+	return !s.until.IsZero()
+}
+
+func (s *idleState) markReturned(w io.Writer, until time.Time) {
+	s.until = until
+	fmt.Fprintf(w, "\nWelcome back, you were away for %s. Keep the idle time? [k]eep / [d]iscard\n", s.until.Sub(s.since).Round(time.Second))
+}
+
+// resolve reports how many seconds the elapsed time needs adjusting by and records the interval.
+//
+// While idle the timer kept counting up to the auto-pause, then stopped. Keeping the idle time adds the paused
+// portion, discarding it removes the portion that was counted before the pause.
+func (s *idleState) resolve(remote *Remote, keep bool) int {
+	interval := idle.Interval{
+		TaskId:    remote.Task.TaskId,
+		StartedAt: s.since,
+		EndedAt:   s.until,
+	}
+
+	var delta time.Duration
+	if keep {
+		interval.Kept = 1
+		delta = s.until.Sub(s.pausedAt)
+	} else {
+		delta = -s.pausedAt.Sub(s.since)
+	}
+
+	if err := idle.InsertInterval(remote.Db, &interval); err != nil {
+		log.Printf("Error recording idle interval: %v", err)
+	}
+
+	return int(delta.Round(time.Second).Seconds())
+}
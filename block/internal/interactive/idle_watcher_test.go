@@ -0,0 +1,55 @@
+package interactive
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/blocker"
+	"github.com/connorkuljis/block-cli/internal/db/dbtest"
+	"github.com/connorkuljis/block-cli/internal/idle"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+)
+
+func TestCancelWhileIdleDiscardsIdleTime(t *testing.T) {
+	conn := dbtest.Open(t)
+
+	task := tasks.NewTask("write report", 3600, false, false, time.Now())
+	remote := NewRemote(io.Discard, task, blocker.NewBlocker(), conn)
+
+	// the user went idle six minutes ago, and the five minute threshold auto-paused the session.
+	provider := &idle.FakeProvider{}
+	monitor := idle.NewMonitor(provider, 5*time.Minute)
+	now := time.Now()
+	provider.Set(6 * time.Minute)
+	transition, err := monitor.Check(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transition.Kind != idle.Away {
+		t.Fatalf("Expected the fake provider to report away, got: %v", transition.Kind)
+	}
+	away := &idleState{since: transition.Since, pausedAt: now.Add(-time.Minute)}
+
+	remote.Wg.Add(1)
+	go RenderProgressBar(remote)
+
+	// ten minutes were counted before the auto-pause, including the five idle before it.
+	remote.Pause <- true
+	remote.Adjust <- 600
+
+	cancel(remote, away)
+	remote.Wg.Wait()
+
+	if total := <-remote.TotalTimeSeconds; total != 300 {
+		t.Errorf("Expected the idle time before the pause to be discarded, leaving 300 seconds, got: %d", total)
+	}
+
+	intervals, err := idle.GetIntervalsByTaskId(conn, task.TaskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(intervals) != 1 || intervals[0].Kept != 0 {
+		t.Errorf("Expected one discarded idle interval, got: %+v", intervals)
+	}
+}
@@ -1,11 +1,16 @@
 package interactive
 
 import (
+	"fmt"
 	"log"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/briandowns/spinner"
+	"github.com/connorkuljis/block-cli/internal/idle"
+	"github.com/connorkuljis/block-cli/internal/notes"
+	"github.com/connorkuljis/block-cli/internal/plugins"
 	"github.com/eiannone/keyboard"
 )
 
@@ -23,6 +28,8 @@ func PollInput(remote *Remote) {
 	}
 
 	paused := false
+	var away *idleState // set while auto-paused for idle.
+	var reason []rune   // interruption reason being typed, nil when not logging.
 	spinner := spinner.New(spinner.CharSets[40], 100*time.Millisecond)
 	spinner.Prefix = "Press any key to resume:"
 	for {
@@ -30,21 +37,69 @@ func PollInput(remote *Remote) {
 		case <-remote.Finish:
 			remote.Wg.Done()
 			return
+		case transition := <-remote.Idle:
+			switch {
+			case transition.Kind == idle.Away && !paused:
+				away = &idleState{since: transition.Since, pausedAt: time.Now()}
+				paused = true
+				fmt.Fprintln(remote.W, "\nIdle detected, pausing session.")
+				unpause(remote, spinner)
+			case transition.Kind == idle.Returned && away != nil && !away.returned():
+				spinner.Stop()
+				away.markReturned(remote.W, transition.Until)
+			}
 		case event := <-keysEvents:
 			if event.Err != nil {
 				panic(event.Err)
 			}
 
+			if reason != nil && event.Key != keyboard.KeyCtrlC {
+				reason = logInterruption(remote, reason, event)
+				continue
+			}
+
 			if event.Key == keyboard.KeyCtrlC || event.Key == keyboard.KeyEsc {
 				if paused {
 					spinner.Stop()
 					close(remote.Pause)
 				}
-				slog.Info("Cancelling.")
-				close(remote.Cancel)
+				cancel(remote, away)
 				remote.Wg.Done()
 				return
-			} else if event.Key == keyboard.KeySpace {
+			}
+
+			if away != nil {
+				// any input means the user is back, the next key decides what happens to the idle time.
+				if !away.returned() {
+					spinner.Stop()
+					away.markReturned(remote.W, time.Now())
+					continue
+				}
+
+				var keep bool
+				switch event.Rune {
+				case 'k', 'K':
+					keep = true
+				case 'd', 'D':
+					keep = false
+				default:
+					continue
+				}
+
+				remote.Adjust <- away.resolve(remote, keep)
+				away = nil
+				paused = false
+				pause(remote, spinner)
+				continue
+			}
+
+			if event.Rune == 'i' {
+				fmt.Fprint(remote.W, "\nInterruption reason ([enter] to save, [esc] to discard): ")
+				reason = []rune{}
+				continue
+			}
+
+			if event.Key == keyboard.KeySpace {
 				paused = !paused
 				if paused {
 					unpause(remote, spinner)
@@ -56,12 +111,66 @@ func PollInput(remote *Remote) {
 	}
 }
 
+// logInterruption handles a key typed while logging an interruption. It returns the reason typed so far,
+// or nil once the reason has been saved or discarded.
+func logInterruption(remote *Remote, reason []rune, event keyboard.KeyEvent) []rune {
+	switch event.Key {
+	case keyboard.KeyEsc:
+		fmt.Fprintln(remote.W, "\nInterruption discarded.")
+		return nil
+	case keyboard.KeyEnter:
+		text := strings.TrimSpace(string(reason))
+		if text == "" {
+			fmt.Fprintln(remote.W, "\nInterruption discarded.")
+			return nil
+		}
+		interruption := notes.Interruption{TaskId: remote.Task.TaskId, Reason: text, CreatedAt: time.Now()}
+		if err := notes.InsertInterruption(remote.Db, &interruption); err != nil {
+			log.Printf("Error recording interruption: %v", err)
+			return nil
+		}
+		fmt.Fprintln(remote.W, "\nInterruption logged.")
+		return nil
+	case keyboard.KeyBackspace, keyboard.KeyBackspace2:
+		if len(reason) > 0 {
+			reason = reason[:len(reason)-1]
+			fmt.Fprint(remote.W, "\b \b")
+		}
+		return reason
+	case keyboard.KeySpace:
+		fmt.Fprint(remote.W, " ")
+		return append(reason, ' ')
+	}
+
+	if event.Rune != 0 {
+		fmt.Fprint(remote.W, string(event.Rune))
+		reason = append(reason, event.Rune)
+	}
+
+	return reason
+}
+
+// cancel ends the session. Idle time still waiting on a decision is discarded, taking back the seconds
+// counted before the auto-pause.
+func cancel(remote *Remote, away *idleState) {
+	if away != nil {
+		if !away.returned() {
+			away.until = time.Now()
+		}
+		remote.Adjust <- away.resolve(remote, false)
+	}
+
+	slog.Info("Cancelling.")
+	close(remote.Cancel)
+}
+
 func unpause(remote *Remote, spinner *spinner.Spinner) {
 	_, err := remote.Blocker.Stop()
 	if err != nil {
 		log.Print(err)
 	}
 	remote.Pause <- true
+	remote.emit(plugins.EventSessionPause)
 	spinner.Start()
 }
 
@@ -72,4 +181,5 @@ func pause(remote *Remote, spinner *spinner.Spinner) {
 		log.Print(err)
 	}
 	remote.Pause <- true
+	remote.emit(plugins.EventSessionResume)
 }
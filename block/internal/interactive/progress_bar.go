@@ -4,7 +4,7 @@ import (
 	"io"
 	"time"
 
-	"github.com/connorkuljis/block-cli/internal/utils"
+	"github.com/connorkuljis/block-cli/internal/notify"
 	"github.com/schollz/progressbar/v3"
 )
 
@@ -20,7 +20,7 @@ func initProgressBar(max int, w io.Writer) *progressbar.ProgressBar {
 }
 
 func RenderProgressBar(remote *Remote) {
-	durationSeconds := int(remote.Task.EstimatedDurationSeconds)
+	durationSeconds := remote.DurationSeconds
 	pbar := initProgressBar(durationSeconds, remote.W)
 
 	ticker := time.NewTicker(time.Second * 1)
@@ -30,17 +30,27 @@ func RenderProgressBar(remote *Remote) {
 	for {
 		select {
 		case <-remote.Cancel:
+			// an adjustment sent just before cancelling, such as discarding idle time, still counts.
+			select {
+			case delta := <-remote.Adjust:
+				i = min(max(i+delta, 0), durationSeconds)
+				pbar.Set(i)
+			default:
+			}
 			remote.TotalTimeSeconds <- i
 			remote.CompletionPercent <- pbar.State().CurrentPercent * 100
 			remote.Wg.Done()
 			return
 		case <-remote.Pause:
 			paused = !paused
+		case delta := <-remote.Adjust:
+			i = min(max(i+delta, 0), durationSeconds)
+			pbar.Set(i)
 		case <-ticker.C:
 			if i == durationSeconds {
 				remote.TotalTimeSeconds <- i
 				remote.CompletionPercent <- pbar.State().CurrentPercent * 100
-				utils.SendNotification()
+				remote.notify(notify.EventFinished, i)
 				close(remote.Finish)
 				remote.Wg.Done()
 				return
@@ -49,6 +59,13 @@ func RenderProgressBar(remote *Remote) {
 			if !paused {
 				pbar.Add(1)
 				i++
+
+				// mid-session notifications are sent in the background so slow backends don't stall the timer.
+				// they are timed against the whole task, so a resumed task is not told it is halfway twice.
+				remaining := remote.Task.RemainingSeconds() - int64(i)
+				for _, event := range notify.Due(remote.Task.EstimatedDurationSeconds, remaining) {
+					go remote.notify(event, i)
+				}
 			}
 		}
 	}
@@ -1,12 +1,18 @@
 package interactive
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"log"
 	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/connorkuljis/block-cli/internal/blocker"
+	"github.com/connorkuljis/block-cli/internal/idle"
+	"github.com/connorkuljis/block-cli/internal/notify"
+	"github.com/connorkuljis/block-cli/internal/plugins"
 	"github.com/connorkuljis/block-cli/internal/tasks"
 	"github.com/jmoiron/sqlx"
 )
@@ -16,6 +22,18 @@ type Remote struct {
 	Blocker blocker.Blocker
 	Db      *sqlx.DB
 
+	Notifier *notify.Dispatcher
+	Monitor  *idle.Monitor
+	Plugins  *plugins.Host
+
+	// Screen captures are recorded from AvfoundationDevice into RecordingsPath.
+	RecordingsPath     string
+	AvfoundationDevice string
+
+	// DurationSeconds is the length of this session segment, StartedAt is when it began.
+	DurationSeconds int
+	StartedAt       time.Time
+
 	W                 io.Writer
 	Wg                *sync.WaitGroup
 	Pause             chan bool
@@ -23,13 +41,22 @@ type Remote struct {
 	Finish            chan error
 	CompletionPercent chan float64
 	TotalTimeSeconds  chan int
+
+	Idle   chan idle.Transition // presence changes reported by WatchIdle.
+	Adjust chan int             // seconds to add to (or remove from) the elapsed time.
+
+	events chan plugins.Event // pauses and resumes waiting to be sent to Plugins, in order.
 }
 
-func Run(w io.Writer, task *tasks.Task, blocker blocker.Blocker, db *sqlx.DB) (int, float64) {
-	remote := &Remote{
+// NewRemote returns a Remote for a session running for the task's full estimated duration.
+// Notifier, Monitor and Plugins are optional and may be set before calling Run.
+func NewRemote(w io.Writer, task *tasks.Task, blocker blocker.Blocker, db *sqlx.DB) *Remote {
+	return &Remote{
 		Task:              task,
 		Blocker:           blocker,
 		Db:                db,
+		DurationSeconds:   int(task.EstimatedDurationSeconds),
+		StartedAt:         time.Now(),
 		Wg:                &sync.WaitGroup{},
 		W:                 w,
 		Pause:             make(chan bool, 1),
@@ -37,8 +64,14 @@ func Run(w io.Writer, task *tasks.Task, blocker blocker.Blocker, db *sqlx.DB) (i
 		Finish:            make(chan error, 1),
 		CompletionPercent: make(chan float64, 1),
 		TotalTimeSeconds:  make(chan int, 1),
+		Idle:              make(chan idle.Transition, 1),
+		Adjust:            make(chan int, 1),
 	}
+}
 
+// Run blocks until the session finishes or is cancelled, returning the seconds elapsed and the percent of
+// DurationSeconds completed.
+func Run(remote *Remote) (int, float64) {
 	remote.Wg.Add(2)
 
 	slog.Info("Rendering progress bar")
@@ -47,15 +80,37 @@ func Run(w io.Writer, task *tasks.Task, blocker blocker.Blocker, db *sqlx.DB) (i
 	slog.Info("Polling input")
 	go PollInput(remote)
 
-	if task.ScreenEnabled == 1 {
+	if remote.Task.ScreenEnabled == 1 {
 		remote.Wg.Add(1)
 		slog.Info("Capturing screen.")
 		go FfmpegCaptureScreen(remote)
 	}
 
+	if remote.Monitor != nil {
+		remote.Wg.Add(1)
+		slog.Info("Watching for idle.")
+		go WatchIdle(remote)
+	}
+
+	if remote.Plugins != nil {
+		remote.events = make(chan plugins.Event, 16)
+		sent := make(chan struct{})
+		go func() {
+			for event := range remote.events {
+				remote.Plugins.Emit(context.Background(), event, remote.Task)
+			}
+			close(sent)
+		}()
+		defer func() {
+			close(remote.events)
+			<-sent
+		}()
+	}
+
 	fmt.Println("---")
 	fmt.Println("Press [q] or [esc] or [control-C] to quit.")
 	fmt.Println("Press [space] key to pause (re-enables sites temporarily).")
+	fmt.Println("Press [i] to log an interruption.")
 
 	remote.Wg.Wait()
 
@@ -64,3 +119,29 @@ func Run(w io.Writer, task *tasks.Task, blocker blocker.Blocker, db *sqlx.DB) (i
 
 	return totalTimeSeconds, percent
 }
+
+// emit queues a plugin event, so a slow plugin never holds up the keyboard.
+func (remote *Remote) emit(event plugins.Event) {
+	if remote.events == nil {
+		return
+	}
+
+	select {
+	case remote.events <- event:
+	default:
+		log.Printf("Error, too many plugin events queued, dropped %s", event)
+	}
+}
+
+// notify sends a notification for the event, logging any backend failures.
+func (remote *Remote) notify(event notify.Event, actualSeconds int) {
+	session := notify.Session{
+		TaskName:         remote.Task.TaskName,
+		ActualSeconds:    remote.Task.ActualDurationSeconds.Int64 + int64(actualSeconds),
+		EstimatedSeconds: remote.Task.EstimatedDurationSeconds,
+	}
+
+	if err := remote.Notifier.Send(event, session); err != nil {
+		log.Printf("Error, could not send %s notification: %v", event, err)
+	}
+}
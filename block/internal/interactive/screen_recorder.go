@@ -12,7 +12,6 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/connorkuljis/block-cli/internal/config"
 	"github.com/connorkuljis/block-cli/internal/ffmpeg"
 
 	"github.com/fatih/color"
@@ -29,7 +28,7 @@ func conventionalFilename(timestamp, name, filetype string) string {
 func FfmpegCaptureScreen(remote *Remote) {
 	var filename string
 
-	timestamp := remote.Task.CreatedAt.Format(TimeFormat)
+	timestamp := remote.StartedAt.Format(TimeFormat)
 	name := remote.Task.TaskName
 	if name == "" {
 		filename = fmt.Sprintf("%s.mkv", timestamp)
@@ -38,10 +37,9 @@ func FfmpegCaptureScreen(remote *Remote) {
 		filename = fmt.Sprintf("%s-%s.mkv", timestamp, name)
 	}
 
-	recordingPath := config.GetFfmpegRecordingPath()
-	outputFile := filepath.Join(recordingPath, filename)
+	outputFile := filepath.Join(remote.RecordingsPath, filename)
 
-	ffmpeg.RecordScreen(config.GetAvfoundationDevice(), outputFile, remote.Cancel, remote.Finish, remote.Wg)
+	ffmpeg.RecordScreen(remote.AvfoundationDevice, outputFile, remote.Cancel, remote.Finish, remote.Wg)
 
 }
 
@@ -52,14 +50,14 @@ func terminate(cmd *exec.Cmd) {
 	}
 }
 
-func FfmpegConcatenateScreenRecordings(inTime time.Time, files []string) (string, error) {
+func FfmpegConcatenateScreenRecordings(recordingsPath string, inTime time.Time, files []string) (string, error) {
 	var args []string
 
 	if len(files) == 0 {
 		return "", errors.New("Need at least one file to generate timelapse.")
 	}
 
-	filename := filepath.Join(config.GetFfmpegRecordingPath(), conventionalFilename(
+	filename := filepath.Join(recordingsPath, conventionalFilename(
 		inTime.Format(TimeFormat),
 		"concatenated",
 		".mkv",
@@ -0,0 +1,97 @@
+package notes
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	MinFocusRating = 1
+	MaxFocusRating = 5
+)
+
+// Note records the outcome of a session and how focused it felt.
+type Note struct {
+	NoteId      int64         `db:"note_id"`
+	TaskId      int64         `db:"task_id"`
+	Outcome     string        `db:"outcome"`
+	FocusRating sql.NullInt64 `db:"focus_rating"`
+	CreatedAt   time.Time     `db:"created_at"`
+}
+
+// Interruption is logged during a session with a short reason.
+type Interruption struct {
+	InterruptionId int64     `db:"interruption_id"`
+	TaskId         int64     `db:"task_id"`
+	Reason         string    `db:"reason"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+func NewNote(taskId int64, outcome string, focusRating int, createdAt time.Time) *Note {
+	return &Note{
+		TaskId:      taskId,
+		Outcome:     outcome,
+		FocusRating: sql.NullInt64{Int64: int64(focusRating), Valid: focusRating != 0},
+		CreatedAt:   createdAt,
+	}
+}
+
+func InsertNote(db sqlx.Ext, note *Note) error {
+	query := `INSERT INTO Notes (task_id, outcome, focus_rating, created_at) VALUES (:task_id, :outcome, :focus_rating, :created_at)`
+
+	result, err := sqlx.NamedExec(db, query, note)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	note.NoteId = id
+
+	return nil
+}
+
+func GetNotesByTaskId(db *sqlx.DB, taskId int64) ([]Note, error) {
+	var notes []Note
+
+	err := db.Select(&notes, "SELECT * FROM Notes WHERE task_id = ? ORDER BY created_at ASC", taskId)
+	if err != nil {
+		return notes, err
+	}
+
+	return notes, nil
+}
+
+func InsertInterruption(db sqlx.Ext, interruption *Interruption) error {
+	query := `INSERT INTO Interruptions (task_id, reason, created_at) VALUES (:task_id, :reason, :created_at)`
+
+	result, err := sqlx.NamedExec(db, query, interruption)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	interruption.InterruptionId = id
+
+	return nil
+}
+
+func GetInterruptionsByTaskId(db *sqlx.DB, taskId int64) ([]Interruption, error) {
+	var interruptions []Interruption
+
+	err := db.Select(&interruptions, "SELECT * FROM Interruptions WHERE task_id = ? ORDER BY created_at ASC", taskId)
+	if err != nil {
+		return interruptions, err
+	}
+
+	return interruptions, nil
+}
@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/gen2brain/beeep"
+)
+
+const httpTimeout = 10 * time.Second
+
+// DesktopNotifier shows a native desktop notification.
+type DesktopNotifier struct {
+	Icon string
+}
+
+func (n DesktopNotifier) Notify(msg Message) error {
+	if err := beeep.Notify(msg.Title, msg.Body, n.Icon); err != nil {
+		return fmt.Errorf("Error, could not send desktop notification: %w", err)
+	}
+	return nil
+}
+
+// BellNotifier sounds the system bell.
+type BellNotifier struct{}
+
+func (n BellNotifier) Notify(msg Message) error {
+	if err := beeep.Beep(beeep.DefaultFreq, beeep.DefaultDuration); err != nil {
+		return fmt.Errorf("Error, could not send notification beep: %w", err)
+	}
+	return nil
+}
+
+// WebhookNotifier POSTs the message as JSON to a URL.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhookNotifier(url string) WebhookNotifier {
+	return WebhookNotifier{URL: url, Client: &http.Client{Timeout: httpTimeout}}
+}
+
+func (n WebhookNotifier) Notify(msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.Client.Post(n.URL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("Error sending webhook notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Error sending webhook notification: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// NtfyNotifier pushes a plain text message to an ntfy-style topic URL.
+type NtfyNotifier struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+func NewNtfyNotifier(url, token string) NtfyNotifier {
+	return NtfyNotifier{URL: url, Token: token, Client: &http.Client{Timeout: httpTimeout}}
+}
+
+func (n NtfyNotifier) Notify(msg Message) error {
+	req, err := http.NewRequest(http.MethodPost, n.URL, strings.NewReader(msg.Body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Title", msg.Title)
+	req.Header.Set("Tags", string(msg.Event))
+	if n.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.Token)
+	}
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Error sending push notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Error sending push notification: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// CommandNotifier runs an external command. The message is passed through
+// BLOCK_* environment variables.
+type CommandNotifier struct {
+	Command []string
+}
+
+func (n CommandNotifier) Notify(msg Message) error {
+	cmd := exec.Command(n.Command[0], n.Command[1:]...)
+	cmd.Env = append(os.Environ(),
+		"BLOCK_EVENT="+string(msg.Event),
+		"BLOCK_TITLE="+msg.Title,
+		"BLOCK_MESSAGE="+msg.Body,
+		"BLOCK_TASK_NAME="+msg.TaskName,
+		fmt.Sprintf("BLOCK_ACTUAL_SECONDS=%d", msg.ActualSeconds),
+		fmt.Sprintf("BLOCK_ESTIMATED_SECONDS=%d", msg.EstimatedSeconds),
+	)
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("Error running notification command '%s': %w: %s", cmd.String(), err, out)
+	}
+
+	return nil
+}
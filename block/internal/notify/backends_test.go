@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testMessage = Message{
+	Event:            EventFinished,
+	Title:            DefaultTitle,
+	Body:             `Your session "write report" has finished! (25:00)`,
+	TaskName:         "write report",
+	ActualSeconds:    1500,
+	EstimatedSeconds: 1500,
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a json POST, got: %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	if err := NewWebhookNotifier(server.URL).Notify(testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if got != testMessage {
+		t.Errorf("Expected the message as json, got: %+v", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	if err := NewWebhookNotifier(failing.URL).Notify(testMessage); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Expected an error with the status, got: %v", err)
+	}
+}
+
+func TestNtfyNotifier(t *testing.T) {
+	var body string
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body, header = string(data), r.Header
+	}))
+	defer server.Close()
+
+	if err := NewNtfyNotifier(server.URL+"/block", "secret").Notify(testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if body != testMessage.Body {
+		t.Errorf("Expected the body as plain text, got: %q", body)
+	}
+	if header.Get("Title") != DefaultTitle || header.Get("Tags") != "finished" || header.Get("Authorization") != "Bearer secret" {
+		t.Errorf("Expected title, tags and token headers, got: %v", header)
+	}
+
+	if err := NewNtfyNotifier(server.URL, "").Notify(testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("Authorization") != "" {
+		t.Errorf("Expected no token header without a token, got: %q", header.Get("Authorization"))
+	}
+}
+
+func TestCommandNotifier(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+
+	n := CommandNotifier{Command: []string{"sh", "-c", `echo "$BLOCK_EVENT|$BLOCK_TASK_NAME|$BLOCK_ACTUAL_SECONDS|$BLOCK_MESSAGE" > "$0"`, out}}
+	if err := n.Notify(testMessage); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "finished|write report|1500|" + testMessage.Body + "\n"; string(data) != want {
+		t.Errorf("Expected the message in the environment, got: %q", data)
+	}
+
+	failing := CommandNotifier{Command: []string{"sh", "-c", "echo broken >&2; exit 1"}}
+	if err := failing.Notify(testMessage); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Expected an error with the command's output, got: %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"text/template"
+
+	"github.com/connorkuljis/block-cli/internal/config"
+	"github.com/connorkuljis/block-cli/internal/utils"
+)
+
+// Event identifies a point in the session lifecycle that can trigger a notification.
+type Event string
+
+const (
+	EventHalfway         Event = "halfway"
+	EventFiveMinutesLeft Event = "five-minutes-left"
+	EventFinished        Event = "finished"
+)
+
+const DefaultTitle = "block-cli"
+
+// fiveMinutes is the time remaining when EventFiveMinutesLeft is sent.
+const fiveMinutes = 5 * 60
+
+// Due returns the mid-session events reached when a task planned for estimatedSeconds has
+// remainingSeconds left, counting every segment worked on it. Tasks of five minutes or less get
+// no five minute warning.
+func Due(estimatedSeconds, remainingSeconds int64) []Event {
+	var events []Event
+	if estimatedSeconds-remainingSeconds == estimatedSeconds/2 {
+		events = append(events, EventHalfway)
+	}
+	if estimatedSeconds > fiveMinutes && remainingSeconds == fiveMinutes {
+		events = append(events, EventFiveMinutesLeft)
+	}
+	return events
+}
+
+var defaultTemplates = map[Event]string{
+	EventHalfway:         `Halfway there{{ if .TaskName }} on "{{ .TaskName }}"{{ end }}, {{ .Remaining }} to go.`,
+	EventFiveMinutesLeft: `Five minutes left{{ if .TaskName }} on "{{ .TaskName }}"{{ end }}.`,
+	EventFinished:        `Your session{{ if .TaskName }} "{{ .TaskName }}"{{ end }} has finished! ({{ .Actual }})`,
+}
+
+// Message is the rendered notification handed to each backend.
+type Message struct {
+	Event            Event  `json:"event"`
+	Title            string `json:"title"`
+	Body             string `json:"body"`
+	TaskName         string `json:"task_name"`
+	ActualSeconds    int64  `json:"actual_seconds"`
+	EstimatedSeconds int64  `json:"estimated_seconds"`
+}
+
+// Notifier is implemented by every notification backend.
+type Notifier interface {
+	Notify(msg Message) error
+}
+
+// Session carries the values available to message templates.
+type Session struct {
+	TaskName         string
+	ActualSeconds    int64
+	EstimatedSeconds int64
+}
+
+func (s Session) Actual() string {
+	return utils.SecsToHHMMSS(s.ActualSeconds)
+}
+
+func (s Session) Estimated() string {
+	return utils.SecsToHHMMSS(s.EstimatedSeconds)
+}
+
+func (s Session) Remaining() string {
+	return utils.SecsToHHMMSS(max(s.EstimatedSeconds-s.ActualSeconds, 0))
+}
+
+type route struct {
+	notifier Notifier
+	events   []Event
+}
+
+// Dispatcher renders event messages and fans them out to the configured backends.
+type Dispatcher struct {
+	routes    []route
+	templates map[Event]*template.Template
+}
+
+// NewDispatcher returns a Dispatcher with the default message templates and no backends.
+func NewDispatcher() *Dispatcher {
+	d := &Dispatcher{templates: make(map[Event]*template.Template)}
+	for event, text := range defaultTemplates {
+		d.templates[event] = template.Must(template.New(string(event)).Parse(text))
+	}
+	return d
+}
+
+// NewFromConfig builds a Dispatcher from the notifications section of the config file.
+func NewFromConfig(cfg config.NotificationsConfig) (*Dispatcher, error) {
+	d := NewDispatcher()
+
+	for event, text := range cfg.Templates {
+		if err := d.SetTemplate(Event(event), text); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, backend := range cfg.Backends {
+		notifier, err := newBackend(backend)
+		if err != nil {
+			return nil, err
+		}
+
+		var events []Event
+		for _, event := range backend.Events {
+			events = append(events, Event(event))
+		}
+
+		d.Add(notifier, events...)
+	}
+
+	return d, nil
+}
+
+func newBackend(cfg config.NotifierConfig) (Notifier, error) {
+	switch cfg.Type {
+	case "desktop":
+		return DesktopNotifier{}, nil
+	case "bell":
+		return BellNotifier{}, nil
+	case "webhook":
+		if cfg.URL == "" {
+			return nil, errors.New("Error, webhook notifier requires a url")
+		}
+		return NewWebhookNotifier(cfg.URL), nil
+	case "ntfy":
+		if cfg.URL == "" {
+			return nil, errors.New("Error, ntfy notifier requires a url")
+		}
+		return NewNtfyNotifier(cfg.URL, cfg.Token), nil
+	case "command":
+		if len(cfg.Command) == 0 {
+			return nil, errors.New("Error, command notifier requires a command")
+		}
+		return CommandNotifier{Command: cfg.Command}, nil
+	default:
+		return nil, fmt.Errorf("Error, unknown notifier type '%s'", cfg.Type)
+	}
+}
+
+// Add registers a backend for the given events. No events subscribes the backend to all of them.
+func (d *Dispatcher) Add(n Notifier, events ...Event) {
+	d.routes = append(d.routes, route{notifier: n, events: events})
+}
+
+// SetTemplate overrides the message template used for an event.
+func (d *Dispatcher) SetTemplate(event Event, text string) error {
+	tmpl, err := template.New(string(event)).Parse(text)
+	if err != nil {
+		return fmt.Errorf("Error parsing notification template for '%s': %w", event, err)
+	}
+	d.templates[event] = tmpl
+	return nil
+}
+
+// Send renders the message for event and delivers it to every subscribed backend.
+// Delivery continues past failing backends; their errors are joined and returned.
+func (d *Dispatcher) Send(event Event, session Session) error {
+	if d == nil {
+		return nil
+	}
+
+	tmpl, ok := d.templates[event]
+	if !ok {
+		return fmt.Errorf("Error, no notification template for '%s'", event)
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, session); err != nil {
+		return fmt.Errorf("Error rendering notification for '%s': %w", event, err)
+	}
+
+	msg := Message{
+		Event:            event,
+		Title:            DefaultTitle,
+		Body:             body.String(),
+		TaskName:         session.TaskName,
+		ActualSeconds:    session.ActualSeconds,
+		EstimatedSeconds: session.EstimatedSeconds,
+	}
+
+	var errs []error
+	for _, r := range d.routes {
+		if len(r.events) > 0 && !slices.Contains(r.events, event) {
+			continue
+		}
+		if err := r.notifier.Notify(msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,162 @@
+package notify
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/connorkuljis/block-cli/internal/config"
+)
+
+// recorder is a backend that keeps every message it is sent.
+type recorder struct {
+	messages []Message
+	err      error
+}
+
+func (r *recorder) Notify(msg Message) error {
+	r.messages = append(r.messages, msg)
+	return r.err
+}
+
+func (r *recorder) events() []Event {
+	var events []Event
+	for _, msg := range r.messages {
+		events = append(events, msg.Event)
+	}
+	return events
+}
+
+func TestDefaultTemplates(t *testing.T) {
+	session := Session{TaskName: "write report", ActualSeconds: 750, EstimatedSeconds: 1500}
+
+	testCases := []struct {
+		event Event
+		want  string
+	}{
+		{event: EventHalfway, want: `Halfway there on "write report", 12:30 to go.`},
+		{event: EventFiveMinutesLeft, want: `Five minutes left on "write report".`},
+		{event: EventFinished, want: `Your session "write report" has finished! (12:30)`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.event), func(t *testing.T) {
+			r := &recorder{}
+			d := NewDispatcher()
+			d.Add(r)
+
+			if err := d.Send(tc.event, session); err != nil {
+				t.Fatal(err)
+			}
+			if len(r.messages) != 1 || r.messages[0].Body != tc.want {
+				t.Errorf("Expected %q, got: %+v", tc.want, r.messages)
+			}
+		})
+	}
+
+	r := &recorder{}
+	d := NewDispatcher()
+	d.Add(r)
+	if err := d.Send(EventFiveMinutesLeft, Session{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.messages[0].Body; got != "Five minutes left." {
+		t.Errorf("Expected the task name to be left out when empty, got: %q", got)
+	}
+}
+
+func TestCustomTemplate(t *testing.T) {
+	r := &recorder{}
+	d, err := NewFromConfig(config.NotificationsConfig{
+		Templates: map[string]string{"finished": "Done: {{ .TaskName }} in {{ .Actual }} of {{ .Estimated }}"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Add(r)
+
+	if err := d.Send(EventFinished, Session{TaskName: "email", ActualSeconds: 600, EstimatedSeconds: 900}); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.messages[0].Body; got != "Done: email in 10:00 of 15:00" {
+		t.Errorf("Expected the custom template, got: %q", got)
+	}
+
+	if err := d.SetTemplate(EventHalfway, "{{ .TaskName "); err == nil {
+		t.Error("Expected an error for an invalid template")
+	}
+	if _, err := NewFromConfig(config.NotificationsConfig{Backends: []config.NotifierConfig{{Type: "webhook"}}}); err == nil {
+		t.Error("Expected an error for a webhook without a url")
+	}
+}
+
+func TestDispatcherRoutes(t *testing.T) {
+	all, finished, failing := &recorder{}, &recorder{}, &recorder{err: errors.New("offline")}
+
+	d := NewDispatcher()
+	d.Add(failing)
+	d.Add(all)
+	d.Add(finished, EventFinished)
+
+	for _, event := range []Event{EventHalfway, EventFiveMinutesLeft, EventFinished} {
+		if err := d.Send(event, Session{}); err == nil || !strings.Contains(err.Error(), "offline") {
+			t.Errorf("Expected the failing backend's error for %s, got: %v", event, err)
+		}
+	}
+
+	if got := all.events(); len(got) != 3 {
+		t.Errorf("Expected a backend without events to get every event despite an earlier failure, got: %v", got)
+	}
+	if got := finished.events(); !slices.Equal(got, []Event{EventFinished}) {
+		t.Errorf("Expected only the finished event, got: %v", got)
+	}
+}
+
+func TestSessionSchedule(t *testing.T) {
+	testCases := []struct {
+		name     string
+		duration int
+		worked   int // seconds worked in earlier segments.
+		want     map[int][]Event
+	}{
+		{name: "25 minutes", duration: 1500, want: map[int][]Event{750: {EventHalfway}, 1200: {EventFiveMinutesLeft}}},
+		{name: "10 minutes", duration: 600, want: map[int][]Event{300: {EventHalfway, EventFiveMinutesLeft}}},
+		{name: "5 minutes", duration: 300, want: map[int][]Event{150: {EventHalfway}}},
+		{name: "odd seconds", duration: 61, want: map[int][]Event{30: {EventHalfway}}},
+		{name: "resumed past halfway", duration: 1500, worked: 1000, want: map[int][]Event{200: {EventFiveMinutesLeft}}},
+		{name: "resumed before halfway", duration: 1500, worked: 500, want: map[int][]Event{250: {EventHalfway}, 700: {EventFiveMinutesLeft}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &recorder{}
+			d := NewDispatcher()
+			d.Add(r)
+
+			// tick through the segment as the progress bar does, a second at a time.
+			got := make(map[int][]Event)
+			for elapsed := 1; elapsed <= tc.duration-tc.worked; elapsed++ {
+				before := len(r.messages)
+				actual := int64(tc.worked + elapsed)
+				for _, event := range Due(int64(tc.duration), int64(tc.duration)-actual) {
+					if err := d.Send(event, Session{ActualSeconds: actual, EstimatedSeconds: int64(tc.duration)}); err != nil {
+						t.Fatal(err)
+					}
+				}
+				for _, msg := range r.messages[before:] {
+					got[elapsed] = append(got[elapsed], msg.Event)
+				}
+			}
+
+			if len(got) != len(tc.want) {
+				t.Fatalf("Expected events at %v, got: %v", tc.want, got)
+			}
+			for elapsed, events := range tc.want {
+				if !slices.Equal(got[elapsed], events) {
+					t.Errorf("Expected %v at %ds, got: %v", events, elapsed, got[elapsed])
+				}
+			}
+		})
+	}
+}
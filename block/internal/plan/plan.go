@@ -0,0 +1,315 @@
+// Package plan keeps the backlog of planned tasks and compares each day's plan with the time spent.
+package plan
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrEmpty is returned by Next when nothing is left to start.
+var ErrEmpty = errors.New("Error, the plan is empty, add tasks with `block plan add`")
+
+// Item is a planned task, waiting in the backlog until it is started.
+type Item struct {
+	PlanId                   int64         `db:"plan_id"`
+	TaskName                 string        `db:"task_name"`
+	EstimatedDurationSeconds int64         `db:"estimated_duration_seconds"`
+	BucketId                 sql.NullInt64 `db:"bucket_id"`
+	Position                 int64         `db:"position"`
+	PlannedFor               string        `db:"planned_for"` // yyyy-mm-dd
+	TaskId                   sql.NullInt64 `db:"task_id"`     // the task the item became once started.
+	CreatedAt                time.Time     `db:"created_at"`
+	StartedAt                sql.NullTime  `db:"started_at"`
+}
+
+// NewItem returns an item planned for the day labelled date.
+func NewItem(taskName string, estimatedDurationSeconds int64, date time.Time, createdAt time.Time) *Item {
+	return &Item{
+		TaskName:                 taskName,
+		EstimatedDurationSeconds: estimatedDurationSeconds,
+		PlannedFor:               date.Format("2006-01-02"),
+		CreatedAt:                createdAt,
+	}
+}
+
+func (item *Item) AddBucketTag(bucketId int64) {
+	item.BucketId = sql.NullInt64{Int64: bucketId, Valid: true}
+}
+
+// NewTask returns the task to run for the item, with the blocker enabled.
+func (item Item) NewTask(createdAt time.Time) *tasks.Task {
+	task := tasks.NewTask(item.TaskName, item.EstimatedDurationSeconds, true, false, createdAt)
+	task.BucketId = item.BucketId
+	return task
+}
+
+// Insert adds the item to the end of the backlog.
+func Insert(db *sqlx.DB, item *Item) error {
+	err := db.Get(&item.Position, "SELECT COALESCE(MAX(position), 0) + 1 FROM Plan WHERE started_at IS NULL")
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO Plan
+	(
+	  task_name
+	, estimated_duration_seconds
+	, bucket_id
+	, position
+	, planned_for
+	, created_at
+	)
+	VALUES
+	(
+	  :task_name
+	, :estimated_duration_seconds
+	, :bucket_id
+	, :position
+	, :planned_for
+	, :created_at
+	)`
+
+	result, err := db.NamedExec(query, item)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	item.PlanId = id
+
+	return nil
+}
+
+// Backlog returns the items not yet started, in order.
+func Backlog(db *sqlx.DB) ([]Item, error) {
+	var items []Item
+
+	err := db.Select(&items, "SELECT * FROM Plan WHERE started_at IS NULL ORDER BY position ASC, plan_id ASC")
+	if err != nil {
+		return items, err
+	}
+
+	return items, nil
+}
+
+// Next returns the first item of the backlog, or ErrEmpty.
+func Next(db *sqlx.DB) (Item, error) {
+	var item Item
+
+	err := db.Get(&item, "SELECT * FROM Plan WHERE started_at IS NULL ORDER BY position ASC, plan_id ASC LIMIT 1")
+	if errors.Is(err, sql.ErrNoRows) {
+		return item, ErrEmpty
+	}
+	if err != nil {
+		return item, err
+	}
+
+	return item, nil
+}
+
+// GetItem fetches a waiting item by id.
+func GetItem(db *sqlx.DB, planId int64) (Item, error) {
+	var item Item
+
+	err := db.Get(&item, "SELECT * FROM Plan WHERE plan_id = ? AND started_at IS NULL", planId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return item, fmt.Errorf("Error, no planned task with id %d", planId)
+	}
+	if err != nil {
+		return item, err
+	}
+
+	return item, nil
+}
+
+// MarkStarted takes the item out of the backlog and links it to the task it became.
+func MarkStarted(db sqlx.Execer, planId, taskId int64, startedAt time.Time) error {
+	_, err := db.Exec("UPDATE Plan SET task_id = ?, started_at = ? WHERE plan_id = ?", taskId, startedAt, planId)
+	return err
+}
+
+// Remove deletes a waiting item from the backlog.
+func Remove(db *sqlx.DB, planId int64) error {
+	result, err := db.Exec("DELETE FROM Plan WHERE plan_id = ? AND started_at IS NULL", planId)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("Error, no planned task with id %d", planId)
+	}
+
+	return nil
+}
+
+// Reorder sets the backlog order to planIds, which must list every waiting item once.
+func Reorder(db *sqlx.DB, planIds []int64) error {
+	backlog, err := Backlog(db)
+	if err != nil {
+		return err
+	}
+
+	waiting := make(map[int64]bool, len(backlog))
+	for _, item := range backlog {
+		waiting[item.PlanId] = true
+	}
+	if len(planIds) != len(backlog) {
+		return fmt.Errorf("Error, expected the order of all %d planned tasks, got %d", len(backlog), len(planIds))
+	}
+	for _, id := range planIds {
+		if !waiting[id] {
+			return fmt.Errorf("Error, no planned task with id %d, or it is listed twice", id)
+		}
+		delete(waiting, id)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i, id := range planIds {
+		if _, err := tx.Exec("UPDATE Plan SET position = ? WHERE plan_id = ?", i+1, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Move puts a waiting item at position, counting from 1. Positions past the end move it last.
+func Move(db *sqlx.DB, planId int64, position int) error {
+	if position < 1 {
+		return errors.New("Error, position must be 1 or more")
+	}
+
+	backlog, err := Backlog(db)
+	if err != nil {
+		return err
+	}
+
+	var ids []int64
+	found := false
+	for _, item := range backlog {
+		if item.PlanId == planId {
+			found = true
+			continue
+		}
+		ids = append(ids, item.PlanId)
+	}
+	if !found {
+		return fmt.Errorf("Error, no planned task with id %d", planId)
+	}
+
+	position = min(position, len(ids)+1)
+	ids = append(ids[:position-1], append([]int64{planId}, ids[position-1:]...)...)
+
+	return Reorder(db, ids)
+}
+
+// Comparison is a planned item against the time actually spent on it.
+type Comparison struct {
+	Item          Item
+	Started       bool
+	Completed     bool
+	ActualSeconds int64
+	DeltaSeconds  int64 // actual minus estimate, once started.
+}
+
+// Review compares a day's plan with the time spent.
+type Review struct {
+	Date             time.Time
+	Items            []Comparison
+	PlannedSeconds   int64 // estimates of every planned item.
+	ActualSeconds    int64 // time spent on planned items.
+	UnplannedSeconds int64 // time spent on tasks that were not planned.
+	UnplannedTasks   int
+}
+
+// BuildReview compares items with the tasks they became. dayTasks are all the day's tasks,
+// those not started from the plan count as unplanned.
+func BuildReview(items []Item, started map[int64]tasks.Task, dayTasks []tasks.Task, date time.Time) Review {
+	r := Review{Date: date}
+
+	planned := make(map[int64]bool)
+	for _, item := range items {
+		c := Comparison{Item: item}
+		if task, ok := started[item.TaskId.Int64]; ok && item.TaskId.Valid {
+			planned[task.TaskId] = true
+			c.Started = true
+			c.Completed = task.Completed == 1
+			c.ActualSeconds = task.ActualDurationSeconds.Int64
+			c.DeltaSeconds = c.ActualSeconds - item.EstimatedDurationSeconds
+		}
+
+		r.PlannedSeconds += item.EstimatedDurationSeconds
+		r.ActualSeconds += c.ActualSeconds
+		r.Items = append(r.Items, c)
+	}
+
+	for _, task := range dayTasks {
+		if planned[task.TaskId] || task.ActualDurationSeconds.Int64 == 0 {
+			continue
+		}
+		r.UnplannedTasks++
+		r.UnplannedSeconds += task.ActualDurationSeconds.Int64
+	}
+
+	return r
+}
+
+// ReviewDay compares the plan for the day containing t, and items started that day, with the time spent.
+func ReviewDay(ctx context.Context, db *sqlx.DB, cal calendar.Calendar, t time.Time) (Review, error) {
+	date := cal.Date(t)
+	start, end := cal.DayBounds(t)
+
+	var items []Item
+	query := `SELECT * FROM Plan
+	WHERE planned_for = ? OR (julianday(started_at) >= julianday(?) AND julianday(started_at) < julianday(?))
+	ORDER BY started_at IS NULL, started_at ASC, position ASC`
+	err := db.SelectContext(ctx, &items, query, date.Format("2006-01-02"), start, end)
+	if err != nil {
+		return Review{}, err
+	}
+
+	store := tasks.NewStore(db)
+
+	started := make(map[int64]tasks.Task)
+	for _, item := range items {
+		if !item.TaskId.Valid {
+			continue
+		}
+		task, err := store.Get(ctx, item.TaskId.Int64)
+		if err != nil {
+			return Review{}, err
+		}
+		// a deleted task counts as never started.
+		if !task.DeletedAt.Valid {
+			started[task.TaskId] = task
+		}
+	}
+
+	dayTasks, err := store.Select(ctx, tasks.NewQuery().OnDay(cal, t))
+	if err != nil {
+		return Review{}, err
+	}
+
+	return BuildReview(items, started, dayTasks, date), nil
+}
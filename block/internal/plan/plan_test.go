@@ -0,0 +1,141 @@
+package plan
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/db/dbtest"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/jmoiron/sqlx"
+)
+
+func backlogNames(t *testing.T, conn *sqlx.DB) []string {
+	t.Helper()
+
+	backlog, err := Backlog(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, item := range backlog {
+		names = append(names, item.TaskName)
+	}
+	return names
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBacklogOrder(t *testing.T) {
+	conn := dbtest.Open(t)
+
+	date := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	now := date.Add(8 * time.Hour)
+
+	var ids []int64
+	for _, name := range []string{"email", "review", "write"} {
+		item := NewItem(name, 1800, date, now)
+		if err := Insert(conn, item); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, item.PlanId)
+	}
+
+	if err := Move(conn, ids[2], 1); err != nil {
+		t.Fatal(err)
+	}
+	if names := backlogNames(t, conn); !equal(names, []string{"write", "email", "review"}) {
+		t.Errorf("Expected write moved first, got: %v", names)
+	}
+
+	if err := Reorder(conn, []int64{ids[1], ids[0]}); err == nil {
+		t.Error("Expected error when the order leaves out a task, got nil")
+	}
+	if err := Reorder(conn, []int64{ids[1], ids[1], ids[0]}); err == nil {
+		t.Error("Expected error when the order lists a task twice, got nil")
+	}
+	if err := Reorder(conn, []int64{ids[1], ids[0], ids[2]}); err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := Next(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.TaskName != "review" {
+		t.Errorf("Expected review next, got: %s", next.TaskName)
+	}
+
+	// a started item leaves the backlog and can no longer be removed.
+	if err := MarkStarted(conn, next.PlanId, 1, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := Remove(conn, next.PlanId); err == nil {
+		t.Error("Expected error removing a started task, got nil")
+	}
+	if names := backlogNames(t, conn); !equal(names, []string{"email", "write"}) {
+		t.Errorf("Expected email then write left, got: %v", names)
+	}
+
+	for _, id := range []int64{ids[0], ids[2]} {
+		if err := Remove(conn, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := Next(conn); err != ErrEmpty {
+		t.Errorf("Expected ErrEmpty, got: %v", err)
+	}
+}
+
+func TestBuildReview(t *testing.T) {
+	date := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	planned := func(id int64, estimateMinutes int64, taskId int64) Item {
+		item := Item{PlanId: id, TaskName: "planned", EstimatedDurationSeconds: estimateMinutes * 60}
+		if taskId > 0 {
+			item.TaskId = sql.NullInt64{Int64: taskId, Valid: true}
+		}
+		return item
+	}
+	task := func(id int64, minutes int64, completed int) tasks.Task {
+		return tasks.Task{TaskId: id, Completed: completed, ActualDurationSeconds: sql.NullInt64{Int64: minutes * 60, Valid: true}}
+	}
+
+	items := []Item{planned(1, 30, 10), planned(2, 60, 11), planned(3, 45, 0)}
+	dayTasks := []tasks.Task{task(10, 40, 1), task(11, 20, 0), task(12, 15, 1)}
+	started := map[int64]tasks.Task{10: dayTasks[0], 11: dayTasks[1]}
+
+	r := BuildReview(items, started, dayTasks, date)
+
+	if r.PlannedSeconds != 135*60 || r.ActualSeconds != 60*60 {
+		t.Errorf("Expected 2h15m planned and 1h spent, got: %d planned, %d actual", r.PlannedSeconds, r.ActualSeconds)
+	}
+	if r.UnplannedTasks != 1 || r.UnplannedSeconds != 15*60 {
+		t.Errorf("Expected one unplanned task of 15m, got: %d tasks, %d seconds", r.UnplannedTasks, r.UnplannedSeconds)
+	}
+
+	want := []struct {
+		started, completed bool
+		delta              int64
+	}{
+		{true, true, 10 * 60},
+		{true, false, -40 * 60},
+		{false, false, 0},
+	}
+	for i, c := range r.Items {
+		if c.Started != want[i].started || c.Completed != want[i].completed || c.DeltaSeconds != want[i].delta {
+			t.Errorf("Expected item %d started %v completed %v delta %d, got: %+v", i, want[i].started, want[i].completed, want[i].delta, c)
+		}
+	}
+}
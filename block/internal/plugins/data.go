@@ -0,0 +1,65 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Data is a value a plugin attached to a task.
+type Data struct {
+	TaskId    int64     `db:"task_id"`
+	Plugin    string    `db:"plugin"`
+	Key       string    `db:"key"`
+	Value     string    `db:"value"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// Attach sets a plugin's values on a task, replacing any it set before under the same keys.
+// An empty value removes the key.
+func Attach(db *sqlx.DB, taskId int64, plugin string, values map[string]string, at time.Time) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("Error, plugin %s attached a value without a key", plugin)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, key := range keys {
+		if values[key] == "" {
+			_, err = tx.Exec("DELETE FROM TaskPluginData WHERE task_id = ? AND plugin = ? AND key = ?", taskId, plugin, key)
+		} else {
+			_, err = tx.Exec(`INSERT INTO TaskPluginData (task_id, plugin, key, value, updated_at) VALUES (?, ?, ?, ?, ?)
+				ON CONFLICT (task_id, plugin, key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+				taskId, plugin, key, values[key], at)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetDataByTaskId returns the values plugins attached to a task, by plugin and key.
+func GetDataByTaskId(db *sqlx.DB, taskId int64) ([]Data, error) {
+	var data []Data
+
+	err := db.Select(&data, "SELECT * FROM TaskPluginData WHERE task_id = ? ORDER BY plugin, key", taskId)
+	if err != nil {
+		return data, err
+	}
+
+	return data, nil
+}
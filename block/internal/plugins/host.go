@@ -0,0 +1,117 @@
+package plugins
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/jmoiron/sqlx"
+)
+
+// EventParams are sent with every event.
+type EventParams struct {
+	Event Event     `json:"event"`
+	At    time.Time `json:"at"`
+	Task  TaskInfo  `json:"task"`
+}
+
+// TaskInfo describes the task an event is about. Data holds what the plugin attached to the
+// task earlier, such as on session.start.
+type TaskInfo struct {
+	ID                int64             `json:"id"`
+	UUID              string            `json:"uuid"`
+	Name              string            `json:"name"`
+	EstimatedSeconds  int64             `json:"estimated_seconds"`
+	ActualSeconds     int64             `json:"actual_seconds"`
+	CompletionPercent float64           `json:"completion_percent"`
+	Completed         bool              `json:"completed"`
+	Data              map[string]string `json:"data"`
+}
+
+// EventResult is a plugin's answer to an event. Attach sets values on the task record, where
+// an empty value removes the key.
+type EventResult struct {
+	Attach map[string]string `json:"attach"`
+}
+
+// Host sends lifecycle events to the loaded plugins and saves what they attach. A nil Host
+// sends nothing.
+type Host struct {
+	db      *sqlx.DB
+	plugins []Loaded
+}
+
+func NewHost(db *sqlx.DB, loaded []Loaded) *Host {
+	return &Host{db: db, plugins: loaded}
+}
+
+// Emit calls every plugin that wants event, all at once, and attaches their results to the
+// task. Failures are logged, so a broken plugin never stops a session.
+func (h *Host) Emit(ctx context.Context, event Event, task *tasks.Task) {
+	if h == nil {
+		return
+	}
+
+	var wanted []Loaded
+	for _, p := range h.plugins {
+		if p.Wants(event) {
+			wanted = append(wanted, p)
+		}
+	}
+	if len(wanted) == 0 {
+		return
+	}
+
+	existing, err := GetDataByTaskId(h.db, task.TaskId)
+	if err != nil {
+		log.Printf("Error reading plugin data for task %d: %v", task.TaskId, err)
+	}
+
+	at := time.Now()
+	results := make([]EventResult, len(wanted))
+
+	var wg sync.WaitGroup
+	for i, p := range wanted {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			params := EventParams{Event: event, At: at, Task: taskInfo(task, p.Name, existing)}
+			if err := p.Call(ctx, string(event), params, &results[i]); err != nil {
+				log.Print(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, p := range wanted {
+		if len(results[i].Attach) == 0 {
+			continue
+		}
+		if err := Attach(h.db, task.TaskId, p.Name, results[i].Attach, at); err != nil {
+			log.Printf("Error saving data from plugin %s: %v", p.Name, err)
+		}
+	}
+}
+
+func taskInfo(task *tasks.Task, plugin string, existing []Data) TaskInfo {
+	info := TaskInfo{
+		ID:                task.TaskId,
+		UUID:              task.TaskUUID,
+		Name:              task.TaskName,
+		EstimatedSeconds:  task.EstimatedDurationSeconds,
+		ActualSeconds:     task.ActualDurationSeconds.Int64,
+		CompletionPercent: task.CompletionPercent.Float64,
+		Completed:         task.Completed == 1,
+		Data:              make(map[string]string),
+	}
+	for _, d := range existing {
+		if d.Plugin == plugin {
+			info.Data[d.Key] = d.Value
+		}
+	}
+
+	return info
+}
@@ -0,0 +1,225 @@
+// Package plugins runs external executables named block-plugin-<name>, found on PATH, that
+// follow the session lifecycle and add commands to block.
+//
+// Each call runs the plugin as `block-plugin-<name> rpc`, writes one JSON-RPC 2.0 request to
+// its stdin and reads one response from its stdout. The plugin is first asked to describe
+// itself, listing the events it wants and the commands it adds. A command runs the plugin as
+// `block-plugin-<name> command <command> [args...]` on the terminal.
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Prefix starts the name of every plugin executable.
+const Prefix = "block-plugin-"
+
+// Timeout limits how long a plugin may take to answer a call.
+const Timeout = 5 * time.Second
+
+// Event is a point in the session lifecycle that plugins can follow. Its value is the
+// JSON-RPC method the plugin is called with.
+type Event string
+
+const (
+	EventTaskCreated   Event = "task.created"
+	EventSessionStart  Event = "session.start"
+	EventSessionPause  Event = "session.pause"
+	EventSessionResume Event = "session.resume"
+	EventSessionFinish Event = "session.finish"
+)
+
+// MethodDescribe asks a plugin for its Manifest.
+const MethodDescribe = "describe"
+
+// Plugin is a plugin executable.
+type Plugin struct {
+	Name string // the executable's name without Prefix.
+	Path string
+}
+
+// Manifest is a plugin's answer to describe.
+type Manifest struct {
+	Description string    `json:"description"`
+	Events      []Event   `json:"events"`
+	Commands    []Command `json:"commands"`
+}
+
+// Command is a subcommand a plugin adds to block.
+type Command struct {
+	Name  string `json:"name"`
+	Usage string `json:"usage"`
+}
+
+// Loaded is a plugin that has described itself.
+type Loaded struct {
+	Plugin
+	Manifest Manifest
+}
+
+// Wants reports whether the plugin asked to be called for event.
+func (l Loaded) Wants(event Event) bool {
+	for _, e := range l.Manifest.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Discover finds the plugins in a PATH-style list of directories. When two directories hold
+// a plugin of the same name the first wins, as it would when run from a shell.
+func Discover(pathList string) []Plugin {
+	seen := make(map[string]bool)
+	var found []Plugin
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			name, ok := strings.CutPrefix(entry.Name(), Prefix)
+			if !ok || name == "" || seen[name] {
+				continue
+			}
+
+			path := filepath.Join(dir, entry.Name())
+			info, err := os.Stat(path)
+			if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+				continue
+			}
+
+			seen[name] = true
+			found = append(found, Plugin{Name: name, Path: path})
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].Name < found[j].Name })
+
+	return found
+}
+
+// Load asks each plugin to describe itself, all at once. A plugin that fails is logged and
+// left out.
+func Load(ctx context.Context, found []Plugin) []Loaded {
+	loaded := make([]*Loaded, len(found))
+
+	var wg sync.WaitGroup
+	for i, p := range found {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var manifest Manifest
+			if err := p.Call(ctx, MethodDescribe, nil, &manifest); err != nil {
+				log.Printf("%v, skipping it", err)
+				return
+			}
+			loaded[i] = &Loaded{Plugin: p, Manifest: manifest}
+		}()
+	}
+	wg.Wait()
+
+	var out []Loaded
+	for _, l := range loaded {
+		if l != nil {
+			out = append(out, *l)
+		}
+	}
+
+	return out
+}
+
+type request struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int    `json:"id"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int             `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Call sends the plugin one request and decodes its result into result, which may be nil.
+func (p Plugin) Call(ctx context.Context, method string, params, result any) error {
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	data, err := json.Marshal(request{JSONRPC: "2.0", ID: 1, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Path, "rpc")
+	cmd.Stdin = bytes.NewReader(append(data, '\n'))
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("Error, plugin %s did not answer %s within %s", p.Name, method, Timeout)
+		}
+		return fmt.Errorf("Error running plugin %s for %s: %w: %s", p.Name, method, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var resp response
+	if err := json.NewDecoder(&stdout).Decode(&resp); err != nil {
+		return fmt.Errorf("Error, plugin %s sent an invalid response to %s: %w", p.Name, method, err)
+	}
+	if resp.JSONRPC != "2.0" || resp.ID != 1 {
+		return fmt.Errorf("Error, plugin %s sent a response to %s that is not JSON-RPC 2.0 with id 1", p.Name, method)
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("Error from plugin %s for %s: %s (%d)", p.Name, method, resp.Error.Message, resp.Error.Code)
+	}
+
+	if result != nil && len(resp.Result) > 0 {
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("Error, plugin %s sent an invalid result for %s: %w", p.Name, method, err)
+		}
+	}
+
+	return nil
+}
+
+// Command runs one of the plugin's commands on the terminal, with env added to block's
+// environment.
+func (p Plugin) Command(name string, args []string, env []string) error {
+	cmd := exec.Command(p.Path, append([]string{"command", name}, args...)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(), env...)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Error running plugin %s command %s: %w", p.Name, name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/db/dbtest"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+)
+
+// script answers describe, attaches the issue it was given back on session.start, and
+// rejects anything else.
+const script = `#!/bin/sh
+[ "$1" = rpc ] || exit 2
+read -r line
+case "$line" in
+*'"describe"'*)
+	echo '{"jsonrpc":"2.0","id":1,"result":{"description":"tracks issues","events":["session.start","session.finish"],"commands":[{"name":"issue","usage":"pick an issue"}]}}' ;;
+*'"session.start"'*)
+	echo '{"jsonrpc":"2.0","id":1,"result":{"attach":{"issue":"ABC-1","status":"started"}}}' ;;
+*'"session.finish"'*'"issue":"ABC-1"'*)
+	echo '{"jsonrpc":"2.0","id":1,"result":{"attach":{"status":""}}}' ;;
+*)
+	echo '{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found"}}' ;;
+esac
+`
+
+func install(t *testing.T, dir, name, contents string, mode os.FileMode) {
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	install(t, first, Prefix+"issues", script, 0755)
+	install(t, first, Prefix+"notes.txt", "", 0644)
+	install(t, second, Prefix+"issues", script, 0755)
+	install(t, second, Prefix+"music", script, 0755)
+	install(t, second, "block", script, 0755)
+
+	found := Discover(strings.Join([]string{first, "", filepath.Join(first, "missing"), second}, string(os.PathListSeparator)))
+
+	if len(found) != 2 || found[0].Name != "issues" || found[1].Name != "music" {
+		t.Fatalf("Expected the issues and music plugins, got: %+v", found)
+	}
+	if filepath.Dir(found[0].Path) != first {
+		t.Errorf("Expected the first plugin on PATH to win, got: %s", found[0].Path)
+	}
+}
+
+func TestLoadAndEmit(t *testing.T) {
+	dir := t.TempDir()
+	install(t, dir, Prefix+"issues", script, 0755)
+	install(t, dir, Prefix+"broken", "#!/bin/sh\necho not json\n", 0755)
+
+	loaded := Load(context.Background(), Discover(dir))
+	if len(loaded) != 1 || loaded[0].Name != "issues" {
+		t.Fatalf("Expected only the working plugin to load, got: %+v", loaded)
+	}
+	if !loaded[0].Wants(EventSessionStart) || loaded[0].Wants(EventSessionPause) {
+		t.Errorf("Expected the plugin to want session.start but not session.pause, got: %+v", loaded[0].Manifest.Events)
+	}
+	if cmds := loaded[0].Manifest.Commands; len(cmds) != 1 || cmds[0].Name != "issue" {
+		t.Errorf("Expected the issue command, got: %+v", cmds)
+	}
+
+	conn := dbtest.Open(t)
+
+	task := tasks.NewTask("write report", 1500, false, false, time.Now())
+	if err := tasks.NewStore(conn).Insert(context.Background(), task); err != nil {
+		t.Fatal(err)
+	}
+
+	host := NewHost(conn, loaded)
+	host.Emit(context.Background(), EventSessionStart, task)
+
+	data, err := GetDataByTaskId(conn, task.TaskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 2 || data[0].Key != "issue" || data[0].Value != "ABC-1" || data[1].Key != "status" {
+		t.Fatalf("Expected the issue and status to be attached, got: %+v", data)
+	}
+
+	// finish is only answered when the plugin is sent the issue it attached on start.
+	host.Emit(context.Background(), EventSessionFinish, task)
+
+	data, err = GetDataByTaskId(conn, task.TaskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 1 || data[0].Key != "issue" {
+		t.Errorf("Expected the status to be removed, got: %+v", data)
+	}
+
+	var nilHost *Host
+	nilHost.Emit(context.Background(), EventSessionStart, task)
+}
+
+func TestCallError(t *testing.T) {
+	dir := t.TempDir()
+	install(t, dir, Prefix+"issues", script, 0755)
+
+	err := Plugin{Name: "issues", Path: filepath.Join(dir, Prefix+"issues")}.Call(context.Background(), "unknown", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "method not found") {
+		t.Errorf("Expected the plugin's error, got: %v", err)
+	}
+}
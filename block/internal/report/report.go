@@ -0,0 +1,334 @@
+// Package report aggregates task history into focus reports shared by `block report` and the /reports page.
+package report
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/goals"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/jmoiron/sqlx"
+)
+
+// Periods accepted by Options.Period.
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+type Options struct {
+	Period    string
+	Now       time.Time           // the report covers the period containing Now.
+	Calendar  calendar.Calendar   // groups tasks into days, midnight in Now's location if unset.
+	DailyGoal time.Duration       // focus time a day must reach to count towards a streak, 0 for no goal and no streaks.
+	DayGoals  map[time.Time]int64 // goal seconds recorded for past days, keyed by date. Other days use DailyGoal.
+}
+
+func (opts Options) calendar() calendar.Calendar {
+	if opts.Calendar == (calendar.Calendar{}) {
+		cal, _ := calendar.New(opts.Now.Location(), 0)
+		return cal
+	}
+	return opts.Calendar
+}
+
+// Stats are the headline numbers for a span of time.
+type Stats struct {
+	FocusSeconds          int64
+	Sessions              int
+	Completed             int
+	CompletionRate        float64 // percent of sessions that ran for their full planned time.
+	AverageSessionSeconds int64
+}
+
+// Total is the focus time within [Start, End).
+type Total struct {
+	Start        time.Time
+	End          time.Time
+	FocusSeconds int64
+	Sessions     int
+	DeltaSeconds int64 // change against the span of the same length immediately before.
+	MetGoal      bool
+}
+
+type BucketTotal struct {
+	BucketId     int64 // 0 for tasks without a bucket.
+	BucketName   string
+	Colour       string
+	FocusSeconds int64
+	Sessions     int
+	Percent      float64 // share of the period's focus time.
+}
+
+// Streak is a run of consecutive days meeting the daily goal.
+type Streak struct {
+	Days  int
+	Start time.Time
+	End   time.Time
+}
+
+type Report struct {
+	Period string
+	Start  time.Time
+	End    time.Time
+
+	Stats        Stats
+	Previous     Stats // the period before Start.
+	DeltaSeconds int64
+	DeltaPercent float64
+
+	Days    []Total
+	Weeks   []Total
+	Buckets []BucketTotal
+
+	DailyGoalSeconds int64
+	LongestStreak    Streak // across all history up to End.
+	CurrentStreak    Streak // ending today, or yesterday while today's goal is still open.
+}
+
+// Bounds returns the start and end of the period containing now.
+func Bounds(cal calendar.Calendar, period string, now time.Time) (time.Time, time.Time, error) {
+	switch period {
+	case PeriodDay:
+		start, end := cal.DayBounds(now)
+		return start, end, nil
+	case PeriodWeek:
+		start, end := cal.WeekBounds(now)
+		return start, end, nil
+	case PeriodMonth:
+		start, end := cal.MonthBounds(now)
+		return start, end, nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("Error, unknown period '%s', expected day, week or month", period)
+	}
+}
+
+// Generate loads the task history up to the end of the period and builds its report.
+func Generate(ctx context.Context, db *sqlx.DB, opts Options) (Report, error) {
+	_, end, err := Bounds(opts.calendar(), opts.Period, opts.Now)
+	if err != nil {
+		return Report{}, err
+	}
+
+	all, err := tasks.NewStore(db).Select(ctx, tasks.NewQuery().Until(end).OrderBy("date", false))
+	if err != nil {
+		return Report{}, err
+	}
+
+	allBuckets, err := buckets.GetAllBuckets(db)
+	if err != nil {
+		return Report{}, err
+	}
+
+	opts.DayGoals, err = goals.Recorded(ctx, db, opts.calendar())
+	if err != nil {
+		return Report{}, err
+	}
+
+	return Build(all, allBuckets, opts)
+}
+
+// Build computes a report from tasks, which should cover all history up to the end of the period.
+func Build(all []tasks.Task, allBuckets []buckets.Bucket, opts Options) (Report, error) {
+	cal := opts.calendar()
+
+	start, end, err := Bounds(cal, opts.Period, opts.Now)
+	if err != nil {
+		return Report{}, err
+	}
+
+	r := Report{
+		Period:           opts.Period,
+		Start:            start,
+		End:              end,
+		DailyGoalSeconds: int64(opts.DailyGoal.Seconds()),
+	}
+
+	daily := dailyTotals(all, cal)
+
+	previousStart, _, _ := Bounds(cal, opts.Period, start.Add(-time.Nanosecond))
+	r.Stats = stats(between(all, start, end))
+	r.Previous = stats(between(all, previousStart, start))
+	r.DeltaSeconds = r.Stats.FocusSeconds - r.Previous.FocusSeconds
+	if r.Previous.FocusSeconds > 0 {
+		r.DeltaPercent = float64(r.DeltaSeconds) / float64(r.Previous.FocusSeconds) * 100
+	}
+
+	for day := start; day.Before(end); {
+		_, next := cal.DayBounds(day)
+		t := spanTotal(all, day, next)
+		t.MetGoal = r.DailyGoalSeconds > 0 && t.FocusSeconds >= r.goalOn(opts, cal.Date(day))
+		r.Days = append(r.Days, t)
+		day = next
+	}
+
+	week, _ := cal.WeekBounds(start)
+	for week.Before(end) {
+		_, next := cal.WeekBounds(week)
+		r.Weeks = append(r.Weeks, spanTotal(all, week, next))
+		week = next
+	}
+
+	r.Buckets = bucketTotals(between(all, start, end), allBuckets, r.Stats.FocusSeconds)
+	if r.DailyGoalSeconds > 0 {
+		goalOn := func(date time.Time) int64 { return r.goalOn(opts, date) }
+		r.LongestStreak, r.CurrentStreak = streaks(daily, goalOn, cal.Date(opts.Now), cal)
+	}
+
+	return r, nil
+}
+
+// goalOn returns the goal recorded for a date, or the report's daily goal.
+func (r Report) goalOn(opts Options, date time.Time) int64 {
+	if goal, ok := opts.DayGoals[date]; ok && goal > 0 {
+		return goal
+	}
+	return r.DailyGoalSeconds
+}
+
+func between(all []tasks.Task, start, end time.Time) []tasks.Task {
+	var out []tasks.Task
+	for _, task := range all {
+		if !task.CreatedAt.Before(start) && task.CreatedAt.Before(end) {
+			out = append(out, task)
+		}
+	}
+	return out
+}
+
+// isSession reports whether a task was actually worked on, rather than started and abandoned instantly.
+func isSession(task tasks.Task) bool {
+	return task.FinishedAt.Valid || task.ActualDurationSeconds.Int64 > 0
+}
+
+func stats(all []tasks.Task) Stats {
+	var s Stats
+	for _, task := range all {
+		if !isSession(task) {
+			continue
+		}
+		s.Sessions++
+		s.FocusSeconds += task.ActualDurationSeconds.Int64
+		if task.Completed == 1 {
+			s.Completed++
+		}
+	}
+
+	if s.Sessions > 0 {
+		s.CompletionRate = float64(s.Completed) / float64(s.Sessions) * 100
+		s.AverageSessionSeconds = s.FocusSeconds / int64(s.Sessions)
+	}
+
+	return s
+}
+
+// spanTotal totals [start, end) and its delta against the span of equal length before it.
+func spanTotal(all []tasks.Task, start, end time.Time) Total {
+	current := stats(between(all, start, end))
+	previous := stats(between(all, start.Add(-end.Sub(start)), start))
+
+	return Total{
+		Start:        start,
+		End:          end,
+		FocusSeconds: current.FocusSeconds,
+		Sessions:     current.Sessions,
+		DeltaSeconds: current.FocusSeconds - previous.FocusSeconds,
+	}
+}
+
+func bucketTotals(all []tasks.Task, allBuckets []buckets.Bucket, focusSeconds int64) []BucketTotal {
+	byId := make(map[int64]*BucketTotal)
+	for _, task := range all {
+		if !isSession(task) {
+			continue
+		}
+
+		id := task.BucketId.Int64
+		if !task.BucketId.Valid {
+			id = 0
+		}
+
+		total, ok := byId[id]
+		if !ok {
+			total = &BucketTotal{BucketId: id, BucketName: "(none)"}
+			byId[id] = total
+		}
+		total.FocusSeconds += task.ActualDurationSeconds.Int64
+		total.Sessions++
+	}
+
+	for _, b := range allBuckets {
+		if total, ok := byId[b.BucketId]; ok {
+			total.BucketName = b.BucketName
+			total.Colour = b.Colour.String
+		}
+	}
+
+	var out []BucketTotal
+	for _, total := range byId {
+		if focusSeconds > 0 {
+			total.Percent = float64(total.FocusSeconds) / float64(focusSeconds) * 100
+		}
+		out = append(out, *total)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].FocusSeconds != out[j].FocusSeconds {
+			return out[i].FocusSeconds > out[j].FocusSeconds
+		}
+		return out[i].BucketName < out[j].BucketName
+	})
+
+	return out
+}
+
+// dailyTotals sums focus seconds per day, keyed by date.
+func dailyTotals(all []tasks.Task, cal calendar.Calendar) map[time.Time]int64 {
+	totals := make(map[time.Time]int64)
+	for _, task := range all {
+		if !isSession(task) {
+			continue
+		}
+		totals[cal.Date(task.CreatedAt)] += task.ActualDurationSeconds.Int64
+	}
+	return totals
+}
+
+// streaks finds the longest run of days meeting their goal up to today, and the run still open today.
+// Days are compared as dates, so a 23 or 25 hour day across a dst change still counts as consecutive.
+func streaks(daily map[time.Time]int64, goalOn func(date time.Time) int64, today time.Time, cal calendar.Calendar) (Streak, Streak) {
+	var days []time.Time
+	for day, seconds := range daily {
+		if seconds >= goalOn(day) && !day.After(today) {
+			days = append(days, day)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	var longest, run Streak
+	for _, day := range days {
+		if run.Days > 0 && cal.AddDays(run.End, 1).Equal(day) {
+			run.Days++
+			run.End = day
+		} else {
+			run = Streak{Days: 1, Start: day, End: day}
+		}
+
+		if run.Days > longest.Days {
+			longest = run
+		}
+	}
+
+	// today still counts as open, so a streak ending yesterday is current.
+	var current Streak
+	if run.Days > 0 && (run.End.Equal(today) || cal.AddDays(run.End, 1).Equal(today)) {
+		current = run
+	}
+
+	return longest, current
+}
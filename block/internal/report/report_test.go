@@ -0,0 +1,236 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/connorkuljis/block-cli/internal/tasks/taskstest"
+)
+
+var utc = time.UTC
+
+func day(d int) time.Time {
+	// March 2024, the 4th is a Monday.
+	return time.Date(2024, 3, d, 9, 0, 0, 0, utc)
+}
+
+func TestBuildWeek(t *testing.T) {
+	all := []tasks.Task{
+		// previous week
+		taskstest.Finished(day(1), 60, taskstest.InBucket(1)),
+		// this week
+		taskstest.Finished(day(4), 90, taskstest.InBucket(1)),
+		taskstest.Finished(day(4).Add(2*time.Hour), 30, taskstest.Stopped, taskstest.InBucket(2)),
+		taskstest.Finished(day(5), 120),
+		// next week is outside the report
+		taskstest.Finished(day(11), 60, taskstest.InBucket(1)),
+	}
+	allBuckets := []buckets.Bucket{{BucketId: 1, BucketName: "work"}, {BucketId: 2, BucketName: "study"}}
+
+	r, err := Build(all, allBuckets, Options{Period: PeriodWeek, Now: day(6), DailyGoal: 2 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.Start.Equal(time.Date(2024, 3, 4, 0, 0, 0, 0, utc)) {
+		t.Errorf("Expected week to start Monday 4th, got: %v", r.Start)
+	}
+
+	if r.Stats.FocusSeconds != 240*60 {
+		t.Errorf("Expected focus: %d, got: %d", 240*60, r.Stats.FocusSeconds)
+	}
+	if r.Stats.Sessions != 3 || r.Stats.Completed != 2 {
+		t.Errorf("Expected 3 sessions 2 completed, got: %d %d", r.Stats.Sessions, r.Stats.Completed)
+	}
+	if r.Stats.AverageSessionSeconds != 80*60 {
+		t.Errorf("Expected average: %d, got: %d", 80*60, r.Stats.AverageSessionSeconds)
+	}
+
+	if r.Previous.FocusSeconds != 60*60 || r.DeltaSeconds != 180*60 || r.DeltaPercent != 300 {
+		t.Errorf("Expected previous 3600 and delta 10800 (300%%), got: %d %d (%.0f%%)", r.Previous.FocusSeconds, r.DeltaSeconds, r.DeltaPercent)
+	}
+
+	if len(r.Days) != 7 {
+		t.Fatalf("Expected 7 days, got: %d", len(r.Days))
+	}
+	if !r.Days[0].MetGoal || !r.Days[1].MetGoal || r.Days[2].MetGoal {
+		t.Errorf("Expected Monday and Tuesday to meet the goal, got: %v %v %v", r.Days[0].MetGoal, r.Days[1].MetGoal, r.Days[2].MetGoal)
+	}
+
+	if len(r.Weeks) != 1 || r.Weeks[0].DeltaSeconds != 180*60 {
+		t.Errorf("Expected one week with delta 10800, got: %+v", r.Weeks)
+	}
+
+	wantBuckets := []string{"(none)", "work", "study"}
+	if len(r.Buckets) != len(wantBuckets) {
+		t.Fatalf("Expected buckets %v, got: %+v", wantBuckets, r.Buckets)
+	}
+	for i, name := range wantBuckets {
+		if r.Buckets[i].BucketName != name {
+			t.Errorf("Expected bucket %d: %s, got: %s", i, name, r.Buckets[i].BucketName)
+		}
+	}
+}
+
+func TestBuildMonthWeeksOverlapRange(t *testing.T) {
+	r, err := Build(nil, nil, Options{Period: PeriodMonth, Now: day(15)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Days) != 31 {
+		t.Errorf("Expected 31 days in March, got: %d", len(r.Days))
+	}
+	// March 2024 starts on a Friday, so the first week starts in February.
+	if len(r.Weeks) != 5 || r.Weeks[0].Start.Month() != time.February {
+		t.Errorf("Expected 5 weeks starting in February, got: %d starting %v", len(r.Weeks), r.Weeks[0].Start)
+	}
+}
+
+func TestStreaks(t *testing.T) {
+	all := []tasks.Task{
+		taskstest.Finished(day(1), 120),
+		taskstest.Finished(day(2), 120),
+		taskstest.Finished(day(3), 120),
+		taskstest.Finished(day(4), 30), // below goal
+		taskstest.Finished(day(5), 60),
+		taskstest.Finished(day(5).Add(time.Hour), 60), // two sessions reach the goal
+		taskstest.Finished(day(6), 120),
+	}
+
+	testCases := []struct {
+		name        string
+		now         time.Time
+		wantLongest int
+		wantCurrent int
+	}{
+		{name: "today met", now: day(6), wantLongest: 3, wantCurrent: 2},
+		{name: "today still open", now: day(7), wantLongest: 3, wantCurrent: 2},
+		{name: "broken yesterday", now: day(8), wantLongest: 3, wantCurrent: 0},
+		{name: "future days ignored", now: day(2), wantLongest: 2, wantCurrent: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var upToNow []tasks.Task
+			for _, task := range all {
+				if task.CreatedAt.Before(tc.now.Add(24 * time.Hour)) {
+					upToNow = append(upToNow, task)
+				}
+			}
+
+			r, err := Build(upToNow, nil, Options{Period: PeriodDay, Now: tc.now, DailyGoal: 2 * time.Hour})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.LongestStreak.Days != tc.wantLongest {
+				t.Errorf("Expected longest: %d, got: %d", tc.wantLongest, r.LongestStreak.Days)
+			}
+			if r.CurrentStreak.Days != tc.wantCurrent {
+				t.Errorf("Expected current: %d, got: %d", tc.wantCurrent, r.CurrentStreak.Days)
+			}
+		})
+	}
+}
+
+func TestNoDailyGoal(t *testing.T) {
+	all := []tasks.Task{
+		taskstest.Finished(day(5), 120),
+		taskstest.Finished(day(6), 120),
+	}
+
+	r, err := Build(all, nil, Options{Period: PeriodWeek, Now: day(6)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.DailyGoalSeconds != 0 || r.LongestStreak.Days != 0 || r.CurrentStreak.Days != 0 {
+		t.Errorf("Expected no goal and no streaks, got: goal %d, longest %d, current %d", r.DailyGoalSeconds, r.LongestStreak.Days, r.CurrentStreak.Days)
+	}
+	for _, d := range r.Days {
+		if d.MetGoal {
+			t.Errorf("Expected no day to meet a goal that is off, got: %v", d.Start)
+		}
+	}
+}
+
+func TestStreaksUseRecordedGoals(t *testing.T) {
+	all := []tasks.Task{
+		taskstest.Finished(day(4), 60),
+		taskstest.Finished(day(5), 60),
+		taskstest.Finished(day(6), 60),
+	}
+
+	// the goal was an hour on the 4th and 5th, before it was raised to two hours.
+	cal, _ := calendar.New(utc, 0)
+	recorded := map[time.Time]int64{
+		cal.Date(day(4)): 3600,
+		cal.Date(day(5)): 3600,
+	}
+
+	r, err := Build(all, nil, Options{Period: PeriodWeek, Now: day(6), DailyGoal: 2 * time.Hour, DayGoals: recorded})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.LongestStreak.Days != 2 || r.CurrentStreak.Days != 2 {
+		t.Errorf("Expected a two day streak under the recorded goal, got: longest %d, current %d", r.LongestStreak.Days, r.CurrentStreak.Days)
+	}
+	if !r.Days[0].MetGoal || !r.Days[1].MetGoal || r.Days[2].MetGoal {
+		t.Errorf("Expected the 4th and 5th to meet their recorded goal, got: %v %v %v", r.Days[0].MetGoal, r.Days[1].MetGoal, r.Days[2].MetGoal)
+	}
+}
+
+func TestStreakAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("Australia/Sydney")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// daylight saving ends on the 7th of April 2024, making the 7th a 25 hour day.
+	var all []tasks.Task
+	for d := 5; d <= 9; d++ {
+		all = append(all, taskstest.Finished(time.Date(2024, 4, d, 23, 30, 0, 0, loc).Add(-3*time.Hour), 120))
+	}
+
+	r, err := Build(all, nil, Options{Period: PeriodWeek, Now: time.Date(2024, 4, 9, 22, 0, 0, 0, loc), DailyGoal: 2 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.LongestStreak.Days != 5 || r.CurrentStreak.Days != 5 {
+		t.Errorf("Expected a 5 day streak across dst, got: longest %d current %d", r.LongestStreak.Days, r.CurrentStreak.Days)
+	}
+}
+
+func TestBuildDayStartHour(t *testing.T) {
+	cal, err := calendar.New(utc, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	all := []tasks.Task{
+		taskstest.Finished(day(4), 60),
+		// 01:30 on the 5th is still the 4th, the day rolls over at 04:00.
+		taskstest.Finished(time.Date(2024, 3, 5, 1, 30, 0, 0, utc), 60),
+		taskstest.Finished(time.Date(2024, 3, 5, 4, 0, 0, 0, utc), 30),
+	}
+
+	r, err := Build(all, nil, Options{Period: PeriodDay, Now: time.Date(2024, 3, 5, 2, 0, 0, 0, utc), Calendar: cal, DailyGoal: 2 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := time.Date(2024, 3, 4, 4, 0, 0, 0, utc); !r.Start.Equal(want) {
+		t.Errorf("Expected the day to start at %v, got: %v", want, r.Start)
+	}
+	if r.Stats.FocusSeconds != 120*60 || !r.CurrentStreak.End.Equal(time.Date(2024, 3, 4, 0, 0, 0, 0, utc)) {
+		t.Errorf("Expected two hours on the 4th meeting the goal, got: %d, streak %+v", r.Stats.FocusSeconds, r.CurrentStreak)
+	}
+}
+
+func TestBoundsUnknownPeriod(t *testing.T) {
+	if _, _, err := Bounds(Options{Now: day(1)}.calendar(), "year", day(1)); err == nil {
+		t.Error("Expected error for unknown period, got nil")
+	}
+}
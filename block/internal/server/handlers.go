@@ -1,14 +1,27 @@
 package server
 
 import (
+	"errors"
 	"fmt"
+	"html/template"
+	"log"
 	"net/http"
 	"strconv"
-	"text/template"
+	"strings"
 	"time"
 
 	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/estimates"
+	"github.com/connorkuljis/block-cli/internal/goals"
+	"github.com/connorkuljis/block-cli/internal/ical"
+	"github.com/connorkuljis/block-cli/internal/idle"
+	"github.com/connorkuljis/block-cli/internal/notes"
+	"github.com/connorkuljis/block-cli/internal/plan"
+	"github.com/connorkuljis/block-cli/internal/report"
+	"github.com/connorkuljis/block-cli/internal/tags"
 	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/connorkuljis/block-cli/internal/templates"
 )
 
 var funcMap = template.FuncMap{
@@ -18,6 +31,16 @@ var funcMap = template.FuncMap{
 		seconds := secs % 60
 		return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
 	},
+	"PrintRatio": func(ratio float64) string {
+		return fmt.Sprintf("%.0f%%", ratio*100)
+	},
+	"PrintSignedHHMMSS": func(secs int64) string {
+		sign := "+"
+		if secs < 0 {
+			sign, secs = "-", -secs
+		}
+		return fmt.Sprintf("%s%02d:%02d:%02d", sign, secs/3600, (secs%3600)/60, secs%60)
+	},
 	"ParseTimeHHMMSS": func(secs int64) map[string]int64 {
 		hours := secs / 3600
 		minutes := (secs % 3600) / 60
@@ -28,6 +51,16 @@ var funcMap = template.FuncMap{
 			"Seconds": seconds,
 		}
 	},
+	// Highlight escapes a search match and marks its matched words.
+	"Highlight": func(s string) template.HTML {
+		return template.HTML(tasks.Highlight(s, template.HTMLEscapeString, func(s string) string {
+			return "<mark>" + template.HTMLEscapeString(s) + "</mark>"
+		}))
+	},
+	// InZone shows a time in the configured calendar timezone.
+	"InZone": func(t time.Time) time.Time {
+		return calendar.Default().In(t)
+	},
 }
 
 // Routes instatiates http Handlers and associated patterns on the server.
@@ -37,8 +70,20 @@ func (s *Server) Routes() {
 	s.MuxRouter.HandleFunc("/tasks", s.HandleTasks())
 	s.MuxRouter.HandleFunc("/tasks/show/{taskId}", s.HandleShowTasks())
 	s.MuxRouter.HandleFunc("/tasks/edit/{taskId}", s.HandleEditTasks())
+	s.MuxRouter.HandleFunc("/tasks/log", s.HandleLogTask())
+	s.MuxRouter.HandleFunc("POST /tasks/delete/{taskId}", s.HandleDeleteTask())
+	s.MuxRouter.HandleFunc("POST /tasks/restore/{taskId}", s.HandleRestoreTask())
 	s.MuxRouter.HandleFunc("/daily/", s.HandleDaily())
 	s.MuxRouter.HandleFunc("/buckets", s.HandleBuckets())
+	s.MuxRouter.HandleFunc("/plan", s.HandlePlan())
+	s.MuxRouter.HandleFunc("POST /plan/reorder", s.HandleReorderPlan())
+	s.MuxRouter.HandleFunc("POST /plan/delete/{planId}", s.HandleDeletePlanItem())
+	s.MuxRouter.HandleFunc("/templates", s.HandleTemplates())
+	s.MuxRouter.HandleFunc("GET /reports", s.HandleReports())
+	s.MuxRouter.HandleFunc("GET /estimates", s.HandleEstimates())
+	s.MuxRouter.HandleFunc("GET /calendar.ics", s.HandleCalendar())
+	s.MuxRouter.HandleFunc("GET /api/summary", s.HandleSummaryAPI())
+	s.MuxRouter.HandleFunc("POST /templates/delete/{name}", s.HandleDeleteTemplate())
 }
 
 func (s *Server) HandleHome() http.HandlerFunc {
@@ -63,9 +108,9 @@ func (s *Server) HandleEditTasks() http.HandlerFunc {
 
 		switch r.Method {
 		case "GET":
-			task, err := tasks.GetTaskByID(s.Db, int64(taskId))
+			task, err := s.Tasks.Get(r.Context(), taskId)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusBadRequest)
+				http.Error(w, err.Error(), statusFor(err))
 				return
 			}
 			parcel := map[string]interface{}{"Task": task}
@@ -97,11 +142,14 @@ func (s *Server) HandleEditTasks() http.HandlerFunc {
 				http.Error(w, "Error, minutes or seconds value must not exceed 59", http.StatusBadRequest)
 			}
 			totalSeconds := int64(hours*3600 + minutes*60 + seconds)
-			err = tasks.UpdateTaskFinishById(s.Db, int64(taskId), taskName, totalSeconds)
+			err = s.Tasks.Edit(r.Context(), taskId, taskName, totalSeconds, tasks.SourceWeb)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				http.Error(w, err.Error(), statusFor(err))
 				return
 			}
+			if task, err := s.Tasks.Get(r.Context(), taskId); err == nil {
+				s.updateDailyGoals(r, []tasks.Task{task})
+			}
 			http.Redirect(w, r, fmt.Sprintf("/tasks/show/%d", taskId), http.StatusSeeOther)
 			return
 		default:
@@ -111,6 +159,101 @@ func (s *Server) HandleEditTasks() http.HandlerFunc {
 	}
 }
 
+// HandleLogTask records a completed task for time worked without a running session.
+// An overlapping or invalid entry redisplays the form with the error.
+func (s *Server) HandleLogTask() http.HandlerFunc {
+	logPage := []string{"root.html", "head.html", "layout.html", "header.html", "nav.html", "footer.html", "log_task.html"}
+
+	t := s.ParseTemplates("log-task", funcMap, logPage...)
+
+	const startedAtFormat = "2006-01-02T15:04"
+
+	render := func(w http.ResponseWriter, status int, parcel map[string]any) {
+		active, err := buckets.GetActiveBuckets(s.Db)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		parcel["Buckets"] = active
+
+		htmlBytes, err := SafeTmplExec(t, "root", parcel)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(status)
+		SendHTML(w, htmlBytes)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			render(w, http.StatusOK, map[string]any{
+				"Error":     "",
+				"TaskName":  "",
+				"StartedAt": s.Calendar.In(time.Now().Add(-30 * time.Minute)).Format(startedAtFormat),
+				"Minutes":   "30",
+				"BucketId":  "",
+			})
+		case "POST":
+			r.ParseForm()
+			parcel := map[string]any{
+				"Error":     "",
+				"TaskName":  r.FormValue("task_name"),
+				"StartedAt": r.FormValue("started_at"),
+				"Minutes":   r.FormValue("minutes"),
+				"BucketId":  r.FormValue("bucket_id"),
+			}
+
+			task, err := s.parseLoggedTask(r)
+			if err == nil {
+				err = s.Tasks.Log(r.Context(), task)
+			}
+			if err != nil {
+				parcel["Error"] = err.Error()
+				render(w, http.StatusUnprocessableEntity, parcel)
+				return
+			}
+
+			s.updateDailyGoals(r, []tasks.Task{*task})
+
+			http.Redirect(w, r, fmt.Sprintf("/tasks/show/%d", task.TaskId), http.StatusSeeOther)
+		default:
+			http.Error(w, "Unsupported request type", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func (s *Server) parseLoggedTask(r *http.Request) (*tasks.Task, error) {
+	startedAt, err := time.ParseInLocation("2006-01-02T15:04", r.FormValue("started_at"), s.Calendar.Location())
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing start time: %w", err)
+	}
+
+	minutes, err := strconv.ParseFloat(r.FormValue("minutes"), 64)
+	if err != nil || minutes <= 0 {
+		return nil, errors.New("Error, minutes must be a positive number")
+	}
+
+	duration := time.Duration(minutes * float64(time.Minute))
+	if startedAt.Add(duration).After(time.Now()) {
+		return nil, errors.New("Error, logged time must not finish in the future")
+	}
+
+	task := tasks.NewLoggedTask(strings.TrimSpace(r.FormValue("task_name")), duration, startedAt)
+
+	if strBucketId := r.FormValue("bucket_id"); strBucketId != "" {
+		bucket, err := buckets.ResolveActive(s.Db, strBucketId)
+		if err != nil {
+			return nil, err
+		}
+		task.AddBucketTag(bucket.BucketId)
+	}
+
+	return task, nil
+}
+
 func (s *Server) HandleShowTasks() http.HandlerFunc {
 	page := []string{
 		"root.html",
@@ -132,13 +275,50 @@ func (s *Server) HandleShowTasks() http.HandlerFunc {
 			return
 		}
 
-		task, err := tasks.GetTaskByID(s.Db, int64(taskId))
+		task, err := s.Tasks.Get(r.Context(), int64(taskId))
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			http.Error(w, err.Error(), statusFor(err))
+			return
+		}
+
+		idleIntervals, err := idle.GetIntervalsByTaskId(s.Db, task.TaskId)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		taskNotes, err := notes.GetNotesByTaskId(s.Db, task.TaskId)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		interruptions, err := notes.GetInterruptionsByTaskId(s.Db, task.TaskId)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		taskTags, err := tags.GetTagsByTaskId(s.Db, task.TaskId)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		parcel := map[string]interface{}{"Task": task}
+		audit, err := s.Tasks.Audit(r.Context(), task.TaskId)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		parcel := map[string]interface{}{
+			"Task":          task,
+			"Audit":         audit,
+			"Tags":          taskTags,
+			"IdleIntervals": idleIntervals,
+			"Notes":         taskNotes,
+			"Interruptions": interruptions,
+		}
 
 		htmlBytes, err := SafeTmplExec(t, "root", parcel)
 		if err != nil {
@@ -149,6 +329,54 @@ func (s *Server) HandleShowTasks() http.HandlerFunc {
 		SendHTML(w, htmlBytes)
 	}
 }
+
+// updateDailyGoals re-records the daily goal for the days holding changed tasks. The change is
+// already saved, so a failure is only logged.
+func (s *Server) updateDailyGoals(r *http.Request, changed []tasks.Task) {
+	if err := goals.UpdateDays(r.Context(), s.Db, s.Calendar, s.Goals, changed, time.Now()); err != nil {
+		log.Println(err)
+	}
+}
+
+// HandleDeleteTask soft deletes a task, it stays viewable and can be restored from its page.
+func (s *Server) HandleDeleteTask() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		taskId, err := strconv.ParseInt(r.PathValue("taskId"), 10, 64)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		deleted, err := s.Tasks.Delete(r.Context(), []int64{taskId}, tasks.SourceWeb, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), statusFor(err))
+			return
+		}
+		s.updateDailyGoals(r, deleted)
+
+		http.Redirect(w, r, fmt.Sprintf("/tasks/show/%d", taskId), http.StatusSeeOther)
+	}
+}
+
+func (s *Server) HandleRestoreTask() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		taskId, err := strconv.ParseInt(r.PathValue("taskId"), 10, 64)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		restored, err := s.Tasks.Restore(r.Context(), []int64{taskId}, tasks.SourceWeb)
+		if err != nil {
+			http.Error(w, err.Error(), statusFor(err))
+			return
+		}
+		s.updateDailyGoals(r, restored)
+
+		http.Redirect(w, r, fmt.Sprintf("/tasks/show/%d", taskId), http.StatusSeeOther)
+	}
+}
+
 func (s *Server) HandleDaily() http.HandlerFunc {
 	templates := []string{
 		"root.html",
@@ -170,21 +398,21 @@ func (s *Server) HandleDaily() http.HandlerFunc {
 		var dateCurrent time.Time
 		if timestamp != "" {
 			var err error
-			dateCurrent, err = time.Parse(format, timestamp)
+			dateCurrent, err = s.Calendar.ParseDate(timestamp)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
 			}
 		} else {
-			dateCurrent = time.Now().Truncate(24 * time.Hour)
+			dateCurrent = s.Calendar.Today(time.Now())
 		}
 
-		datePrev := dateCurrent.Add(-24 * time.Hour)
+		datePrev := s.Calendar.AddDays(dateCurrent, -1)
 
 		// TODO: validate if overflows current date. if so, don't display the control in the html
-		dateNext := dateCurrent.Add(24 * time.Hour)
+		dateNext := s.Calendar.AddDays(dateCurrent, 1)
 
-		tasks, err := tasks.GetTasksByDate(s.Db, dateCurrent)
+		tasks, err := s.Tasks.Select(r.Context(), tasks.NewQuery().OnDay(s.Calendar, s.Calendar.Start(dateCurrent)))
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -192,12 +420,27 @@ func (s *Server) HandleDaily() http.HandlerFunc {
 
 		taskSummary := summariseTasks(tasks)
 
+		tagSummary, err := tags.Summarise(s.Db, tasks)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		allBuckets, err := buckets.GetAllBuckets(s.Db)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
 		parcel := map[string]any{
 			"Tasks":       tasks,
+			"TagSummary":  tagSummary,
+			"Goal":        goals.Compute(tasks, allBuckets, s.Goals, dateCurrent),
 			"DateCurrent": dateCurrent.Format(format),
 			"DatePrev":    datePrev.Format(format),
 			"DateNext":    dateNext.Format(format),
 			"TaskSummary": taskSummary,
+			"Search":      "",
 		}
 
 		htmlBytes, err := SafeTmplExec(t, "root", parcel)
@@ -221,24 +464,61 @@ func (s *Server) HandleBuckets() http.HandlerFunc {
 		"buckets.html",
 	}
 
-	bucketsTemplate := s.ParseTemplates("index", nil, bucketsTemplateFragments...)
+	bucketsTemplate := s.ParseTemplates("index", funcMap, bucketsTemplateFragments...)
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		buckets, err := buckets.GetAllBuckets(s.Db)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
+		switch r.Method {
+		case "GET":
+			all, err := buckets.GetAllBuckets(s.Db)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
 
-		parcel := map[string]any{"Buckets": buckets}
+			weeklyProgress, err := buckets.GetWeeklyProgress(s.Db, time.Now())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
 
-		htmlBytes, err := SafeTmplExec(bucketsTemplate, "root", parcel)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
+			progress := make(map[int64]buckets.Progress)
+			for _, p := range weeklyProgress {
+				progress[p.Bucket.BucketId] = p
+			}
 
-		SendHTML(w, htmlBytes)
+			parcel := map[string]any{"Buckets": all, "Progress": progress}
+
+			htmlBytes, err := SafeTmplExec(bucketsTemplate, "root", parcel)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			SendHTML(w, htmlBytes)
+		case "POST":
+			r.ParseForm()
+			bucket := buckets.NewBucket(r.FormValue("bucket_name"))
+			bucket.SetColour(r.FormValue("colour"))
+
+			if strGoalHours := r.FormValue("goal_hours"); strGoalHours != "" {
+				goalHours, err := strconv.ParseFloat(strGoalHours, 64)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				bucket.SetWeeklyGoal(time.Duration(goalHours * float64(time.Hour)))
+			}
+
+			err := buckets.InsertBucket(s.Db, bucket)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			http.Redirect(w, r, "/buckets", http.StatusSeeOther)
+		default:
+			fmt.Fprintln(w, "Unsupported request type")
+		}
 	}
 }
 
@@ -251,6 +531,7 @@ func (s *Server) HandleTasks() http.HandlerFunc {
 		"footer.html",
 		"nav.html",
 		"form-get-tasks.html",
+		"template-picker.html",
 		"tasks-table.html",
 		"index.html",
 	}
@@ -272,17 +553,60 @@ func (s *Server) HandleTasks() http.HandlerFunc {
 			}
 		}
 
-		tasks, err := tasks.GetRecentTasks(s.Db, time.Now().Truncate(24*time.Hour), daysBack)
+		selectedTag := tags.Normalise(r.URL.Query().Get("tag"))
+		search := strings.TrimSpace(r.URL.Query().Get("q"))
+
+		since := s.Calendar.Start(s.Calendar.AddDays(s.Calendar.Today(time.Now()), -daysBack))
+		var matches []tasks.Match
+		var taskList []tasks.Task
+		var err error
+		if search != "" {
+			// a search looks through all history, not just the selected days.
+			matches, err = s.Tasks.Search(r.Context(), search, tasks.NewQuery().Tagged(selectedTag).Limit(100))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			for _, m := range matches {
+				taskList = append(taskList, m.Task)
+			}
+		} else {
+			taskList, err = s.Tasks.Select(r.Context(), tasks.NewQuery().From(since).Tagged(selectedTag))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		taskSummary := summariseTasks(taskList)
+
+		tagSummary, err := tags.Summarise(s.Db, taskList)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		taskSummary := summariseTasks(tasks)
+		allTags, err := tags.GetAllTags(s.Db)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		templates, err := templates.GetAllTemplates(s.Db)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 
 		parcel := map[string]any{
-			"Tasks":       tasks,
+			"Tasks":       taskList,
 			"TaskSummary": taskSummary,
+			"TagSummary":  tagSummary,
+			"Tags":        allTags,
+			"SelectedTag": selectedTag,
+			"DaysBack":    daysBack,
+			"Search":      search,
+			"Matches":     matches,
+			"Templates":   templates,
 		}
 
 		var htmlBytes []byte
@@ -305,6 +629,451 @@ func (s *Server) HandleTasks() http.HandlerFunc {
 	}
 }
 
+func (s *Server) HandleTemplates() http.HandlerFunc {
+	templatesPage := []string{
+		"root.html",
+		"layout.html",
+		"head.html",
+		"header.html",
+		"footer.html",
+		"nav.html",
+		"templates.html",
+	}
+
+	t := s.ParseTemplates("templates", funcMap, templatesPage...)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			all, err := templates.GetAllTemplates(s.Db)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			buckets, err := buckets.GetActiveBuckets(s.Db)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			parcel := map[string]any{"Templates": all, "Buckets": buckets}
+
+			htmlBytes, err := SafeTmplExec(t, "root", parcel)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			SendHTML(w, htmlBytes)
+		case "POST":
+			r.ParseForm()
+			name := strings.TrimSpace(r.FormValue("template_name"))
+			if name == "" {
+				http.Error(w, "Error, template name is required", http.StatusBadRequest)
+				return
+			}
+
+			minutes, err := strconv.ParseFloat(r.FormValue("minutes"), 64)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			newTemplate := templates.NewTemplate(name, r.FormValue("task_name"), int64(minutes*60), r.FormValue("blocker") == "on", r.FormValue("capture") == "on", time.Now())
+
+			if strBucketId := r.FormValue("bucket_id"); strBucketId != "" {
+				bucketId, err := strconv.ParseInt(strBucketId, 10, 64)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				newTemplate.AddBucketTag(bucketId)
+			}
+
+			err = templates.InsertTemplate(s.Db, newTemplate)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			http.Redirect(w, r, "/templates", http.StatusSeeOther)
+		default:
+			fmt.Fprintln(w, "Unsupported request type")
+		}
+	}
+}
+
+// HandlePlan lists the planned tasks for reordering, adds new ones and compares today's plan with the time spent.
+func (s *Server) HandlePlan() http.HandlerFunc {
+	planPage := []string{
+		"root.html",
+		"layout.html",
+		"head.html",
+		"header.html",
+		"footer.html",
+		"nav.html",
+		"plan.html",
+	}
+
+	t := s.ParseTemplates("plan", funcMap, planPage...)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			backlog, err := plan.Backlog(s.Db)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			allBuckets, err := buckets.GetAllBuckets(s.Db)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			bucketNames := make(map[int64]string)
+			var active []buckets.Bucket
+			for _, bucket := range allBuckets {
+				bucketNames[bucket.BucketId] = bucket.BucketName
+				if !bucket.ArchivedAt.Valid {
+					active = append(active, bucket)
+				}
+			}
+
+			review, err := plan.ReviewDay(r.Context(), s.Db, s.Calendar, time.Now())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			parcel := map[string]any{
+				"Backlog":     backlog,
+				"Buckets":     active,
+				"BucketNames": bucketNames,
+				"Review":      review,
+			}
+
+			htmlBytes, err := SafeTmplExec(t, "root", parcel)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			SendHTML(w, htmlBytes)
+		case "POST":
+			r.ParseForm()
+			name := strings.TrimSpace(r.FormValue("task_name"))
+			if name == "" {
+				http.Error(w, "Error, task name is required", http.StatusBadRequest)
+				return
+			}
+
+			minutes, err := strconv.ParseFloat(r.FormValue("minutes"), 64)
+			if err != nil || minutes <= 0 {
+				http.Error(w, "Error, minutes must be a positive number", http.StatusBadRequest)
+				return
+			}
+
+			item := plan.NewItem(name, int64(minutes*60), s.Calendar.Today(time.Now()), time.Now())
+
+			if strBucketId := r.FormValue("bucket_id"); strBucketId != "" {
+				bucketId, err := strconv.ParseInt(strBucketId, 10, 64)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				item.AddBucketTag(bucketId)
+			}
+
+			if err := plan.Insert(s.Db, item); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			http.Redirect(w, r, "/plan", http.StatusSeeOther)
+		default:
+			fmt.Fprintln(w, "Unsupported request type")
+		}
+	}
+}
+
+// HandleReorderPlan sets the plan order from the plan_id form values, first to last.
+func (s *Server) HandleReorderPlan() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+
+		var planIds []int64
+		for _, strPlanId := range r.Form["plan_id"] {
+			planId, err := strconv.ParseInt(strPlanId, 10, 64)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			planIds = append(planIds, planId)
+		}
+
+		if err := plan.Reorder(s.Db, planIds); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) HandleDeletePlanItem() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		planId, err := strconv.ParseInt(r.PathValue("planId"), 10, 64)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := plan.Remove(s.Db, planId); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		http.Redirect(w, r, "/plan", http.StatusSeeOther)
+	}
+}
+
+func (s *Server) HandleDeleteTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, err := templates.DeleteTemplateByName(s.Db, r.PathValue("name"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/templates", http.StatusSeeOther)
+	}
+}
+
+// HandleReports shows focus totals, bucket breakdowns and streaks for a day, week or month.
+//
+// Query parameters: period (day, week or month, default week), date (yyyy-mm-dd, default today)
+// and goal (daily goal in minutes, default 120).
+func (s *Server) HandleReports() http.HandlerFunc {
+	reportsPage := []string{
+		"root.html",
+		"layout.html",
+		"head.html",
+		"header.html",
+		"footer.html",
+		"nav.html",
+		"reports.html",
+	}
+
+	t := s.ParseTemplates("reports", funcMap, reportsPage...)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts := report.Options{
+			Period:    report.PeriodWeek,
+			Now:       time.Now(),
+			Calendar:  s.Calendar,
+			DailyGoal: s.Goals.Daily,
+		}
+
+		if period := r.URL.Query().Get("period"); period != "" {
+			opts.Period = period
+		}
+
+		if date := r.URL.Query().Get("date"); date != "" {
+			day, err := s.Calendar.ParseDate(date)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			opts.Now = s.Calendar.Start(day)
+		}
+
+		if strGoal := r.URL.Query().Get("goal"); strGoal != "" {
+			goalMinutes, err := strconv.Atoi(strGoal)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			opts.DailyGoal = time.Duration(goalMinutes) * time.Minute
+		}
+
+		rep, err := report.Generate(r.Context(), s.Db, opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		previous, _, _ := report.Bounds(s.Calendar, opts.Period, rep.Start.Add(-time.Nanosecond))
+
+		parcel := map[string]any{
+			"Report":      rep,
+			"Periods":     []string{report.PeriodDay, report.PeriodWeek, report.PeriodMonth},
+			"GoalMinutes": int64(opts.DailyGoal.Minutes()),
+			"PrevDate":    previous.Format("2006-01-02"),
+			"NextDate":    rep.End.Format("2006-01-02"),
+		}
+
+		htmlBytes, err := SafeTmplExec(t, "root", parcel)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		SendHTML(w, htmlBytes)
+	}
+}
+
+// HandleEstimates compares planned durations with the time work took.
+//
+// Query parameters: weeks (default 12) and bucket (name or id).
+func (s *Server) HandleEstimates() http.HandlerFunc {
+	estimatesPage := []string{
+		"root.html",
+		"layout.html",
+		"head.html",
+		"header.html",
+		"footer.html",
+		"nav.html",
+		"estimates.html",
+	}
+
+	t := s.ParseTemplates("estimates", funcMap, estimatesPage...)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		weeks := 12
+		if strWeeks := r.URL.Query().Get("weeks"); strWeeks != "" {
+			var err error
+			weeks, err = strconv.Atoi(strWeeks)
+			if err != nil || weeks < 1 {
+				http.Error(w, "Error, weeks must be 1 or more", http.StatusBadRequest)
+				return
+			}
+		}
+
+		query := tasks.NewQuery()
+		bucketName := r.URL.Query().Get("bucket")
+		if bucketName != "" {
+			bucket, err := buckets.Resolve(s.Db, bucketName)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			query.Bucket(bucket.BucketId)
+		}
+
+		a, err := estimates.Generate(r.Context(), s.Db, s.Calendar, weeks, time.Now(), query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		allBuckets, err := buckets.GetAllBuckets(s.Db)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		parcel := map[string]any{
+			"Analysis":   a,
+			"Weeks":      weeks,
+			"Bucket":     bucketName,
+			"AllBuckets": allBuckets,
+		}
+
+		htmlBytes, err := SafeTmplExec(t, "root", parcel)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		SendHTML(w, htmlBytes)
+	}
+}
+
+// HandleCalendar serves focus sessions as a subscribable iCalendar feed.
+//
+// Query parameters: bucket (name or id), from and to (yyyy-mm-dd, inclusive).
+func (s *Server) HandleCalendar() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := tasks.NewQuery().OrderBy("date", false)
+
+		if nameOrId := r.URL.Query().Get("bucket"); nameOrId != "" {
+			bucket, err := buckets.Resolve(s.Db, nameOrId)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			query.Bucket(bucket.BucketId)
+		}
+
+		if from := r.URL.Query().Get("from"); from != "" {
+			day, err := s.Calendar.ParseDate(from)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			query.From(s.Calendar.Start(day))
+		}
+
+		if to := r.URL.Query().Get("to"); to != "" {
+			day, err := s.Calendar.ParseDate(to)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			query.Until(s.Calendar.Start(s.Calendar.AddDays(day, 1)))
+		}
+
+		events, err := ical.TaskEvents(r.Context(), s.Db, query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `inline; filename="block.ics"`)
+		if err := ical.Write(w, "block", events); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// HandleSummaryAPI reports task totals as json, aggregated overall and per tag.
+//
+// Query parameters: past (days, default 7) and tag (repeatable, tasks must carry every tag).
+func (s *Server) HandleSummaryAPI() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		daysBack := 7
+		if strPastDays := r.URL.Query().Get("past"); strPastDays != "" {
+			parsedDays, err := strconv.Atoi(strPastDays)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			daysBack = parsedDays
+		}
+
+		since := s.Calendar.Start(s.Calendar.AddDays(s.Calendar.Today(time.Now()), -daysBack))
+		filtered, err := s.Tasks.Select(r.Context(), tasks.NewQuery().From(since).Tagged(r.URL.Query()["tag"]...))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		tagSummary, err := tags.Summarise(s.Db, filtered)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		SendJSON(w, map[string]any{
+			"Summary": summariseTasks(filtered),
+			"Tags":    tagSummary,
+		})
+	}
+}
+
 type TasksSummary struct {
 	TaskCount                    int64
 	TaskTotalSeconds             int64
@@ -2,6 +2,8 @@ package server
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io/fs"
@@ -9,6 +11,10 @@ import (
 	"net/http"
 	"path/filepath"
 
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/config"
+	"github.com/connorkuljis/block-cli/internal/goals"
+	"github.com/connorkuljis/block-cli/internal/tasks"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -20,6 +26,9 @@ type Server struct {
 	MuxRouter            *http.ServeMux
 	TemplateMap          map[string]string
 	Db                   *sqlx.DB
+	Tasks                tasks.TaskStore
+	Calendar             calendar.Calendar
+	Goals                goals.Goals
 
 	Port string
 }
@@ -28,7 +37,7 @@ type Server struct {
 //
 // Server encapsulates all dependencies for the web Server.
 // HTTP handlers access information via receiver types.
-func NewServer(fileSystem fs.FS, db *sqlx.DB, port, templatesPath, staticPath string) (*Server, error) {
+func NewServer(fileSystem fs.FS, db *sqlx.DB, cfg config.Config, port, templatesPath, staticPath string) (*Server, error) {
 	templateMap, err := BuildTemplateMap(fileSystem, templatesPath)
 	if err != nil {
 		return nil, err
@@ -37,6 +46,10 @@ func NewServer(fileSystem fs.FS, db *sqlx.DB, port, templatesPath, staticPath st
 	if err != nil {
 		return nil, err
 	}
+	dailyGoals, err := goals.NewFromConfig(cfg.Goals)
+	if err != nil {
+		return nil, err
+	}
 	s := &Server{
 		FileSystem:           fileSystem,
 		MuxRouter:            http.NewServeMux(),
@@ -44,6 +57,9 @@ func NewServer(fileSystem fs.FS, db *sqlx.DB, port, templatesPath, staticPath st
 		StaticContentHandler: http.FileServer(http.FS(scfs)),
 		TemplateMap:          templateMap,
 		Db:                   db,
+		Tasks:                tasks.NewStore(db),
+		Calendar:             calendar.Default(),
+		Goals:                dailyGoals,
 	}
 	return s, nil
 }
@@ -68,6 +84,10 @@ func BuildTemplateMap(filesystem fs.FS, templatesPath string) (map[string]string
 //
 // template files are provided as strings to be parsed from the filesystem
 func (s *Server) ParseTemplates(name string, funcs template.FuncMap, templateKeys ...string) *template.Template {
+	return parseTemplates(s.FileSystem, s.TemplateMap, name, funcs, templateKeys...)
+}
+
+func parseTemplates(fileSystem fs.FS, templateMap map[string]string, name string, funcs template.FuncMap, templateKeys ...string) *template.Template {
 	tmpl := template.New(name)
 	if funcs != nil {
 		tmpl.Funcs(funcs)
@@ -75,14 +95,14 @@ func (s *Server) ParseTemplates(name string, funcs template.FuncMap, templateKey
 
 	var templatePaths []string
 	for _, key := range templateKeys {
-		path, ok := s.TemplateMap[key]
+		path, ok := templateMap[key]
 		if !ok {
 			log.Fatalf("Error parsing template [%s], key does not exist [%s]", name, key)
 		}
 		templatePaths = append(templatePaths, path)
 	}
 
-	tmpl, err := tmpl.ParseFS(s.FileSystem, templatePaths...)
+	tmpl, err := tmpl.ParseFS(fileSystem, templatePaths...)
 	if err != nil {
 		err = fmt.Errorf("Error building template name='%s': %w", name, err)
 		log.Fatal(err)
@@ -103,6 +123,14 @@ func (s *Server) ListenAndServe() error {
 //
 //
 
+// statusFor maps a store error to a response status, 404 for a missing task and 400 otherwise.
+func statusFor(err error) int {
+	if errors.Is(err, tasks.ErrNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
+
 // safeTmplParse executes a given template to a bytes buffer. It returns the resulting buffer or nil, err if any error occurred.
 //
 // Templates are checked for missing keys to prevent partial data being written to the writer.
@@ -125,3 +153,11 @@ func SendHTML(w http.ResponseWriter, bufBytes []byte) {
 		log.Println(err)
 	}
 }
+
+// SendJSON writes data to a response writer as json
+func SendJSON(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/report"
+	"github.com/connorkuljis/block-cli/internal/team"
+)
+
+// maxTeamPushBytes bounds the size of a push from a member.
+const maxTeamPushBytes = 8 << 20
+
+// TeamServer is the shared server members push finished sessions to, with dashboards of the
+// team's focus time. Members authenticate with their token: as a bearer token when pushing,
+// and as the password with their name as the username in the browser.
+type TeamServer struct {
+	FileSystem           fs.FS
+	StaticContentHandler http.Handler
+	MuxRouter            *http.ServeMux
+	TemplateMap          map[string]string
+	Team                 *team.Store
+	Calendar             calendar.Calendar
+
+	Addr string
+}
+
+func NewTeamServer(fileSystem fs.FS, store *team.Store, addr, templatesPath, staticPath string) (*TeamServer, error) {
+	templateMap, err := BuildTemplateMap(fileSystem, templatesPath)
+	if err != nil {
+		return nil, err
+	}
+	scfs, err := fs.Sub(fileSystem, staticPath)
+	if err != nil {
+		return nil, err
+	}
+	s := &TeamServer{
+		FileSystem:           fileSystem,
+		StaticContentHandler: http.FileServer(http.FS(scfs)),
+		MuxRouter:            http.NewServeMux(),
+		TemplateMap:          templateMap,
+		Team:                 store,
+		Calendar:             calendar.Default(),
+		Addr:                 addr,
+	}
+	return s, nil
+}
+
+// Routes instatiates http Handlers and associated patterns on the team server.
+func (s *TeamServer) Routes() {
+	s.MuxRouter.Handle("/static/", http.StripPrefix("/static/", s.StaticContentHandler))
+	s.MuxRouter.HandleFunc("GET /{$}", s.requireMember(s.HandleDashboard()))
+	s.MuxRouter.HandleFunc("GET /members/{name}", s.requireMember(s.HandleDashboard()))
+	s.MuxRouter.HandleFunc("POST /api/sessions", s.HandlePushSessions())
+}
+
+func (s *TeamServer) ListenAndServe() error {
+	log.Println("[ 💿 Spinning up team server on http://" + s.Addr + " ]")
+	if err := http.ListenAndServe(s.Addr, s.MuxRouter); err != nil {
+		return fmt.Errorf("Error starting team server: %w", err)
+	}
+	return nil
+}
+
+// requireMember asks the browser for a member's name and token.
+func (s *TeamServer) requireMember(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, token, _ := r.BasicAuth()
+		member, err := s.Team.Authenticate(r.Context(), token)
+		if err == nil && !strings.EqualFold(member.Name, name) {
+			err = team.ErrUnauthorised
+		}
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Basic realm="block team"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// HandlePushSessions stores the sessions a member pushes with `block team push`.
+func (s *TeamServer) HandlePushSessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		member, err := s.Team.Authenticate(r.Context(), token)
+		if errors.Is(err, team.ErrUnauthorised) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var sessions []team.Session
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxTeamPushBytes)).Decode(&sessions); err != nil {
+			http.Error(w, fmt.Sprintf("Error reading sessions: %s", err), http.StatusBadRequest)
+			return
+		}
+
+		result, err := s.Team.Save(r.Context(), member, sessions, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		SendJSON(w, result)
+	}
+}
+
+// HandleDashboard shows the team's focus time, or one member's at /members/{name}.
+//
+// Query parameters: period (day, week or month, default week) and date (yyyy-mm-dd, default today).
+func (s *TeamServer) HandleDashboard() http.HandlerFunc {
+	teamPage := []string{
+		"root.html",
+		"layout.html",
+		"head.html",
+		"header.html",
+		"footer.html",
+		"team-nav.html",
+		"team.html",
+	}
+
+	t := parseTemplates(s.FileSystem, s.TemplateMap, "team", funcMap, teamPage...)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		period := report.PeriodWeek
+		if p := r.URL.Query().Get("period"); p != "" {
+			period = p
+		}
+
+		now := time.Now()
+		if date := r.URL.Query().Get("date"); date != "" {
+			day, err := s.Calendar.ParseDate(date)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			now = s.Calendar.Start(day)
+		}
+
+		start, end, err := report.Bounds(s.Calendar, period, now)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		member := r.PathValue("name")
+		dashboard, err := s.Team.Dashboard(r.Context(), s.Calendar, start, end, member)
+		if errors.Is(err, team.ErrNoMember) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		previous, _, _ := report.Bounds(s.Calendar, period, start.Add(-time.Nanosecond))
+
+		parcel := map[string]any{
+			"Dashboard": dashboard,
+			"Member":    member,
+			"Period":    period,
+			"Periods":   []string{report.PeriodDay, report.PeriodWeek, report.PeriodMonth},
+			"Path":      r.URL.Path,
+			"PrevDate":  previous.Format("2006-01-02"),
+			"NextDate":  end.Format("2006-01-02"),
+		}
+
+		htmlBytes, err := SafeTmplExec(t, "root", parcel)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		SendHTML(w, htmlBytes)
+	}
+}
@@ -0,0 +1,81 @@
+package syncer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client talks to a sync server over http.
+type Client struct {
+	URL   string
+	Token string
+	HTTP  *http.Client
+}
+
+func NewClient(serverURL, token string) (*Client, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("Error, sync server '%s' must be an http or https url", serverURL)
+	}
+
+	return &Client{
+		URL:   strings.TrimSuffix(serverURL, "/"),
+		Token: token,
+		HTTP:  &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (c *Client) Push(ctx context.Context, changes []Change) (PushResponse, error) {
+	var resp PushResponse
+
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return resp, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL+"/push", bytes.NewReader(data))
+	if err != nil {
+		return resp, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return resp, c.do(req, &resp)
+}
+
+func (c *Client) Pull(ctx context.Context, since int64) (PullResponse, error) {
+	var resp PullResponse
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL+"/changes?since="+strconv.FormatInt(since, 10), nil)
+	if err != nil {
+		return resp, err
+	}
+
+	return resp, c.do(req, &resp)
+}
+
+func (c *Client) do(req *http.Request, v any) error {
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("Error reaching sync server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("Error, sync server responded %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
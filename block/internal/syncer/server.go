@@ -0,0 +1,191 @@
+package syncer
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// maxPushBytes bounds the size of a push request.
+const maxPushBytes = 32 << 20
+
+// serverSchema is the sync server's own database: the latest version of every task and bucket,
+// numbered by seq in the order they arrived. Storing a newer version renumbers it, so reading
+// from a seq returns everything changed since.
+const serverSchema = `
+	CREATE TABLE IF NOT EXISTS Changes
+	(
+	  seq        INTEGER PRIMARY KEY AUTOINCREMENT
+	, kind       TEXT NOT NULL
+	, key        TEXT NOT NULL
+	, updated_at TIMESTAMP NOT NULL
+	, device     TEXT NOT NULL
+	, body       TEXT NOT NULL
+	, UNIQUE (kind, key)
+	);
+`
+
+// Server is a self-hostable sync server, shared by every device of a user.
+type Server struct {
+	db    *sqlx.DB
+	token string // required as a bearer token when set.
+}
+
+// OpenServer opens or creates the server database at dataSourceName.
+func OpenServer(dataSourceName, token string) (*Server, error) {
+	db, err := sqlx.Connect("sqlite", dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(serverSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Error initalising sync server schema: %w", err)
+	}
+
+	return &Server{db: db, token: token}, nil
+}
+
+func (s *Server) Close() error {
+	return s.db.Close()
+}
+
+// Store keeps each change that is newer than the server's copy.
+func (s *Server) Store(ctx context.Context, changes []Change) (PushResponse, error) {
+	var resp PushResponse
+
+	for _, c := range changes {
+		if err := c.Validate(); err != nil {
+			return resp, err
+		}
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return resp, err
+	}
+	defer tx.Rollback()
+
+	for _, c := range changes {
+		var existing struct {
+			UpdatedAt time.Time `db:"updated_at"`
+			Device    string    `db:"device"`
+		}
+		err := tx.GetContext(ctx, &existing, "SELECT updated_at, device FROM Changes WHERE kind = ? AND key = ?", c.Kind, c.Key)
+		if err == nil && !c.Newer(Change{UpdatedAt: existing.UpdatedAt, Device: existing.Device}) {
+			resp.Rejected++
+			continue
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return resp, err
+		}
+
+		query := "INSERT OR REPLACE INTO Changes (kind, key, updated_at, device, body) VALUES (?, ?, ?, ?, ?)"
+		if _, err := tx.ExecContext(ctx, query, c.Kind, c.Key, c.UpdatedAt, c.Device, string(c.Body)); err != nil {
+			return resp, err
+		}
+		resp.Accepted++
+	}
+
+	return resp, tx.Commit()
+}
+
+// Since returns up to limit changes stored after seq since, oldest first.
+func (s *Server) Since(ctx context.Context, since int64, limit int) (PullResponse, error) {
+	resp := PullResponse{Changes: []Change{}, Cursor: since}
+
+	var rows []struct {
+		Seq       int64     `db:"seq"`
+		Kind      string    `db:"kind"`
+		Key       string    `db:"key"`
+		UpdatedAt time.Time `db:"updated_at"`
+		Device    string    `db:"device"`
+		Body      string    `db:"body"`
+	}
+	err := s.db.SelectContext(ctx, &rows, "SELECT * FROM Changes WHERE seq > ? ORDER BY seq ASC LIMIT ?", since, limit+1)
+	if err != nil {
+		return resp, err
+	}
+
+	if len(rows) > limit {
+		rows, resp.More = rows[:limit], true
+	}
+	for _, row := range rows {
+		resp.Changes = append(resp.Changes, Change{Seq: row.Seq, Kind: row.Kind, Key: row.Key, UpdatedAt: row.UpdatedAt, Device: row.Device, Body: json.RawMessage(row.Body)})
+		resp.Cursor = row.Seq
+	}
+
+	return resp, nil
+}
+
+// Handler serves POST /push and GET /changes?since=seq.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /push", s.handlePush)
+	mux.HandleFunc("GET /changes", s.handleChanges)
+
+	return s.authorise(mux)
+}
+
+func (s *Server) authorise(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" {
+			given := r.Header.Get("Authorization")
+			if subtle.ConstantTimeCompare([]byte(given), []byte("Bearer "+s.token)) != 1 {
+				http.Error(w, "Error, missing or invalid sync token", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handlePush(w http.ResponseWriter, r *http.Request) {
+	var changes []Change
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPushBytes)).Decode(&changes); err != nil {
+		http.Error(w, fmt.Sprintf("Error reading changes: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := s.Store(r.Context(), changes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sendJSON(w, resp)
+}
+
+func (s *Server) handleChanges(w http.ResponseWriter, r *http.Request) {
+	var since int64
+	if strSince := r.URL.Query().Get("since"); strSince != "" {
+		var err error
+		since, err = strconv.ParseInt(strSince, 10, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error parsing since '%s'", strSince), http.StatusBadRequest)
+			return
+		}
+	}
+
+	resp, err := s.Since(r.Context(), since, BatchSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendJSON(w, resp)
+}
+
+func sendJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
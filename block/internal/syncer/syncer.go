@@ -0,0 +1,502 @@
+// Package syncer keeps the tasks and buckets of several devices in step through a sync server.
+//
+// Each device sends the tasks and buckets that changed since it last synced, as listed in its
+// SyncOutbox, then reads every change the server has received since its last read. A change
+// carries the whole task or bucket and when it was last updated, and the latest update wins.
+// Tasks and buckets are matched by uuid, so a renamed bucket stays one bucket. A bucket created
+// on two devices apart is matched by name and settles on the smaller of its uuids.
+package syncer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/archive"
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Kinds of change.
+const (
+	KindTask   = "task"
+	KindBucket = "bucket"
+)
+
+// BatchSize is how many changes are sent or received per request.
+const BatchSize = 200
+
+// Change is the latest version of a task or bucket.
+type Change struct {
+	Seq       int64           `json:"seq,omitempty"` // the order the server received changes in, set by the server.
+	Kind      string          `json:"kind"`
+	Key       string          `json:"key"` // the task or bucket uuid.
+	UpdatedAt time.Time       `json:"updated_at"`
+	Device    string          `json:"device"` // the device that made the change.
+	Body      json.RawMessage `json:"body"`   // a TaskBody or archive.Bucket.
+}
+
+// TaskBody is a task with everything recorded against it.
+type TaskBody struct {
+	archive.Task
+	BucketUUID string     `json:"bucket_uuid,omitempty"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Newer reports whether c wins over other. The later update wins, and ties go to the greater
+// device id so the server and every device agree.
+func (c Change) Newer(other Change) bool {
+	if !c.UpdatedAt.Equal(other.UpdatedAt) {
+		return c.UpdatedAt.After(other.UpdatedAt)
+	}
+	return c.Device > other.Device
+}
+
+// Validate checks a change is well formed before it is stored.
+func (c Change) Validate() error {
+	if c.Kind != KindTask && c.Kind != KindBucket {
+		return fmt.Errorf("Error, unknown change kind '%s', expected task or bucket", c.Kind)
+	}
+	if c.Key == "" || c.Device == "" || c.UpdatedAt.IsZero() {
+		return fmt.Errorf("Error, %s change is missing its key, device or updated_at", c.Kind)
+	}
+	if !json.Valid(c.Body) {
+		return fmt.Errorf("Error, %s change %s has an invalid body", c.Kind, c.Key)
+	}
+	return nil
+}
+
+type PushResponse struct {
+	Accepted int `json:"accepted"`
+	Rejected int `json:"rejected"` // older than the server's copy.
+}
+
+type PullResponse struct {
+	Changes []Change `json:"changes"`
+	Cursor  int64    `json:"cursor"` // pass as since to read on from here.
+	More    bool     `json:"more"`
+}
+
+// Remote is a sync server.
+type Remote interface {
+	Push(ctx context.Context, changes []Change) (PushResponse, error)
+	Pull(ctx context.Context, since int64) (PullResponse, error)
+}
+
+type Result struct {
+	Sent     int
+	Accepted int
+	Received int
+	Applied  int
+}
+
+// DeviceID returns the id this database syncs as, creating it on first use.
+func DeviceID(ctx context.Context, db *sqlx.DB) (string, error) {
+	id, err := state(ctx, db, "device_id")
+	if err != nil || id != "" {
+		return id, err
+	}
+
+	id = uuid.NewString()
+	return id, setState(ctx, db, "device_id", id)
+}
+
+// Pending counts the tasks and buckets changed since they were last sent.
+func Pending(ctx context.Context, db *sqlx.DB) (int, error) {
+	var n int
+	err := db.GetContext(ctx, &n, "SELECT COUNT(*) FROM SyncOutbox")
+	return n, err
+}
+
+// Sync sends local changes to remote, then applies the changes remote has received since the
+// last sync. name identifies the remote, each remote keeping its own read position.
+func Sync(ctx context.Context, db *sqlx.DB, remote Remote, name string) (Result, error) {
+	var result Result
+
+	device, err := DeviceID(ctx, db)
+	if err != nil {
+		return result, err
+	}
+
+	changes, lastOutboxId, err := outgoing(ctx, db, device)
+	if err != nil {
+		return result, err
+	}
+
+	for start := 0; start < len(changes); start += BatchSize {
+		batch := changes[start:min(start+BatchSize, len(changes))]
+		resp, err := remote.Push(ctx, batch)
+		if err != nil {
+			return result, err
+		}
+		result.Sent += len(batch)
+		result.Accepted += resp.Accepted
+	}
+
+	// entries added while sending stay for the next sync.
+	if _, err := db.ExecContext(ctx, "DELETE FROM SyncOutbox WHERE outbox_id <= ?", lastOutboxId); err != nil {
+		return result, err
+	}
+
+	cursorName := "cursor " + name
+	since, err := state(ctx, db, cursorName)
+	if err != nil {
+		return result, err
+	}
+	cursor, _ := strconv.ParseInt(since, 10, 64)
+
+	for {
+		resp, err := remote.Pull(ctx, cursor)
+		if err != nil {
+			return result, err
+		}
+
+		tx, err := db.BeginTxx(ctx, nil)
+		if err != nil {
+			return result, err
+		}
+
+		for _, c := range resp.Changes {
+			applied, err := apply(ctx, tx, c, device)
+			if err != nil {
+				tx.Rollback()
+				return result, fmt.Errorf("Error applying %s %s: %w", c.Kind, c.Key, err)
+			}
+			result.Received++
+			if applied {
+				result.Applied++
+			}
+		}
+
+		cursor = resp.Cursor
+		if err := setState(ctx, tx, cursorName, strconv.FormatInt(cursor, 10)); err != nil {
+			tx.Rollback()
+			return result, err
+		}
+		if err := tx.Commit(); err != nil {
+			return result, err
+		}
+
+		if !resp.More {
+			return result, nil
+		}
+	}
+}
+
+// outgoing reads every task and bucket in the outbox, with the last outbox id read.
+func outgoing(ctx context.Context, db *sqlx.DB, device string) ([]Change, int64, error) {
+	var entries []struct {
+		OutboxId int64  `db:"outbox_id"`
+		Kind     string `db:"kind"`
+		Key      string `db:"key"`
+	}
+	err := db.SelectContext(ctx, &entries, "SELECT outbox_id, kind, key FROM SyncOutbox ORDER BY outbox_id ASC")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	allBuckets, err := buckets.GetAllBuckets(db)
+	if err != nil {
+		return nil, 0, err
+	}
+	bucketsById := make(map[int64]buckets.Bucket)
+	for _, b := range allBuckets {
+		bucketsById[b.BucketId] = b
+	}
+
+	store := tasks.NewStore(db)
+
+	var changes []Change
+	var lastOutboxId int64
+	for _, entry := range entries {
+		lastOutboxId = entry.OutboxId
+
+		var body any
+		var updatedAt sql.NullTime
+		switch entry.Kind {
+		case KindTask:
+			task, err := store.GetByUUID(ctx, entry.Key)
+			if errors.Is(err, tasks.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, 0, err
+			}
+
+			bucket := bucketsById[task.BucketId.Int64]
+			t, err := archive.ExportTask(ctx, db, task, bucket.BucketName)
+			if err != nil {
+				return nil, 0, err
+			}
+			taskBody := TaskBody{Task: t, BucketUUID: bucket.BucketUUID}
+			if task.DeletedAt.Valid {
+				taskBody.DeletedAt = &task.DeletedAt.Time
+			}
+			body, updatedAt = taskBody, task.UpdatedAt
+		case KindBucket:
+			// a bucket that took another device's uuid since is sent under that uuid.
+			bucket, err := buckets.FindByUUID(db, entry.Key)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				return nil, 0, err
+			}
+			body, updatedAt = archive.ExportBucket(bucket), bucket.UpdatedAt
+		default:
+			continue
+		}
+
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		changes = append(changes, Change{Kind: entry.Kind, Key: entry.Key, UpdatedAt: updatedAt.Time, Device: device, Body: data})
+	}
+
+	return changes, lastOutboxId, nil
+}
+
+// apply brings a change from the server into the local database, unless the local copy is the
+// same version or wins over it. It reports whether anything changed.
+func apply(ctx context.Context, tx *sqlx.Tx, c Change, device string) (bool, error) {
+	if err := c.Validate(); err != nil {
+		return false, err
+	}
+
+	// an unsent local change made at the same time is this device's own version, and a tie
+	// is settled as the server settles it.
+	keep := func(key string, local sql.NullTime) (bool, error) {
+		if !local.Valid {
+			return false, nil
+		}
+
+		var pending bool
+		err := tx.GetContext(ctx, &pending, "SELECT COUNT(*) > 0 FROM SyncOutbox WHERE kind = ? AND key = ?", c.Kind, key)
+		if err != nil {
+			return false, err
+		}
+
+		if pending {
+			return !c.Newer(Change{UpdatedAt: local.Time, Device: device}), nil
+		}
+		return !local.Time.Before(c.UpdatedAt), nil
+	}
+
+	switch c.Kind {
+	case KindBucket:
+		var b archive.Bucket
+		if err := json.Unmarshal(c.Body, &b); err != nil {
+			return false, err
+		}
+		// changes sent before buckets had uuids are keyed by the lower-case name.
+		if b.UUID != c.Key && (b.UUID != "" || strings.ToLower(b.Name) != c.Key) {
+			return false, fmt.Errorf("Error, bucket body has uuid '%s'", b.UUID)
+		}
+
+		local, exists, err := matchBucket(tx, b.UUID, b.Name)
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			skip, err := keep(local.BucketUUID, local.UpdatedAt)
+			if err != nil {
+				return false, err
+			}
+			if err := settleUUID(ctx, tx, local, b.UUID); err != nil {
+				return false, err
+			}
+			if skip {
+				return false, nil
+			}
+		}
+
+		bucketId, err := saveBucket(tx, b, local, exists)
+		if err != nil {
+			return false, err
+		}
+		return true, applied(ctx, tx, "Buckets", "bucket_id", bucketId, c)
+	case KindTask:
+		var t TaskBody
+		if err := json.Unmarshal(c.Body, &t); err != nil {
+			return false, err
+		}
+		if t.UUID != c.Key {
+			return false, fmt.Errorf("Error, task body has uuid %s", t.UUID)
+		}
+
+		local, err := tasks.NewStore(tx).GetByUUID(ctx, c.Key)
+		if err != nil && !errors.Is(err, tasks.ErrNotFound) {
+			return false, err
+		}
+		exists := err == nil
+		if exists {
+			skip, err := keep(c.Key, local.UpdatedAt)
+			if err != nil || skip {
+				return false, err
+			}
+		}
+
+		taskId, err := saveTask(ctx, tx, t, local, exists)
+		if err != nil {
+			return false, err
+		}
+		return true, applied(ctx, tx, "Tasks", "task_id", taskId, c)
+	}
+
+	return false, nil
+}
+
+// matchBucket finds the local copy of a bucket by uuid, or by name for a bucket this device
+// created apart from the device that sent it.
+func matchBucket(tx *sqlx.Tx, bucketUUID, name string) (buckets.Bucket, bool, error) {
+	if bucketUUID != "" {
+		bucket, err := buckets.FindByUUID(tx, bucketUUID)
+		if err == nil || !errors.Is(err, sql.ErrNoRows) {
+			return bucket, err == nil, err
+		}
+	}
+
+	bucket, err := buckets.FindByName(tx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return bucket, false, nil
+	}
+	return bucket, err == nil, err
+}
+
+// settleUUID gives a bucket matched by name the smaller of its uuid and the one it was sent
+// under, so every device settles on the same uuid whichever version wins. The bucket keeps its
+// updated_at, and is sent again under the uuid it settles on.
+func settleUUID(ctx context.Context, tx *sqlx.Tx, local buckets.Bucket, bucketUUID string) error {
+	if bucketUUID == "" || bucketUUID >= local.BucketUUID {
+		return nil
+	}
+
+	// updated_at is cleared first so the sync trigger leaves it alone, as in applied.
+	_, err := tx.ExecContext(ctx, "UPDATE Buckets SET bucket_uuid = ?, updated_at = NULL WHERE bucket_id = ?", bucketUUID, local.BucketId)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE Buckets SET updated_at = ? WHERE bucket_id = ?", local.UpdatedAt, local.BucketId)
+	return err
+}
+
+func saveBucket(tx *sqlx.Tx, b archive.Bucket, local buckets.Bucket, exists bool) (int64, error) {
+	bucket, err := b.NewBucket(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	if !exists {
+		err := buckets.InsertBucket(tx, bucket)
+		return bucket.BucketId, err
+	}
+
+	query := "UPDATE Buckets SET bucket_name = ?, colour = ?, weekly_goal_seconds = ?, archived_at = ? WHERE bucket_id = ?"
+	_, err = tx.Exec(query, bucket.BucketName, bucket.Colour, bucket.WeeklyGoalSeconds, bucket.ArchivedAt, local.BucketId)
+	return local.BucketId, err
+}
+
+// saveTask replaces the local task and everything recorded against it with t.
+func saveTask(ctx context.Context, tx *sqlx.Tx, t TaskBody, local tasks.Task, exists bool) (int64, error) {
+	task := t.NewTask()
+	if t.DeletedAt != nil {
+		task.DeletedAt = sql.NullTime{Time: *t.DeletedAt, Valid: true}
+	}
+
+	if t.Bucket != "" {
+		bucket, exists, err := matchBucket(tx, t.BucketUUID, t.Bucket)
+		if err != nil {
+			return 0, err
+		}
+		if !exists {
+			// the bucket has not arrived yet. This device has no version of its own to send.
+			created, err := archive.Bucket{UUID: t.BucketUUID, Name: t.Bucket}.NewBucket(tx)
+			if err != nil {
+				return 0, err
+			}
+			if err := buckets.InsertBucket(tx, created); err != nil {
+				return 0, err
+			}
+			if _, err := tx.ExecContext(ctx, "DELETE FROM SyncOutbox WHERE kind = ? AND key = ?", KindBucket, created.BucketUUID); err != nil {
+				return 0, err
+			}
+			bucket = *created
+		}
+		task.AddBucketTag(bucket.BucketId)
+	}
+
+	store := tasks.NewStore(tx)
+	if !exists {
+		if err := store.Insert(ctx, task); err != nil {
+			return 0, err
+		}
+	} else {
+		task.TaskId = local.TaskId
+		query := `UPDATE Tasks SET task_name = ?, estimated_duration_seconds = ?, blocker_enabled = ?, screen_enabled = ?, screen_url = ?,
+		created_at = ?, bucket_id = ?
+		WHERE task_id = ?`
+		_, err := tx.ExecContext(ctx, query, task.TaskName, task.EstimatedDurationSeconds, task.BlockerEnabled, task.ScreenEnabled, task.ScreenURL,
+			task.CreatedAt, task.BucketId, task.TaskId)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, table := range []string{"TaskTags", "Segments", "IdleIntervals", "Notes", "Interruptions"} {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE task_id = ?", task.TaskId); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if err := store.Finish(ctx, *task); err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE Tasks SET deleted_at = ? WHERE task_id = ?", task.DeletedAt, task.TaskId); err != nil {
+		return 0, err
+	}
+
+	if err := archive.InsertChildren(ctx, tx, task.TaskId, t.Task); err != nil {
+		return 0, err
+	}
+
+	return task.TaskId, nil
+}
+
+// applied gives the saved row the change's updated_at and takes it out of the outbox, so the
+// change is not sent back. updated_at is cleared first so the statement always changes it, and
+// the sync triggers leave the value alone.
+func applied(ctx context.Context, tx *sqlx.Tx, table, idColumn string, id int64, c Change) error {
+	query := fmt.Sprintf("UPDATE %s SET updated_at = ? WHERE %s = ?", table, idColumn)
+	if _, err := tx.ExecContext(ctx, query, nil, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, query, c.UpdatedAt, id); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, "DELETE FROM SyncOutbox WHERE kind = ? AND key = ?", c.Kind, c.Key)
+	return err
+}
+
+func state(ctx context.Context, db sqlx.QueryerContext, name string) (string, error) {
+	var value string
+	err := sqlx.GetContext(ctx, db, &value, "SELECT value FROM SyncState WHERE name = ?", name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return value, err
+}
+
+func setState(ctx context.Context, db sqlx.ExecerContext, name, value string) error {
+	_, err := db.ExecContext(ctx, "INSERT INTO SyncState (name, value) VALUES (?, ?) ON CONFLICT (name) DO UPDATE SET value = excluded.value", name, value)
+	return err
+}
@@ -0,0 +1,326 @@
+package syncer
+
+import (
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/db/dbtest"
+	"github.com/connorkuljis/block-cli/internal/notes"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/jmoiron/sqlx"
+)
+
+// startServer runs a sync server over http and returns a client for it.
+func startServer(t *testing.T) *Client {
+	t.Helper()
+
+	server, err := OpenServer(filepath.Join(t.TempDir(), "server.db")+"?_time_format=sqlite", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
+
+	client, err := NewClient(ts.URL, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func sync(t *testing.T, conn *sqlx.DB, client *Client) Result {
+	t.Helper()
+
+	result, err := Sync(context.Background(), conn, client, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// insertTask adds a finished task in bucket with a note.
+func insertTask(t *testing.T, conn *sqlx.DB, name, bucket string) *tasks.Task {
+	t.Helper()
+	ctx := context.Background()
+
+	b, err := buckets.FindByName(conn, bucket)
+	if err != nil {
+		b = *buckets.NewBucket(bucket)
+		if err := buckets.InsertBucket(conn, &b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	createdAt := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	task := tasks.NewTask(name, 1500, false, false, createdAt)
+	task.AddBucketTag(b.BucketId)
+	if err := tasks.NewStore(conn).Insert(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+	task.AccumulateSegment(1500, true)
+	task.SetFinishTime(createdAt.Add(25 * time.Minute))
+	if err := tasks.NewStore(conn).Finish(ctx, *task); err != nil {
+		t.Fatal(err)
+	}
+	if err := notes.InsertNote(conn, notes.NewNote(task.TaskId, "done", 4, createdAt.Add(25*time.Minute))); err != nil {
+		t.Fatal(err)
+	}
+
+	return task
+}
+
+func TestSyncBetweenDevices(t *testing.T) {
+	ctx := context.Background()
+	client := startServer(t)
+	a, b := dbtest.Open(t), dbtest.Open(t)
+
+	task := insertTask(t, a, "write report", "work")
+
+	if result := sync(t, a, client); result.Sent != 2 || result.Accepted != 2 {
+		t.Errorf("Expected a bucket and a task sent, got: %+v", result)
+	}
+	if n, _ := Pending(ctx, a); n != 0 {
+		t.Errorf("Expected nothing pending after sync, got: %d", n)
+	}
+
+	if result := sync(t, b, client); result.Applied != 2 {
+		t.Errorf("Expected a bucket and a task applied, got: %+v", result)
+	}
+
+	got, err := tasks.NewStore(b).GetByUUID(ctx, task.TaskUUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.TaskName != "write report" || got.ActualDurationSeconds.Int64 != 1500 || !got.FinishedAt.Valid {
+		t.Errorf("Expected the finished task on b, got: %+v", got)
+	}
+	bucket, err := buckets.FindByName(b, "work")
+	if err != nil || got.BucketId.Int64 != bucket.BucketId {
+		t.Errorf("Expected the task in b's work bucket, got: %+v %v", got, err)
+	}
+	taskNotes, err := notes.GetNotesByTaskId(b, got.TaskId)
+	if err != nil || len(taskNotes) != 1 {
+		t.Errorf("Expected the note on b, got: %+v %v", taskNotes, err)
+	}
+
+	// applied changes are not sent back.
+	if n, _ := Pending(ctx, b); n != 0 {
+		t.Errorf("Expected nothing pending on b, got: %d", n)
+	}
+	if result := sync(t, a, client); result.Sent != 0 || result.Applied != 0 {
+		t.Errorf("Expected a to be up to date, got: %+v", result)
+	}
+}
+
+func TestSyncLastWriterWins(t *testing.T) {
+	ctx := context.Background()
+	client := startServer(t)
+	a, b := dbtest.Open(t), dbtest.Open(t)
+
+	task := insertTask(t, a, "write report", "work")
+	sync(t, a, client)
+	sync(t, b, client)
+
+	rename := func(conn *sqlx.DB, name string, at time.Time) {
+		t.Helper()
+		_, err := conn.Exec("UPDATE Tasks SET task_name = ?, updated_at = ? WHERE task_uuid = ?", name, at, task.TaskUUID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now().UTC()
+	rename(b, "write report on b", now.Add(2*time.Minute))
+	rename(a, "write report on a", now.Add(time.Minute))
+
+	// b's later edit reaches the server first and a's is rejected, then a takes b's.
+	sync(t, b, client)
+	if result := sync(t, a, client); result.Accepted != 0 || result.Applied != 1 {
+		t.Errorf("Expected a's older edit rejected and b's applied, got: %+v", result)
+	}
+	sync(t, b, client)
+
+	for name, conn := range map[string]*sqlx.DB{"a": a, "b": b} {
+		got, err := tasks.NewStore(conn).GetByUUID(ctx, task.TaskUUID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.TaskName != "write report on b" {
+			t.Errorf("Expected %s to keep b's later edit, got: %q", name, got.TaskName)
+		}
+	}
+}
+
+func TestSyncRenamedBucket(t *testing.T) {
+	ctx := context.Background()
+	client := startServer(t)
+	a, b := dbtest.Open(t), dbtest.Open(t)
+
+	task := insertTask(t, a, "write report", "work")
+	sync(t, a, client)
+	sync(t, b, client)
+
+	rename := func(conn *sqlx.DB, from, to string) {
+		t.Helper()
+		bucket, err := buckets.FindByName(conn, from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := buckets.RenameBucket(conn, bucket.BucketId, to); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the bucket is renamed on a, then renamed again on b, and each device follows.
+	rename(a, "work", "job")
+	sync(t, a, client)
+	sync(t, b, client)
+	rename(b, "job", "client work")
+	sync(t, b, client)
+	sync(t, a, client)
+
+	for name, conn := range map[string]*sqlx.DB{"a": a, "b": b} {
+		all, err := buckets.GetAllBuckets(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != 1 || all[0].BucketName != "client work" {
+			t.Fatalf("Expected %s to have only the renamed bucket, got: %+v", name, all)
+		}
+
+		got, err := tasks.NewStore(conn).GetByUUID(ctx, task.TaskUUID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.BucketId.Int64 != all[0].BucketId {
+			t.Errorf("Expected the task on %s to stay in the renamed bucket, got bucket %d", name, got.BucketId.Int64)
+		}
+	}
+}
+
+func TestSyncSettlesBucketUUID(t *testing.T) {
+	client := startServer(t)
+	a, b := dbtest.Open(t), dbtest.Open(t)
+
+	// both devices created a work bucket before they first synced.
+	insertTask(t, a, "write report", "work")
+	insertTask(t, b, "review pr", "Work")
+	sync(t, a, client)
+	sync(t, b, client)
+	sync(t, a, client)
+
+	var uuids []string
+	for name, conn := range map[string]*sqlx.DB{"a": a, "b": b} {
+		all, err := buckets.GetAllBuckets(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != 1 {
+			t.Fatalf("Expected %s to have one work bucket, got: %+v", name, all)
+		}
+		uuids = append(uuids, all[0].BucketUUID)
+	}
+	if uuids[0] != uuids[1] {
+		t.Errorf("Expected both devices to settle on one uuid, got: %v", uuids)
+	}
+}
+
+func TestSyncKeepsNewerLocalEdit(t *testing.T) {
+	ctx := context.Background()
+	client := startServer(t)
+	a, b := dbtest.Open(t), dbtest.Open(t)
+
+	task := insertTask(t, a, "write report", "work")
+	sync(t, a, client)
+	sync(t, b, client)
+
+	now := time.Now().UTC()
+	if _, err := a.Exec("UPDATE Tasks SET task_name = 'older', updated_at = ? WHERE task_uuid = ?", now.Add(time.Minute), task.TaskUUID); err != nil {
+		t.Fatal(err)
+	}
+	sync(t, a, client)
+
+	// b edited later, so a's change must not overwrite it even before the server has b's.
+	if _, err := b.Exec("UPDATE Tasks SET task_name = 'newer', updated_at = ? WHERE task_uuid = ?", now.Add(2*time.Minute), task.TaskUUID); err != nil {
+		t.Fatal(err)
+	}
+	server := &pullOnly{client}
+	if _, err := Sync(ctx, b, server, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := tasks.NewStore(b).GetByUUID(ctx, task.TaskUUID)
+	if got.TaskName != "newer" {
+		t.Errorf("Expected b's later edit kept, got: %q", got.TaskName)
+	}
+}
+
+// pullOnly drops pushes, leaving the device's changes pending.
+type pullOnly struct {
+	*Client
+}
+
+func (p *pullOnly) Push(ctx context.Context, changes []Change) (PushResponse, error) {
+	return PushResponse{}, nil
+}
+
+func TestSyncDeletedTask(t *testing.T) {
+	ctx := context.Background()
+	client := startServer(t)
+	a, b := dbtest.Open(t), dbtest.Open(t)
+
+	task := insertTask(t, a, "write report", "work")
+	sync(t, a, client)
+	sync(t, b, client)
+
+	if _, err := tasks.NewStore(a).Delete(ctx, []int64{task.TaskId}, "test", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	sync(t, a, client)
+	sync(t, b, client)
+
+	got, err := tasks.NewStore(b).GetByUUID(ctx, task.TaskUUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.DeletedAt.Valid {
+		t.Errorf("Expected the task deleted on b, got: %+v", got)
+	}
+}
+
+func TestTriggersSetUpdatedAt(t *testing.T) {
+	ctx := context.Background()
+	conn := dbtest.Open(t)
+
+	task := insertTask(t, conn, "write report", "work")
+	before, err := tasks.NewStore(conn).GetByUUID(ctx, task.TaskUUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !before.UpdatedAt.Valid || time.Since(before.UpdatedAt.Time) > time.Minute {
+		t.Fatalf("Expected updated_at set on insert, got: %+v", before.UpdatedAt)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if err := notes.InsertNote(conn, notes.NewNote(task.TaskId, "more", 0, time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := tasks.NewStore(conn).GetByUUID(ctx, task.TaskUUID)
+	if !after.UpdatedAt.Time.After(before.UpdatedAt.Time) {
+		t.Errorf("Expected a new note to update the task, got: %v then %v", before.UpdatedAt.Time, after.UpdatedAt.Time)
+	}
+}
+
+func TestServerRequiresToken(t *testing.T) {
+	client := startServer(t)
+	client.Token = "wrong"
+
+	if _, err := client.Pull(context.Background(), 0); err == nil {
+		t.Error("Expected an error with the wrong token")
+	}
+}
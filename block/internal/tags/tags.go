@@ -0,0 +1,188 @@
+package tags
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/jmoiron/sqlx"
+)
+
+type Tag struct {
+	TagId     int64     `db:"tag_id"`
+	TagName   string    `db:"tag_name"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Summary aggregates the tasks carrying a tag.
+type Summary struct {
+	TagName      string
+	TaskCount    int64
+	TotalSeconds int64
+}
+
+// Normalise lowercases and trims a tag name so "Client-X " and "client-x" are the same tag.
+func Normalise(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// normaliseAll normalises names, dropping blanks and duplicates.
+func normaliseAll(names []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, name := range names {
+		name = Normalise(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		out = append(out, name)
+	}
+	return out
+}
+
+// Validate rejects tag names that would be ambiguous on the command line or in web forms.
+func Validate(names []string) error {
+	for _, name := range normaliseAll(names) {
+		if strings.ContainsAny(name, ", ") {
+			return fmt.Errorf("Error, tag '%s' must not contain spaces or commas", name)
+		}
+	}
+	return nil
+}
+
+// TagTask attaches the named tags to a task, creating any tags that don't exist yet.
+func TagTask(db *sqlx.DB, taskId int64, names []string) error {
+	if err := Validate(names); err != nil {
+		return err
+	}
+
+	names = normaliseAll(names)
+	if len(names) == 0 {
+		return nil
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := AddTags(tx, taskId, names); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AddTags tags a task within an existing transaction, creating any tags that do not exist yet.
+// Names must already be validated.
+func AddTags(tx sqlx.Execer, taskId int64, names []string) error {
+	for _, name := range normaliseAll(names) {
+		_, err := tx.Exec("INSERT OR IGNORE INTO Tags (tag_name, created_at) VALUES (?, ?)", name, time.Now())
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`INSERT OR IGNORE INTO TaskTags (task_id, tag_id)
+		SELECT ?, tag_id FROM Tags WHERE tag_name = ?`, taskId, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func GetAllTags(db *sqlx.DB) ([]Tag, error) {
+	var tags []Tag
+
+	err := db.Select(&tags, "SELECT * FROM Tags ORDER BY tag_name ASC")
+	if err != nil {
+		return tags, err
+	}
+
+	return tags, nil
+}
+
+func GetTagsByTaskId(db *sqlx.DB, taskId int64) ([]string, error) {
+	var names []string
+
+	query := `SELECT t.tag_name FROM Tags t
+	JOIN TaskTags tt ON tt.tag_id = t.tag_id
+	WHERE tt.task_id = ?
+	ORDER BY t.tag_name ASC`
+
+	err := db.Select(&names, query, taskId)
+	if err != nil {
+		return names, err
+	}
+
+	return names, nil
+}
+
+// GetTagsByTask returns the tag names of every tagged task, keyed by task id.
+func GetTagsByTask(db *sqlx.DB) (map[int64][]string, error) {
+	var rows []struct {
+		TaskId  int64  `db:"task_id"`
+		TagName string `db:"tag_name"`
+	}
+
+	query := `SELECT tt.task_id, t.tag_name FROM TaskTags tt
+	JOIN Tags t ON t.tag_id = tt.tag_id
+	ORDER BY t.tag_name ASC`
+
+	err := db.Select(&rows, query)
+	if err != nil {
+		return nil, err
+	}
+
+	tagsByTask := make(map[int64][]string)
+	for _, row := range rows {
+		tagsByTask[row.TaskId] = append(tagsByTask[row.TaskId], row.TagName)
+	}
+
+	return tagsByTask, nil
+}
+
+// Summarise totals the actual duration of the given tasks per tag, largest first.
+// A task with several tags counts towards each of them.
+func Summarise(db *sqlx.DB, all []tasks.Task) ([]Summary, error) {
+	if len(all) == 0 {
+		return nil, nil
+	}
+
+	tagsByTask, err := GetTagsByTask(db)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*Summary)
+	for _, task := range all {
+		for _, name := range tagsByTask[task.TaskId] {
+			s, ok := byName[name]
+			if !ok {
+				s = &Summary{TagName: name}
+				byName[name] = s
+			}
+			s.TaskCount++
+			s.TotalSeconds += task.ActualDurationSeconds.Int64
+		}
+	}
+
+	summaries := make([]Summary, 0, len(byName))
+	for _, s := range byName {
+		summaries = append(summaries, *s)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].TotalSeconds == summaries[j].TotalSeconds {
+			return summaries[i].TagName < summaries[j].TagName
+		}
+		return summaries[i].TotalSeconds > summaries[j].TotalSeconds
+	})
+
+	return summaries, nil
+}
@@ -0,0 +1,195 @@
+package tasks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Audit actions.
+const (
+	ActionEdit    = "edit"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionUndo    = "undo"
+)
+
+// Where an audited change was made.
+const (
+	SourceCLI = "cli"
+	SourceWeb = "web"
+)
+
+// AuditEntry records one change to a task. Before and After are json snapshots of the task.
+// Entries written by the same command share a BatchId.
+type AuditEntry struct {
+	AuditId   int64        `db:"audit_id"`
+	BatchId   string       `db:"batch_id"`
+	TaskId    int64        `db:"task_id"`
+	Action    string       `db:"action"`
+	Source    string       `db:"source"`
+	Before    string       `db:"before_json"`
+	After     string       `db:"after_json"`
+	UndoneAt  sql.NullTime `db:"undone_at"`
+	CreatedAt time.Time    `db:"created_at"`
+}
+
+// ErrNothingToUndo is returned by Undo when every change has already been undone.
+var ErrNothingToUndo = errors.New("Error, nothing to undo")
+
+// Edit renames a task and sets its actual duration.
+func (s *Store) Edit(ctx context.Context, taskId int64, taskName string, actualDurationSeconds int64, source string) error {
+	_, err := s.audited(ctx, []int64{taskId}, ActionEdit, source, func(tx *Store, task Task) error {
+		query := "UPDATE Tasks SET task_name = ?, actual_duration_seconds = ? WHERE task_id = ?"
+		return tx.update(ctx, task.TaskId, query, taskName, actualDurationSeconds, task.TaskId)
+	})
+	return err
+}
+
+// Delete marks tasks as deleted, hiding them from every query until they are restored.
+func (s *Store) Delete(ctx context.Context, ids []int64, source string, now time.Time) ([]Task, error) {
+	return s.audited(ctx, ids, ActionDelete, source, func(tx *Store, task Task) error {
+		if task.DeletedAt.Valid {
+			return fmt.Errorf("Error, task %d is already deleted", task.TaskId)
+		}
+		return tx.update(ctx, task.TaskId, "UPDATE Tasks SET deleted_at = ? WHERE task_id = ?", now, task.TaskId)
+	})
+}
+
+// Restore brings deleted tasks back.
+func (s *Store) Restore(ctx context.Context, ids []int64, source string) ([]Task, error) {
+	return s.audited(ctx, ids, ActionRestore, source, func(tx *Store, task Task) error {
+		if !task.DeletedAt.Valid {
+			return fmt.Errorf("Error, task %d is not deleted", task.TaskId)
+		}
+		return tx.update(ctx, task.TaskId, "UPDATE Tasks SET deleted_at = NULL WHERE task_id = ?", task.TaskId)
+	})
+}
+
+// Undo reverts the most recent batch of changes that has not been undone, returning its entries.
+// Calling Undo again steps further back.
+func (s *Store) Undo(ctx context.Context, source string, now time.Time) ([]AuditEntry, error) {
+	var entries []AuditEntry
+
+	err := s.inTx(ctx, func(tx *Store) error {
+		var batchId string
+		err := sqlx.GetContext(ctx, tx.db, &batchId, "SELECT batch_id FROM AuditLog WHERE action != ? AND undone_at IS NULL ORDER BY audit_id DESC LIMIT 1", ActionUndo)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNothingToUndo
+		}
+		if err != nil {
+			return err
+		}
+
+		err = sqlx.SelectContext(ctx, tx.db, &entries, "SELECT * FROM AuditLog WHERE batch_id = ? ORDER BY audit_id DESC", batchId)
+		if err != nil {
+			return err
+		}
+
+		undoBatchId := uuid.NewString()
+		for _, entry := range entries {
+			var before Task
+			if err := json.Unmarshal([]byte(entry.Before), &before); err != nil {
+				return fmt.Errorf("Error reading audit entry %d: %w", entry.AuditId, err)
+			}
+
+			current, err := tx.Get(ctx, entry.TaskId)
+			if err != nil {
+				return err
+			}
+
+			query := `UPDATE Tasks SET task_name = ?, actual_duration_seconds = ?, finished_at = ?, completed = ?, completion_percent = ?, deleted_at = ?
+			WHERE task_id = ?`
+			err = tx.update(ctx, before.TaskId, query, before.TaskName, before.ActualDurationSeconds, before.FinishedAt, before.Completed, before.CompletionPercent, before.DeletedAt, before.TaskId)
+			if err != nil {
+				return err
+			}
+
+			if err := tx.insertAuditEntry(ctx, undoBatchId, ActionUndo, source, current, before, now); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.db.ExecContext(ctx, "UPDATE AuditLog SET undone_at = ? WHERE batch_id = ?", now, batchId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Audit returns the changes made to a task, newest first.
+func (s *Store) Audit(ctx context.Context, taskId int64) ([]AuditEntry, error) {
+	var entries []AuditEntry
+
+	err := sqlx.SelectContext(ctx, s.db, &entries, "SELECT * FROM AuditLog WHERE task_id = ? ORDER BY audit_id DESC", taskId)
+	if err != nil {
+		return entries, fmt.Errorf("Error getting audit log for task %d: %w", taskId, err)
+	}
+
+	return entries, nil
+}
+
+// audited applies change to each task in one transaction, recording every task before and after
+// under a single batch. Nothing is written if any change fails.
+func (s *Store) audited(ctx context.Context, ids []int64, action, source string, change func(tx *Store, task Task) error) ([]Task, error) {
+	now := time.Now()
+	batchId := uuid.NewString()
+
+	var changed []Task
+	err := s.inTx(ctx, func(tx *Store) error {
+		for _, id := range ids {
+			before, err := tx.Get(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			if err := change(tx, before); err != nil {
+				return err
+			}
+
+			after, err := tx.Get(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			if err := tx.insertAuditEntry(ctx, batchId, action, source, before, after, now); err != nil {
+				return err
+			}
+
+			changed = append(changed, after)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changed, nil
+}
+
+func (s *Store) insertAuditEntry(ctx context.Context, batchId, action, source string, before, after Task, now time.Time) error {
+	beforeJSON, err := json.Marshal(before)
+	if err != nil {
+		return err
+	}
+
+	afterJSON, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO AuditLog (batch_id, task_id, action, source, before_json, after_json, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	_, err = s.db.ExecContext(ctx, query, batchId, before.TaskId, action, source, string(beforeJSON), string(afterJSON), now)
+	return err
+}
@@ -0,0 +1,89 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDeleteRestoreUndo(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t)
+
+	day := time.Date(2024, 3, 4, 9, 0, 0, 0, time.Local)
+	report := insertFinished(t, store, "write report", day, 1500, 100)
+	email := insertFinished(t, store, "email", day.Add(time.Hour), 600, 40)
+	insertFinished(t, store, "review", day.Add(2*time.Hour), 900, 100)
+
+	if _, err := store.Delete(ctx, []int64{report.TaskId, email.TaskId}, SourceCLI, day.Add(3*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	assertNames := func(q *Query, want []string) {
+		t.Helper()
+		all, err := store.Select(ctx, q.OrderBy("date", false))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := taskNames(all); !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected: %v, got: %v", want, got)
+		}
+	}
+
+	assertNames(NewQuery(), []string{"review"})
+	assertNames(NewQuery().Deleted(), []string{"write report", "email"})
+
+	if _, err := store.Delete(ctx, []int64{report.TaskId}, SourceCLI, day); err == nil {
+		t.Error("Expected error deleting a deleted task, got nil")
+	}
+
+	if _, err := store.Restore(ctx, []int64{email.TaskId}, SourceWeb); err != nil {
+		t.Fatal(err)
+	}
+	assertNames(NewQuery(), []string{"email", "review"})
+
+	if err := store.Edit(ctx, email.TaskId, "inbox zero", 1200, SourceWeb); err != nil {
+		t.Fatal(err)
+	}
+
+	// the edit is undone first, then the restore, then the delete of both tasks.
+	if _, err := store.Undo(ctx, SourceCLI, day); err != nil {
+		t.Fatal(err)
+	}
+	reverted, err := store.Get(ctx, email.TaskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reverted.TaskName != "email" || reverted.ActualDurationSeconds.Int64 != 600 {
+		t.Errorf("Expected the edit to be reverted, got: %q %d", reverted.TaskName, reverted.ActualDurationSeconds.Int64)
+	}
+
+	if _, err := store.Undo(ctx, SourceCLI, day); err != nil {
+		t.Fatal(err)
+	}
+	assertNames(NewQuery(), []string{"review"})
+
+	entries, err := store.Undo(ctx, SourceCLI, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Action != ActionDelete {
+		t.Errorf("Expected the delete of two tasks to be undone, got: %+v", entries)
+	}
+	assertNames(NewQuery(), []string{"write report", "email", "review"})
+
+	if _, err := store.Undo(ctx, SourceCLI, day); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Expected ErrNothingToUndo, got: %v", err)
+	}
+
+	audit, err := store.Audit(ctx, email.TaskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// delete, restore, edit and an undo of each.
+	if len(audit) != 6 {
+		t.Errorf("Expected 6 audit entries for the task, got: %d", len(audit))
+	}
+}
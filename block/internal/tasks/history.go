@@ -1,16 +1,90 @@
 package tasks
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
-	"os"
+	"io"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/utils"
 	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
 )
 
-func RenderTable(tasks []Task) {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"ID", "Date", "Name", "Planned (min)", "Actual (min)", "Completion Percent", "Completed"})
+// Output formats accepted by Render.
+const (
+	FormatTable    = "table"
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatMarkdown = "markdown"
+)
+
+var Formats = []string{FormatTable, FormatJSON, FormatCSV, FormatMarkdown}
+
+// Record is the plain view of a task used by the json and csv renderers.
+type Record struct {
+	Id                int64      `json:"id"`
+	UUID              string     `json:"uuid"`
+	Name              string     `json:"name"`
+	CreatedAt         time.Time  `json:"created_at"`
+	FinishedAt        *time.Time `json:"finished_at"`
+	PlannedSeconds    int64      `json:"planned_seconds"`
+	ActualSeconds     int64      `json:"actual_seconds"`
+	CompletionPercent float64    `json:"completion_percent"`
+	Completed         bool       `json:"completed"`
+	BucketId          *int64     `json:"bucket_id"`
+}
+
+func (task Task) ToRecord() Record {
+	record := Record{
+		Id:                task.TaskId,
+		UUID:              task.TaskUUID,
+		Name:              task.TaskName,
+		CreatedAt:         task.CreatedAt,
+		PlannedSeconds:    task.EstimatedDurationSeconds,
+		ActualSeconds:     task.ActualDurationSeconds.Int64,
+		CompletionPercent: task.CompletionPercent.Float64,
+		Completed:         task.Completed == 1,
+	}
+
+	if task.FinishedAt.Valid {
+		finishedAt := task.FinishedAt.Time
+		record.FinishedAt = &finishedAt
+	}
+
+	if task.BucketId.Valid {
+		bucketId := task.BucketId.Int64
+		record.BucketId = &bucketId
+	}
+
+	return record
+}
+
+// Render writes tasks to w in one of the Formats.
+func Render(w io.Writer, format string, tasks []Task) error {
+	switch format {
+	case FormatTable:
+		RenderTable(w, tasks)
+		return nil
+	case FormatJSON:
+		return RenderJSON(w, tasks)
+	case FormatCSV:
+		return RenderCSV(w, tasks)
+	case FormatMarkdown:
+		RenderMarkdown(w, tasks)
+		return nil
+	default:
+		return fmt.Errorf("Error, unknown format '%s', expected one of %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+func RenderTable(w io.Writer, tasks []Task) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"ID", "Date", "Name", "Planned", "Actual", "Completion Percent", "Completed"})
 	table.SetAutoWrapText(false)
 	table.SetAutoFormatHeaders(true)
 	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
@@ -23,31 +97,107 @@ func RenderTable(tasks []Task) {
 	table.SetTablePadding("\t") // pad with tabs
 	table.SetNoWhiteSpace(true)
 
-	totalMinutes := 0.0
 	for _, task := range tasks {
-		id := fmt.Sprint(task.TaskId)
-		name := task.TaskName
-		planned := fmt.Sprintf("%d", task.EstimatedDurationSeconds)
-		actual := fmt.Sprintf("%d", task.ActualDurationSeconds.Int64)
-		date := task.CreatedAt.Format("Mon Jan 02 15:04:05")
-
-		completionPercent := fmt.Sprintf("%.2f%%", task.CompletionPercent.Float64)
-
 		var completed string
 		if task.Completed == 1 {
 			completed = "✅"
 		}
 
-		row := []string{id, date, name, planned, actual, completionPercent, completed}
+		table.Append(append(summaryRow(task), completed))
+	}
+	table.Render()
+
+	fmt.Fprintln(w)
+	color.New(color.FgCyan).Fprintf(w, "Total: %s\n", utils.SecsToHHMMSS(totalActualSeconds(tasks)))
+}
+
+func RenderJSON(w io.Writer, tasks []Task) error {
+	records := make([]Record, 0, len(tasks))
+	for _, task := range tasks {
+		records = append(records, task.ToRecord())
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+// RenderCSV writes one row per task with durations in seconds, for spreadsheets and scripts.
+func RenderCSV(w io.Writer, tasks []Task) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write([]string{"id", "uuid", "created_at", "finished_at", "name", "planned_seconds", "actual_seconds", "completion_percent", "completed", "bucket_id"})
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		record := task.ToRecord()
+
+		var finishedAt, bucketId string
+		if record.FinishedAt != nil {
+			finishedAt = record.FinishedAt.Format(time.RFC3339)
+		}
+		if record.BucketId != nil {
+			bucketId = strconv.FormatInt(*record.BucketId, 10)
+		}
+
+		err := writer.Write([]string{
+			strconv.FormatInt(record.Id, 10),
+			record.UUID,
+			record.CreatedAt.Format(time.RFC3339),
+			finishedAt,
+			record.Name,
+			strconv.FormatInt(record.PlannedSeconds, 10),
+			strconv.FormatInt(record.ActualSeconds, 10),
+			strconv.FormatFloat(record.CompletionPercent, 'f', 2, 64),
+			strconv.FormatBool(record.Completed),
+			bucketId,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func RenderMarkdown(w io.Writer, tasks []Task) {
+	fmt.Fprintln(w, "| ID | Date | Name | Planned | Actual | Completion Percent | Completed |")
+	fmt.Fprintln(w, "|---|---|---|---|---|---|---|")
+
+	for _, task := range tasks {
+		row := summaryRow(task)
+		row[2] = strings.ReplaceAll(row[2], "|", `\|`)
 
-		if task.ActualDurationSeconds.Valid {
-			totalMinutes += float64(task.ActualDurationSeconds.Int64)
+		completed := "no"
+		if task.Completed == 1 {
+			completed = "yes"
 		}
 
-		table.Append(row)
+		fmt.Fprintf(w, "| %s | %s |\n", strings.Join(row, " | "), completed)
 	}
-	table.Render()
 
-	fmt.Println()
-	color.Cyan(fmt.Sprintf("Total: %.0f minutes", totalMinutes))
+	fmt.Fprintf(w, "\n**Total:** %s\n", utils.SecsToHHMMSS(totalActualSeconds(tasks)))
+}
+
+// summaryRow formats the id, date, name, planned, actual and completion columns of a task.
+func summaryRow(task Task) []string {
+	return []string{
+		fmt.Sprint(task.TaskId),
+		calendar.Default().In(task.CreatedAt).Format("Mon Jan 02 15:04:05"),
+		task.TaskName,
+		utils.SecsToHHMMSS(task.EstimatedDurationSeconds),
+		utils.SecsToHHMMSS(task.ActualDurationSeconds.Int64),
+		fmt.Sprintf("%.2f%%", task.CompletionPercent.Float64),
+	}
+}
+
+func totalActualSeconds(tasks []Task) int64 {
+	var total int64
+	for _, task := range tasks {
+		total += task.ActualDurationSeconds.Int64
+	}
+	return total
 }
@@ -0,0 +1,113 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// OverlapError is returned when a logged session would overlap sessions already recorded.
+type OverlapError struct {
+	Tasks []Task
+}
+
+func (e *OverlapError) Error() string {
+	var sessions []string
+	for _, task := range e.Tasks {
+		sessions = append(sessions, fmt.Sprintf("#%d %q at %s", task.TaskId, task.TaskName, task.CreatedAt.Format("2006-01-02 15:04")))
+	}
+	return "Error, overlaps existing sessions: " + strings.Join(sessions, ", ")
+}
+
+// NewLoggedTask returns a completed task for time worked without a running session.
+func NewLoggedTask(taskName string, duration time.Duration, startedAt time.Time) *Task {
+	seconds := int64(duration.Seconds())
+
+	task := NewTask(taskName, seconds, false, false, startedAt)
+	task.SetActualDuration(int(seconds))
+	task.SetCompletionPercent(100.0)
+	task.SetFinishTime(startedAt.Add(duration))
+
+	return task
+}
+
+// Span returns when a task started and when it finished, or when it is expected to finish
+// from its recorded or planned duration.
+func (task Task) Span() (time.Time, time.Time) {
+	if task.FinishedAt.Valid {
+		return task.CreatedAt, task.FinishedAt.Time
+	}
+
+	seconds := task.EstimatedDurationSeconds
+	if task.ActualDurationSeconds.Valid {
+		seconds = task.ActualDurationSeconds.Int64
+	}
+
+	return task.CreatedAt, task.CreatedAt.Add(time.Duration(seconds) * time.Second)
+}
+
+// Overlapping returns live tasks with time recorded within [start, end). A task's time is its
+// segments, so the gaps between the sessions of a resumed task are free. A task without
+// segments, such as one still running its first session, takes up its whole span.
+func (s *Store) Overlapping(ctx context.Context, start, end time.Time) ([]Task, error) {
+	query := `SELECT * FROM Tasks
+	WHERE deleted_at IS NULL
+	AND (
+		EXISTS (
+			SELECT 1 FROM Segments
+			WHERE Segments.task_id = Tasks.task_id
+			AND julianday(Segments.started_at) < julianday(?)
+			AND julianday(Segments.finished_at) > julianday(?)
+		)
+		OR (
+			NOT EXISTS (SELECT 1 FROM Segments WHERE Segments.task_id = Tasks.task_id)
+			AND julianday(created_at) < julianday(?)
+			AND COALESCE(
+				julianday(finished_at),
+				julianday(created_at, '+' || COALESCE(actual_duration_seconds, estimated_duration_seconds) || ' seconds')
+			) > julianday(?)
+		)
+	)
+	ORDER BY created_at ASC`
+
+	var tasks []Task
+	err := sqlx.SelectContext(ctx, s.db, &tasks, query, end, start, end, start)
+	if err != nil {
+		return tasks, fmt.Errorf("Error checking for overlapping tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// Log records a finished task and its single segment, refusing to overlap existing sessions.
+func (s *Store) Log(ctx context.Context, task *Task) error {
+	start, end := task.Span()
+	if !end.After(start) {
+		return errors.New("Error, duration must be positive")
+	}
+
+	return s.inTx(ctx, func(tx *Store) error {
+		overlapping, err := tx.Overlapping(ctx, start, end)
+		if err != nil {
+			return err
+		}
+		if len(overlapping) > 0 {
+			return &OverlapError{Tasks: overlapping}
+		}
+
+		if err := tx.Insert(ctx, task); err != nil {
+			return err
+		}
+
+		if err := tx.Finish(ctx, *task); err != nil {
+			return err
+		}
+
+		segment := Segment{TaskId: task.TaskId, StartedAt: start, FinishedAt: end, DurationSeconds: int64(end.Sub(start).Seconds())}
+		return tx.InsertSegment(ctx, &segment)
+	})
+}
@@ -0,0 +1,110 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLogTaskRejectsOverlap(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t)
+
+	nine := time.Date(2024, 3, 4, 9, 0, 0, 0, time.Local)
+	if err := store.Log(ctx, NewLoggedTask("write report", 45*time.Minute, nine)); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name    string
+		start   time.Time
+		length  time.Duration
+		overlap bool
+	}{
+		{name: "inside", start: nine.Add(10 * time.Minute), length: 10 * time.Minute, overlap: true},
+		{name: "covering", start: nine.Add(-time.Hour), length: 3 * time.Hour, overlap: true},
+		{name: "ends during", start: nine.Add(-30 * time.Minute), length: 31 * time.Minute, overlap: true},
+		{name: "ends as it starts", start: nine.Add(-30 * time.Minute), length: 30 * time.Minute},
+		{name: "starts as it ends", start: nine.Add(45 * time.Minute), length: 15 * time.Minute},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			overlapping, err := store.Overlapping(ctx, tc.start, tc.start.Add(tc.length))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(overlapping) > 0; got != tc.overlap {
+				t.Errorf("Expected overlap: %v, got: %v", tc.overlap, got)
+			}
+		})
+	}
+
+	err := store.Log(ctx, NewLoggedTask("email", 15*time.Minute, nine.Add(30*time.Minute)))
+	var overlapErr *OverlapError
+	if !errors.As(err, &overlapErr) || len(overlapErr.Tasks) != 1 {
+		t.Fatalf("Expected an overlap with one task, got: %v", err)
+	}
+
+	// an unfinished session spans its planned duration.
+	running := NewTask("running", 1500, true, false, nine.Add(2*time.Hour))
+	if err := store.Insert(ctx, running); err != nil {
+		t.Fatal(err)
+	}
+	err = store.Log(ctx, NewLoggedTask("email", 15*time.Minute, nine.Add(2*time.Hour+20*time.Minute)))
+	if !errors.As(err, &overlapErr) {
+		t.Errorf("Expected an overlap with the unfinished session, got: %v", err)
+	}
+
+	logged, err := store.Select(ctx, NewQuery().Completed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logged) != 1 || logged[0].ActualDurationSeconds.Int64 != 45*60 {
+		t.Errorf("Expected only the first logged task to be saved, got: %+v", logged)
+	}
+
+	segments, err := store.Segments(ctx, logged[0].TaskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 1 || segments[0].DurationSeconds != 45*60 {
+		t.Errorf("Expected one 45 minute segment, got: %+v", segments)
+	}
+}
+
+func TestLogBetweenResumedSegments(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t)
+
+	// a task worked on Monday morning and resumed on Wednesday.
+	monday := time.Date(2024, 3, 4, 9, 0, 0, 0, time.Local)
+	wednesday := monday.AddDate(0, 0, 2)
+
+	task := NewTask("thesis", 3*3600, false, false, monday)
+	if err := store.Insert(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+	for _, start := range []time.Time{monday, wednesday} {
+		segment := Segment{TaskId: task.TaskId, StartedAt: start, FinishedAt: start.Add(time.Hour), DurationSeconds: 3600}
+		if err := store.InsertSegment(ctx, &segment); err != nil {
+			t.Fatal(err)
+		}
+	}
+	task.AccumulateSegment(7200, false)
+	task.SetFinishTime(wednesday.Add(time.Hour))
+	if err := store.Finish(ctx, *task); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Log(ctx, NewLoggedTask("meeting", time.Hour, monday.AddDate(0, 0, 1))); err != nil {
+		t.Errorf("Expected time between the segments to be free, got: %v", err)
+	}
+
+	var overlapErr *OverlapError
+	err := store.Log(ctx, NewLoggedTask("email", 15*time.Minute, wednesday.Add(30*time.Minute)))
+	if !errors.As(err, &overlapErr) {
+		t.Errorf("Expected an overlap with the second segment, got: %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package tasks
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/calendar"
+)
+
+// Sort keys accepted by Query.OrderBy, mapped to their columns.
+var sortColumns = map[string]string{
+	"date":       "created_at",
+	"duration":   "actual_duration_seconds",
+	"estimate":   "estimated_duration_seconds",
+	"name":       "task_name",
+	"completion": "completion_percent",
+}
+
+// Query composes a filtered, sorted and limited SELECT over Tasks.
+//
+//	store.Select(ctx, tasks.NewQuery().Between(from, to).Completed().OrderBy("duration", true).Limit(10))
+//
+// Each filter narrows the result, filters are combined with AND. Deleted tasks are left out
+// unless Deleted is called.
+type Query struct {
+	deleted    bool
+	conditions []string
+	args       []any
+	orderBy    string
+	desc       bool
+	limit      int
+	err        error
+}
+
+// NewQuery returns a query over every task that has not been deleted, newest first.
+func NewQuery() *Query {
+	return &Query{orderBy: "created_at", desc: true}
+}
+
+func (q *Query) where(condition string, args ...any) *Query {
+	q.conditions = append(q.conditions, condition)
+	q.args = append(q.args, args...)
+	return q
+}
+
+// From keeps tasks created at or after t. Times are compared as julian days so rows written
+// under a different utc offset still sort correctly.
+func (q *Query) From(t time.Time) *Query {
+	return q.where("julianday(created_at) >= julianday(?)", t)
+}
+
+// Until keeps tasks created before t.
+func (q *Query) Until(t time.Time) *Query {
+	return q.where("julianday(created_at) < julianday(?)", t)
+}
+
+// Between keeps tasks created in [from, to).
+func (q *Query) Between(from, to time.Time) *Query {
+	return q.From(from).Until(to)
+}
+
+// OnDay keeps tasks created during the day containing t.
+func (q *Query) OnDay(cal calendar.Calendar, t time.Time) *Query {
+	return q.Between(cal.DayBounds(t))
+}
+
+func (q *Query) Bucket(bucketId int64) *Query {
+	return q.where("bucket_id = ?", bucketId)
+}
+
+// Completed keeps tasks that ran for their full planned time.
+func (q *Query) Completed() *Query {
+	return q.where("completed = 1")
+}
+
+// Cancelled keeps tasks that were stopped before their planned time.
+func (q *Query) Cancelled() *Query {
+	return q.where("(completed = 0 OR completed IS NULL) AND finished_at IS NOT NULL")
+}
+
+// Incomplete keeps tasks that have not been completed, whether cancelled or never finished.
+func (q *Query) Incomplete() *Query {
+	return q.where("(completed = 0 OR completed IS NULL)")
+}
+
+// Captured keeps tasks with screen capture enabled.
+func (q *Query) Captured() *Query {
+	return q.where("screen_enabled = 1")
+}
+
+// MinActualSeconds keeps tasks that ran for at least n seconds.
+func (q *Query) MinActualSeconds(n int64) *Query {
+	return q.where("actual_duration_seconds >= ?", n)
+}
+
+// Search keeps tasks whose name contains text, case-insensitively.
+func (q *Query) Search(text string) *Query {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(text)
+	return q.where(`task_name LIKE ? ESCAPE '\'`, "%"+escaped+"%")
+}
+
+// Tagged keeps tasks carrying every one of the named tags.
+func (q *Query) Tagged(names ...string) *Query {
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q.where(`task_id IN (SELECT tt.task_id FROM TaskTags tt JOIN Tags t ON t.tag_id = tt.tag_id WHERE t.tag_name = ?)`, name)
+	}
+	return q
+}
+
+// Deleted selects deleted tasks instead of live ones.
+func (q *Query) Deleted() *Query {
+	q.deleted = true
+	return q
+}
+
+// OrderBy sorts by one of date, duration, estimate, name or completion.
+// An unknown key is reported when the query is built.
+func (q *Query) OrderBy(key string, desc bool) *Query {
+	column, ok := sortColumns[key]
+	if !ok {
+		q.err = fmt.Errorf("Error, unknown sort '%s', expected one of date, duration, estimate, name or completion", key)
+		return q
+	}
+	q.orderBy = column
+	q.desc = desc
+	return q
+}
+
+// Limit caps the number of tasks returned, 0 means no limit.
+func (q *Query) Limit(n int) *Query {
+	q.limit = n
+	return q
+}
+
+// Build returns the SQL and arguments for the query.
+func (q *Query) Build() (string, []any, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("SELECT * FROM Tasks WHERE ")
+	sb.WriteString(q.filter())
+
+	direction := "ASC"
+	if q.desc {
+		direction = "DESC"
+	}
+	fmt.Fprintf(&sb, " ORDER BY %s %s, task_id %s", q.orderBy, direction, direction)
+
+	args := append([]any{}, q.args...)
+	if q.limit > 0 {
+		sb.WriteString(" LIMIT ?")
+		args = append(args, q.limit)
+	}
+
+	return sb.String(), args, nil
+}
+
+// filter returns the WHERE clause of the query, without sorting or limit. Its arguments are q.args.
+func (q *Query) filter() string {
+	var sb strings.Builder
+	if q.deleted {
+		sb.WriteString("deleted_at IS NOT NULL")
+	} else {
+		sb.WriteString("deleted_at IS NULL")
+	}
+
+	for _, condition := range q.conditions {
+		sb.WriteString(" AND ")
+		sb.WriteString(condition)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,170 @@
+package tasks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/db/dbtest"
+	"github.com/jmoiron/sqlx"
+)
+
+func openTestStore(t *testing.T) *Store {
+	return NewStore(dbtest.Open(t))
+}
+
+func insertFinished(t *testing.T, store *Store, name string, createdAt time.Time, actualSeconds int, completionPercent float64) Task {
+	t.Helper()
+
+	ctx := context.Background()
+	task := NewTask(name, 1500, false, false, createdAt)
+	if err := store.Insert(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+
+	task.SetActualDuration(actualSeconds)
+	task.SetCompletionPercent(completionPercent)
+	task.SetFinishTime(createdAt.Add(time.Duration(actualSeconds) * time.Second))
+	if err := store.Finish(ctx, *task); err != nil {
+		t.Fatal(err)
+	}
+
+	return *task
+}
+
+func taskNames(all []Task) []string {
+	var names []string
+	for _, task := range all {
+		names = append(names, task.TaskName)
+	}
+	return names
+}
+
+func TestQuery(t *testing.T) {
+	store := openTestStore(t)
+
+	local, _ := calendar.New(time.Local, 0)
+	day := time.Date(2024, 3, 4, 9, 0, 0, 0, time.Local)
+	insertFinished(t, store, "write report", day, 1500, 100)
+	insertFinished(t, store, "review 100% of PRs", day.Add(time.Hour), 600, 40)
+	insertFinished(t, store, "plan sprint", day.AddDate(0, 0, 1), 900, 100)
+	insertFinished(t, store, "email", day.AddDate(0, 0, -1), 300, 20)
+
+	testCases := []struct {
+		name  string
+		query *Query
+		want  []string
+	}{
+		{
+			name:  "all newest first",
+			query: NewQuery(),
+			want:  []string{"plan sprint", "review 100% of PRs", "write report", "email"},
+		},
+		{
+			name:  "on day",
+			query: NewQuery().OnDay(local, day),
+			want:  []string{"review 100% of PRs", "write report"},
+		},
+		{
+			name:  "between is half open",
+			query: NewQuery().Between(local.DayBounds(day)).OrderBy("date", false),
+			want:  []string{"write report", "review 100% of PRs"},
+		},
+		{
+			name:  "completed",
+			query: NewQuery().Completed().OrderBy("name", false),
+			want:  []string{"plan sprint", "write report"},
+		},
+		{
+			name:  "cancelled",
+			query: NewQuery().Cancelled().OrderBy("duration", true),
+			want:  []string{"review 100% of PRs", "email"},
+		},
+		{
+			name:  "search escapes wildcards",
+			query: NewQuery().Search("100%"),
+			want:  []string{"review 100% of PRs"},
+		},
+		{
+			name:  "search is case insensitive",
+			query: NewQuery().Search("PLAN"),
+			want:  []string{"plan sprint"},
+		},
+		{
+			name:  "limit",
+			query: NewQuery().OrderBy("duration", true).Limit(2),
+			want:  []string{"write report", "plan sprint"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			all, err := store.Select(context.Background(), tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := taskNames(all)
+			if len(got) != len(tc.want) {
+				t.Fatalf("Expected: %v, got: %v", tc.want, got)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("Expected: %v, got: %v", tc.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryTagged(t *testing.T) {
+	store := openTestStore(t)
+
+	day := time.Date(2024, 3, 4, 9, 0, 0, 0, time.Local)
+	both := insertFinished(t, store, "both", day, 60, 100)
+	one := insertFinished(t, store, "one", day, 60, 100)
+	insertFinished(t, store, "none", day, 60, 100)
+
+	sqlx.MustExec(store.db.(*sqlx.DB), "INSERT INTO Tags (tag_name, created_at) VALUES ('deep', ?), ('client', ?)", day, day)
+	sqlx.MustExec(store.db.(*sqlx.DB), "INSERT INTO TaskTags (task_id, tag_id) VALUES (?, 1), (?, 2), (?, 1)", both.TaskId, both.TaskId, one.TaskId)
+
+	all, err := store.Select(context.Background(), NewQuery().Tagged("Deep", "client"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := taskNames(all); len(got) != 1 || got[0] != "both" {
+		t.Errorf("Expected: [both], got: %v", got)
+	}
+}
+
+func TestQueryOnDayAcrossDST(t *testing.T) {
+	cal, err := calendar.Load("America/New_York", 0)
+	if err != nil {
+		t.Skip(err)
+	}
+	ny := cal.Location()
+
+	store := openTestStore(t)
+
+	// clocks go back an hour at 02:00 on the 3rd of November 2024, making the 3rd 25 hours long.
+	insertFinished(t, store, "before", time.Date(2024, 11, 2, 23, 30, 0, 0, ny), 60, 100)
+	insertFinished(t, store, "early", time.Date(2024, 11, 3, 0, 30, 0, 0, ny), 60, 100)
+	insertFinished(t, store, "late", time.Date(2024, 11, 3, 23, 30, 0, 0, ny), 60, 100)
+	insertFinished(t, store, "after", time.Date(2024, 11, 4, 0, 10, 0, 0, ny), 60, 100)
+
+	// stored with a utc offset, the 3rd is looked up from another timezone.
+	all, err := store.Select(context.Background(), NewQuery().OnDay(cal, time.Date(2024, 11, 3, 12, 0, 0, 0, time.UTC)).OrderBy("date", false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := taskNames(all); len(got) != 2 || got[0] != "early" || got[1] != "late" {
+		t.Errorf("Expected: [early late], got: %v", got)
+	}
+}
+
+func TestQueryUnknownSort(t *testing.T) {
+	if _, _, err := NewQuery().OrderBy("colour", false).Build(); err == nil {
+		t.Error("Expected error for unknown sort, got nil")
+	}
+}
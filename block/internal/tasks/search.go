@@ -0,0 +1,112 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Matched words in Match.Name and Match.Notes are wrapped in these markers, see Highlight.
+const (
+	HighlightStart = "\x02"
+	HighlightEnd   = "\x03"
+)
+
+// Match is a task found by a full-text search.
+type Match struct {
+	Task
+	// Name is the task name with matched words marked.
+	Name string `db:"name_highlight"`
+	// Notes is an excerpt of the task's notes around the matched words, empty when only the name matched.
+	Notes string `db:"notes_snippet"`
+	// Score ranks the match, lower is better.
+	Score float64 `db:"score"`
+}
+
+// Search finds the tasks selected by q whose name or notes contain every word of text,
+// best match first. Words match by prefix, so "pay fix" finds "fixed the payments bug".
+// The sort order of q is ignored, its filters and limit apply.
+func (s *Store) Search(ctx context.Context, text string, q *Query) ([]Match, error) {
+	match, err := ftsQuery(text)
+	if err != nil {
+		return nil, err
+	}
+	if q.err != nil {
+		return nil, q.err
+	}
+
+	// name matches weigh more than note matches.
+	query := `SELECT Tasks.*
+	, highlight(TaskSearch, 0, ?, ?) AS name_highlight
+	, snippet(TaskSearch, 1, ?, ?, '…', 12) AS notes_snippet
+	, bm25(TaskSearch, 4.0, 1.0) AS score
+	FROM TaskSearch JOIN Tasks ON Tasks.task_id = TaskSearch.rowid
+	WHERE TaskSearch MATCH ? AND Tasks.task_id IN (SELECT task_id FROM Tasks WHERE ` + q.filter() + `)
+	ORDER BY score, Tasks.created_at DESC`
+
+	args := []any{HighlightStart, HighlightEnd, HighlightStart, HighlightEnd, match}
+	args = append(args, q.args...)
+	if q.limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.limit)
+	}
+
+	var matches []Match
+	err = sqlx.SelectContext(ctx, s.db, &matches, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Error searching tasks: %w", err)
+	}
+
+	for i := range matches {
+		if !strings.Contains(matches[i].Notes, HighlightStart) {
+			matches[i].Notes = ""
+		}
+	}
+
+	return matches, nil
+}
+
+// Highlight rewrites the markers in s, passing marked words through mark and the rest through plain.
+func Highlight(s string, plain, mark func(string) string) string {
+	var sb strings.Builder
+	for {
+		start := strings.Index(s, HighlightStart)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(s[start:], HighlightEnd)
+		if end < 0 {
+			break
+		}
+		end += start
+
+		sb.WriteString(plain(s[:start]))
+		sb.WriteString(mark(s[start+len(HighlightStart) : end]))
+		s = s[end+len(HighlightEnd):]
+	}
+	sb.WriteString(plain(s))
+
+	return sb.String()
+}
+
+// ftsQuery turns free text into an fts5 query matching every word as a prefix. Words are quoted,
+// so fts5 operators typed by the user are searched for literally.
+func ftsQuery(text string) (string, error) {
+	var terms []string
+	for _, word := range strings.Fields(text) {
+		if strings.IndexFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+			continue
+		}
+		terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+
+	if len(terms) == 0 {
+		return "", errors.New("Error, search needs at least one word")
+	}
+
+	return strings.Join(terms, " "), nil
+}
@@ -0,0 +1,91 @@
+package tasks
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t)
+	conn := store.db.(*sqlx.DB)
+
+	day := time.Date(2024, 3, 4, 9, 0, 0, 0, time.Local)
+	payments := insertFinished(t, store, "fix payments bug", day, 1500, 100)
+	review := insertFinished(t, store, "code review", day.Add(time.Hour), 600, 100)
+	insertFinished(t, store, "email", day.Add(2*time.Hour), 300, 100)
+	old := insertFinished(t, store, "payments refactor", day.AddDate(0, 0, -7), 900, 100)
+
+	sqlx.MustExec(conn, "INSERT INTO Notes (task_id, outcome, created_at) VALUES (?, ?, ?)", review.TaskId, "found the payments rounding issue", day)
+
+	search := func(text string, q *Query) []string {
+		t.Helper()
+		matches, err := store.Search(ctx, text, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, m := range matches {
+			names = append(names, m.TaskName)
+		}
+		return names
+	}
+
+	testCases := []struct {
+		name  string
+		text  string
+		query *Query
+		want  []string
+	}{
+		{name: "name outranks notes", text: "payments", query: NewQuery().From(day), want: []string{"fix payments bug", "code review"}},
+		{name: "every word must match", text: "payments bug", query: NewQuery(), want: []string{"fix payments bug"}},
+		{name: "prefix", text: "PAY fix", query: NewQuery(), want: []string{"fix payments bug"}},
+		{name: "notes only", text: "rounding", query: NewQuery(), want: []string{"code review"}},
+		{name: "operators are literal", text: `"email" OR`, query: NewQuery(), want: nil},
+		{name: "limit", text: "payments", query: NewQuery().Until(day).Limit(1), want: []string{"payments refactor"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := search(tc.text, tc.query); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Expected: %v, got: %v", tc.want, got)
+			}
+		})
+	}
+
+	matches, err := store.Search(ctx, "rounding", NewQuery())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mark := func(s string) string { return "[" + s + "]" }
+	same := func(s string) string { return s }
+	if got := Highlight(matches[0].Notes, same, mark); !strings.Contains(got, "[rounding]") {
+		t.Errorf("Expected the notes to highlight the match, got: %q", got)
+	}
+
+	// the index follows renames and deletes.
+	if err := store.Edit(ctx, payments.TaskId, "fix checkout bug", 1500, SourceCLI); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Delete(ctx, []int64{old.TaskId}, SourceCLI, day); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := search("payments", NewQuery()), []string{"code review"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected: %v, got: %v", want, got)
+	}
+	if got, want := search("checkout", NewQuery()), []string{"fix checkout bug"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected: %v, got: %v", want, got)
+	}
+	if got, want := search("payments", NewQuery().Deleted()), []string{"payments refactor"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected: %v, got: %v", want, got)
+	}
+
+	if _, err := store.Search(ctx, " - ", NewQuery()); err == nil {
+		t.Error("Expected error searching without words, got nil")
+	}
+}
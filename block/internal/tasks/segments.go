@@ -0,0 +1,49 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Segment is one uninterrupted run of a task. A task started once and never resumed has a single segment.
+type Segment struct {
+	SegmentId       int64     `db:"segment_id"`
+	TaskId          int64     `db:"task_id"`
+	StartedAt       time.Time `db:"started_at"`
+	FinishedAt      time.Time `db:"finished_at"`
+	DurationSeconds int64     `db:"duration_seconds"`
+}
+
+// InsertSegment saves a segment and sets its SegmentId.
+func (s *Store) InsertSegment(ctx context.Context, segment *Segment) error {
+	query := `INSERT INTO Segments (task_id, started_at, finished_at, duration_seconds) VALUES (:task_id, :started_at, :finished_at, :duration_seconds)`
+
+	result, err := sqlx.NamedExecContext(ctx, s.db, query, segment)
+	if err != nil {
+		return fmt.Errorf("Error inserting segment for task %d: %w", segment.TaskId, err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	segment.SegmentId = id
+
+	return nil
+}
+
+// Segments returns the segments of a task, oldest first.
+func (s *Store) Segments(ctx context.Context, taskId int64) ([]Segment, error) {
+	var segments []Segment
+
+	err := sqlx.SelectContext(ctx, s.db, &segments, "SELECT * FROM Segments WHERE task_id = ? ORDER BY started_at ASC", taskId)
+	if err != nil {
+		return segments, fmt.Errorf("Error getting segments for task %d: %w", taskId, err)
+	}
+
+	return segments, nil
+}
@@ -0,0 +1,235 @@
+package tasks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrNotFound matches every NotFoundError with errors.Is.
+var ErrNotFound = errors.New("Error, task not found")
+
+// NotFoundError is returned when no task matches an id, uuid or query.
+type NotFoundError struct {
+	Key string
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Key == "" {
+		return "Error, no matching task"
+	}
+	return fmt.Sprintf("Error, no task with id %s", e.Key)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+func notFoundById(id int64) error {
+	return &NotFoundError{Key: fmt.Sprint(id)}
+}
+
+// TaskStore reads and writes tasks with their segments and audit log.
+type TaskStore interface {
+	Get(ctx context.Context, id int64) (Task, error)
+	GetByUUID(ctx context.Context, uuid string) (Task, error)
+	Select(ctx context.Context, q *Query) ([]Task, error)
+	First(ctx context.Context, q *Query) (Task, error)
+	Insert(ctx context.Context, task *Task) error
+	Finish(ctx context.Context, task Task) error
+
+	InsertSegment(ctx context.Context, segment *Segment) error
+	Segments(ctx context.Context, taskId int64) ([]Segment, error)
+
+	Search(ctx context.Context, text string, q *Query) ([]Match, error)
+
+	Overlapping(ctx context.Context, start, end time.Time) ([]Task, error)
+	Log(ctx context.Context, task *Task) error
+
+	Edit(ctx context.Context, taskId int64, taskName string, actualDurationSeconds int64, source string) error
+	Delete(ctx context.Context, ids []int64, source string, now time.Time) ([]Task, error)
+	Restore(ctx context.Context, ids []int64, source string) ([]Task, error)
+	Undo(ctx context.Context, source string, now time.Time) ([]AuditEntry, error)
+	Audit(ctx context.Context, taskId int64) ([]AuditEntry, error)
+
+	InTx(ctx context.Context, fn func(TaskStore) error) error
+}
+
+// Store is the sqlite TaskStore. It runs against a database, or against an open transaction
+// so tasks can be written alongside other tables.
+type Store struct {
+	db sqlx.ExtContext
+}
+
+var _ TaskStore = (*Store)(nil)
+
+// NewStore returns a store over db, a *sqlx.DB or *sqlx.Tx.
+func NewStore(db sqlx.ExtContext) *Store {
+	return &Store{db: db}
+}
+
+// InTx runs fn in a transaction, committing if it returns nil. A store that is already
+// running in a transaction runs fn in that transaction.
+func (s *Store) InTx(ctx context.Context, fn func(TaskStore) error) error {
+	return s.inTx(ctx, func(tx *Store) error {
+		return fn(tx)
+	})
+}
+
+func (s *Store) inTx(ctx context.Context, fn func(tx *Store) error) error {
+	db, ok := s.db.(*sqlx.DB)
+	if !ok {
+		return fn(s)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(NewStore(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Get fetches a task by id, including deleted tasks.
+func (s *Store) Get(ctx context.Context, id int64) (Task, error) {
+	var task Task
+
+	err := sqlx.GetContext(ctx, s.db, &task, "SELECT * FROM Tasks WHERE task_id = ?", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return task, notFoundById(id)
+	}
+	if err != nil {
+		return task, fmt.Errorf("Error getting task %d: %w", id, err)
+	}
+
+	return task, nil
+}
+
+// GetByUUID fetches a task by uuid, including deleted tasks.
+func (s *Store) GetByUUID(ctx context.Context, uuid string) (Task, error) {
+	var task Task
+
+	err := sqlx.GetContext(ctx, s.db, &task, "SELECT * FROM Tasks WHERE task_uuid = ?", uuid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return task, &NotFoundError{Key: uuid}
+	}
+	if err != nil {
+		return task, fmt.Errorf("Error getting task %s: %w", uuid, err)
+	}
+
+	return task, nil
+}
+
+// Select runs the query and returns every matching task.
+func (s *Store) Select(ctx context.Context, q *Query) ([]Task, error) {
+	query, args, err := q.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []Task
+	err = sqlx.SelectContext(ctx, s.db, &tasks, query, args...)
+	if err != nil {
+		return tasks, fmt.Errorf("Error querying tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// First returns the first task matching the query, or a NotFoundError.
+func (s *Store) First(ctx context.Context, q *Query) (Task, error) {
+	var task Task
+
+	query, args, err := q.Limit(1).Build()
+	if err != nil {
+		return task, err
+	}
+
+	err = sqlx.GetContext(ctx, s.db, &task, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return task, &NotFoundError{}
+	}
+	if err != nil {
+		return task, fmt.Errorf("Error querying tasks: %w", err)
+	}
+
+	return task, nil
+}
+
+// Insert saves a new task and sets its TaskId.
+func (s *Store) Insert(ctx context.Context, task *Task) error {
+	insertQuery := `INSERT INTO Tasks
+	(
+	  task_name
+	, estimated_duration_seconds
+	, blocker_enabled
+	, screen_enabled
+	, screen_url
+	, created_at
+	, completed
+	, completion_percent
+	, bucket_id
+	, task_uuid
+	)
+	VALUES
+	(
+	  :task_name
+	, :estimated_duration_seconds
+	, :blocker_enabled
+	, :screen_enabled
+	, :screen_url
+	, :created_at
+	, :completed
+	, :completion_percent
+	, :bucket_id
+	, :task_uuid
+	)`
+
+	result, err := sqlx.NamedExecContext(ctx, s.db, insertQuery, task)
+	if err != nil {
+		return fmt.Errorf("Error inserting task: %w", err)
+	}
+
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	task.TaskId = lastInsertID
+
+	return nil
+}
+
+// Finish saves the finish time, actual duration and completion of a task.
+func (s *Store) Finish(ctx context.Context, task Task) error {
+	query := "UPDATE Tasks SET finished_at = ?, actual_duration_seconds = ?, completion_percent = ?, completed = ? WHERE task_id = ?"
+
+	return s.update(ctx, task.TaskId, query, task.FinishedAt, task.ActualDurationSeconds, task.CompletionPercent, task.Completed, task.TaskId)
+}
+
+// update runs a statement changing one task, returning a NotFoundError if no row changed.
+func (s *Store) update(ctx context.Context, taskId int64, query string, args ...any) error {
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("Error updating task %d: %w", taskId, err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Error updating task %d: %w", taskId, err)
+	}
+	if n == 0 {
+		return notFoundById(taskId)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStoreNotFound(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t)
+
+	day := time.Date(2024, 3, 4, 9, 0, 0, 0, time.Local)
+	task := insertFinished(t, store, "write report", day, 1500, 100)
+
+	missing := Task{TaskId: task.TaskId + 1}
+
+	testCases := []struct {
+		name string
+		call func() error
+	}{
+		{name: "get", call: func() error { _, err := store.Get(ctx, missing.TaskId); return err }},
+		{name: "get by uuid", call: func() error { _, err := store.GetByUUID(ctx, "no-such-uuid"); return err }},
+		{name: "first", call: func() error { _, err := store.First(ctx, NewQuery().Incomplete()); return err }},
+		{name: "finish", call: func() error { return store.Finish(ctx, missing) }},
+		{name: "edit", call: func() error { return store.Edit(ctx, missing.TaskId, "renamed", 60, SourceCLI) }},
+		{name: "delete", call: func() error { _, err := store.Delete(ctx, []int64{missing.TaskId}, SourceCLI, day); return err }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.call()
+			var notFound *NotFoundError
+			if !errors.Is(err, ErrNotFound) || !errors.As(err, &notFound) {
+				t.Errorf("Expected a NotFoundError, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestStoreGetAndSegments(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t)
+
+	day := time.Date(2024, 3, 4, 9, 0, 0, 0, time.Local)
+	task := insertFinished(t, store, "write report", day, 1500, 100)
+
+	got, err := store.GetByUUID(ctx, task.TaskUUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.TaskId != task.TaskId || got.ActualDurationSeconds.Int64 != 1500 || got.Completed != 1 {
+		t.Errorf("Expected the finished task, got: %+v", got)
+	}
+
+	for _, start := range []time.Time{day.Add(10 * time.Minute), day} {
+		segment := Segment{TaskId: task.TaskId, StartedAt: start, FinishedAt: start.Add(5 * time.Minute), DurationSeconds: 300}
+		if err := store.InsertSegment(ctx, &segment); err != nil {
+			t.Fatal(err)
+		}
+		if segment.SegmentId == 0 {
+			t.Error("Expected the segment id to be set")
+		}
+	}
+
+	segments, err := store.Segments(ctx, task.TaskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 2 || !segments[0].StartedAt.Equal(day) {
+		t.Errorf("Expected two segments oldest first, got: %+v", segments)
+	}
+}
+
+func TestStoreInTxRollsBack(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t)
+
+	day := time.Date(2024, 3, 4, 9, 0, 0, 0, time.Local)
+	failure := errors.New("failure")
+
+	err := store.InTx(ctx, func(tx TaskStore) error {
+		if err := tx.Insert(ctx, NewTask("discarded", 1500, false, false, day)); err != nil {
+			return err
+		}
+
+		// a nested transaction joins the outer one.
+		err := tx.InTx(ctx, func(nested TaskStore) error {
+			return nested.Insert(ctx, NewTask("also discarded", 1500, false, false, day))
+		})
+		if err != nil {
+			return err
+		}
+
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Expected the transaction error, got: %v", err)
+	}
+
+	all, err := store.Select(ctx, NewQuery())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 0 {
+		t.Errorf("Expected no tasks after rollback, got: %v", taskNames(all))
+	}
+}
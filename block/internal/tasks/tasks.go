@@ -2,12 +2,10 @@ package tasks
 
 import (
 	"database/sql"
-	"fmt"
-	"log"
 	"time"
 
 	"github.com/connorkuljis/block-cli/internal/utils"
-	"github.com/jmoiron/sqlx"
+	"github.com/google/uuid"
 
 	_ "modernc.org/sqlite"
 )
@@ -26,28 +24,11 @@ type Task struct {
 	CompletionPercent        sql.NullFloat64 `db:"completion_percent"`
 	Status                   sql.NullString  `db:"status"`
 	BucketId                 sql.NullInt64   `db:"bucket_id"`
+	TaskUUID                 string          `db:"task_uuid"`
+	DeletedAt                sql.NullTime    `db:"deleted_at"`
+	UpdatedAt                sql.NullTime    `db:"updated_at"`
 }
 
-const TasksSchema = `
-	CREATE TABLE IF NOT EXISTS Tasks
-	(
-      task_id                    INTEGER PRIMARY KEY AUTOINCREMENT
-    , task_name                  TEXT NOT NULL
-    , estimated_duration_seconds INTEGER NOT NULL
-    , actual_duration_seconds    INTEGER
-    , blocker_enabled            INTEGER DEFAULT 0
-    , screen_enabled             INTEGER DEFAULT 0
-    , screen_url                 TEXT
-    , created_at                 TIMESTAMP NOT NULL
-    , finished_at                TIMESTAMP
-    , completed                  INTEGER
-    , completion_percent         REAL
-    , status                     TEXT           
-    , bucket_id                  INTEGER
-    , FOREIGN KEY (bucket_id) REFERENCES Buckets(bucket_id)
-	);
-`
-
 func NewTask(taskName string, durationSeconds int64, blockerEnabled bool, screenEnabled bool, createdAt time.Time) *Task {
 	return &Task{
 		TaskName:                 taskName,
@@ -61,6 +42,7 @@ func NewTask(taskName string, durationSeconds int64, blockerEnabled bool, screen
 		Completed:                0,
 		CompletionPercent:        sql.NullFloat64{Valid: false},
 		BucketId:                 sql.NullInt64{Valid: false},
+		TaskUUID:                 uuid.NewString(),
 	}
 }
 
@@ -87,248 +69,20 @@ func (task *Task) SetActualDuration(actualDurationSeconds int) {
 	task.ActualDurationSeconds = sql.NullInt64{Int64: int64(actualDurationSeconds), Valid: true}
 }
 
-func InsertTask(db *sqlx.DB, task *Task) error {
-	insertQuery := `INSERT INTO Tasks 
-	(
-	  task_name
-	, estimated_duration_seconds
-	, blocker_enabled
-	, screen_enabled
-	, screen_url
-	, created_at
-	, completed
-	, completion_percent
-	, bucket_id
-	) 
-	VALUES 
-	(
-	  :task_name
-	, :estimated_duration_seconds
-	, :blocker_enabled
-	, :screen_enabled
-	, :screen_url
-	, :created_at
-	, :completed
-	, :completion_percent
-	, :bucket_id
-	)`
-
-	result, err := db.NamedExec(insertQuery, task)
-	if err != nil {
-		return err
-	}
-
-	lastInsertID, err := result.LastInsertId()
-	if err != nil {
-		return err
-	}
-
-	task.TaskId = lastInsertID
-
-	return nil
-}
-
-func GetTaskByID(db *sqlx.DB, id int64) (Task, error) {
-	var task Task
-	err := db.Get(&task, "SELECT * FROM Tasks WHERE task_id = ?", id)
-	if err != nil {
-		return task, err
-	}
-
-	return task, nil
-}
-
-func GetAllTasks(db *sqlx.DB) ([]Task, error) {
-	var tasks []Task
-
-	rows, err := db.Queryx("SELECT * FROM Tasks ORDER BY created_at DESC")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	for rows.Next() {
-		var t Task
-		err = rows.StructScan(&t)
-		if err != nil {
-			log.Fatal(err)
-		}
-		tasks = append(tasks, t)
-	}
-
-	return tasks, nil
-}
-
-func GetTasksByBucketId(db *sqlx.DB, bucketId int64) ([]Task, error) {
-	var tasks []Task
-
-	err := db.Select(&tasks, "SELECT * FROM Tasks WHERE bucket_id = ?", bucketId)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	return tasks, nil
-}
-
-func GetAllCompletedTasks(db *sqlx.DB) ([]Task, error) {
-	var tasks []Task
-
-	err := db.Select(&tasks, "SELECT * FROM Tasks WHERE completed = 1 AND actual_duration_seconds > 5 ORDER BY created_at ASC")
-	if err != nil {
-		return tasks, nil
-	}
-
-	return tasks, nil
-}
-
-func UpdateTaskAsFinished(db *sqlx.DB, task Task) error {
-	query := "UPDATE Tasks SET finished_at = ?, actual_duration_seconds = ?, completion_percent = ?, completed = ? WHERE task_id = ?"
-
-	result, err := db.Exec(query, task.FinishedAt, task.ActualDurationSeconds, task.CompletionPercent, task.Completed, task.TaskId)
-	if err != nil {
-		return err
-	}
-
-	_, err = result.LastInsertId()
-	if err != nil {
-		return err
-	}
-
-	return nil
+// RemainingSeconds returns the planned time not yet worked on the task.
+func (task *Task) RemainingSeconds() int64 {
+	return max(task.EstimatedDurationSeconds-task.ActualDurationSeconds.Int64, 0)
 }
 
-func UpdateTaskFinishById(db *sqlx.DB, taskId int64, taskName string, actualDurationSeconds int64) error {
-	query := `UPDATE Tasks SET task_name = ?, actual_duration_seconds = ? WHERE task_id = ?`
+// AccumulateSegment adds the seconds worked in a segment to the task and recomputes the completion
+// percent across every segment. finished reports whether the segment ran to the end of the planned time.
+func (task *Task) AccumulateSegment(seconds int, finished bool) {
+	total := task.ActualDurationSeconds.Int64 + int64(seconds)
+	task.SetActualDuration(int(total))
 
-	result, err := db.Exec(query, taskName, actualDurationSeconds, taskId)
-	if err != nil {
-		return fmt.Errorf("Error updating task %d: %w", taskId, err)
+	percent := 100.0
+	if !finished && task.EstimatedDurationSeconds > 0 {
+		percent = min(float64(total)/float64(task.EstimatedDurationSeconds)*100, 100.0)
 	}
-
-	_, err = result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("Error updating task %d: %w", taskId, err)
-	}
-
-	return nil
-}
-
-func UpdateScreenURL(db *sqlx.DB, task Task, target string) error {
-	query := "UPDATE Tasks SET screen_url = ? WHERE task_id = ?"
-
-	result, err := db.Exec(query, target, task.TaskId)
-	if err != nil {
-		return err
-	}
-
-	_, err = result.LastInsertId()
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func UpdateCompletionPercent(db *sqlx.DB, inTask Task, inCompletionPercent float64) error {
-	query := "UPDATE Tasks SET completion_percent = ?, completed = ? WHERE task_id = ?"
-
-	completionPercent := sql.NullFloat64{
-		Float64: inCompletionPercent,
-		Valid:   true,
-	}
-
-	// allow nullable for timer/stopwatch
-	if inCompletionPercent < 0 {
-		completionPercent.Valid = false
-	}
-
-	completed := 0
-	if inCompletionPercent == 100.0 {
-		completed = 1
-	}
-
-	result, err := db.Exec(query, completionPercent, completed, inTask.TaskId)
-	if err != nil {
-		return err
-	}
-
-	_, err = result.LastInsertId()
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func DeleteTaskByID(db *sqlx.DB, id string) (int64, error) {
-	query := "DELETE FROM Tasks WHERE task_id = ?"
-	var rowsAffected int64
-
-	result, err := db.Exec(query, id)
-	if err != nil {
-		return rowsAffected, err
-	}
-
-	rowsAffected, err = result.RowsAffected()
-	if err != nil {
-		return rowsAffected, err
-	}
-
-	return rowsAffected, nil
-}
-
-func GetTasksByDate(db *sqlx.DB, inDate time.Time) ([]Task, error) {
-	query := `SELECT * FROM Tasks WHERE DATE(created_at) = DATE(?)`
-
-	var tasks []Task
-
-	err := db.Select(&tasks, query, inDate)
-	if err != nil {
-		return tasks, err
-	}
-
-	return tasks, nil
-}
-
-func GetTasksByDateRange(db *sqlx.DB, startDate, endDate time.Time) ([]Task, error) {
-	query := `SELECT * FROM Tasks WHERE DATE(created_at) BETWEEN ? AND ?`
-
-	var tasks []Task
-
-	err := db.Select(&tasks, query, startDate, endDate)
-	if err != nil {
-		return tasks, err
-	}
-
-	return tasks, nil
-}
-
-func GetRecentTasks(db *sqlx.DB, startDate time.Time, daysBack int) ([]Task, error) {
-	var tasks []Task
-
-	query := `SELECT * FROM Tasks WHERE created_at >= ?`
-
-	prevDate := startDate.AddDate(0, 0, -daysBack)
-
-	err := db.Select(&tasks, query, prevDate)
-	if err != nil {
-		return tasks, err
-	}
-
-	return tasks, nil
-}
-
-func GetCapturedTasksByDate(db *sqlx.DB, inDate time.Time) ([]Task, error) {
-	query := `SELECT * FROM Tasks 
-	WHERE DATE(created_at) = DATE(?)
-	AND screen_enabled = 1
-	AND completed = 1`
-
-	var tasks []Task
-
-	err := db.Select(&tasks, query, inDate)
-	if err != nil {
-		return tasks, nil
-	}
-
-	return tasks, nil
+	task.SetCompletionPercent(percent)
 }
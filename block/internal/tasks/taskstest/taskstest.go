@@ -0,0 +1,53 @@
+// Package taskstest builds finished tasks for tests that work on tasks already loaded, such as
+// reports, goals and estimates.
+package taskstest
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/tasks"
+)
+
+// Option changes a task built by Finished.
+type Option func(task *tasks.Task)
+
+// Finished returns a completed task created at createdAt that ran for minutes, its full estimate.
+func Finished(createdAt time.Time, minutes int, opts ...Option) tasks.Task {
+	seconds := int64(minutes * 60)
+	task := tasks.Task{
+		TaskName:                 "task",
+		EstimatedDurationSeconds: seconds,
+		ActualDurationSeconds:    sql.NullInt64{Int64: seconds, Valid: true},
+		CreatedAt:                createdAt,
+		FinishedAt:               sql.NullTime{Time: createdAt.Add(time.Duration(seconds) * time.Second), Valid: true},
+		Completed:                1,
+	}
+	for _, opt := range opts {
+		opt(&task)
+	}
+	return task
+}
+
+func Named(name string) Option {
+	return func(task *tasks.Task) { task.TaskName = name }
+}
+
+// Estimated plans the task for minutes, leaving the time it ran as it is.
+func Estimated(minutes int) Option {
+	return func(task *tasks.Task) { task.EstimatedDurationSeconds = int64(minutes * 60) }
+}
+
+func InBucket(bucketId int64) Option {
+	return func(task *tasks.Task) { task.BucketId = sql.NullInt64{Int64: bucketId, Valid: true} }
+}
+
+// Stopped marks the task as stopped before the end of its estimate.
+func Stopped(task *tasks.Task) {
+	task.Completed = 0
+}
+
+// Abandoned leaves the task unfinished.
+func Abandoned(task *tasks.Task) {
+	task.FinishedAt = sql.NullTime{}
+}
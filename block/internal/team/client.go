@@ -0,0 +1,68 @@
+package team
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// BatchSize is how many sessions are sent per request.
+const BatchSize = 500
+
+// Client pushes sessions to a team server as one member.
+type Client struct {
+	URL   string
+	Token string
+	HTTP  *http.Client
+}
+
+func NewClient(serverURL, token string) (*Client, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("Error, team server '%s' must be an http or https url", serverURL)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("Error, no team token, ask the server's admin to run `block team member add`")
+	}
+
+	return &Client{
+		URL:   strings.TrimSuffix(serverURL, "/"),
+		Token: token,
+		HTTP:  &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (c *Client) Push(ctx context.Context, sessions []Session) (PushResult, error) {
+	var result PushResult
+
+	data, err := json.Marshal(sessions)
+	if err != nil {
+		return result, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL+"/api/sessions", bytes.NewReader(data))
+	if err != nil {
+		return result, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return result, fmt.Errorf("Error reaching team server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return result, fmt.Errorf("Error, team server responded %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return result, json.NewDecoder(resp.Body).Decode(&result)
+}
@@ -0,0 +1,365 @@
+package team
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrUnauthorised is returned for a token that belongs to no member.
+	ErrUnauthorised = errors.New("Error, missing or invalid team token")
+	ErrNoMember     = errors.New("Error, no such member")
+)
+
+// storeSchema is the team server's database. Members are known by a token, of which only a
+// hash is kept.
+const storeSchema = `
+	CREATE TABLE IF NOT EXISTS Members
+	(
+	  member_id  INTEGER PRIMARY KEY AUTOINCREMENT
+	, name       TEXT NOT NULL UNIQUE COLLATE NOCASE
+	, token_hash TEXT NOT NULL UNIQUE
+	, created_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS Sessions
+	(
+	  member_id         INTEGER NOT NULL REFERENCES Members (member_id)
+	, session_uuid      TEXT NOT NULL
+	, started_at        TIMESTAMP NOT NULL
+	, finished_at       TIMESTAMP NOT NULL
+	, estimated_seconds INTEGER NOT NULL
+	, actual_seconds    INTEGER NOT NULL
+	, completed         INTEGER NOT NULL
+	, bucket            TEXT NOT NULL DEFAULT ''
+	, task_name         TEXT NOT NULL DEFAULT ''
+	, received_at       TIMESTAMP NOT NULL
+	, PRIMARY KEY (member_id, session_uuid)
+	);
+
+	CREATE INDEX IF NOT EXISTS sessions_started_at ON Sessions (started_at);
+`
+
+type Member struct {
+	MemberId  int64     `db:"member_id"`
+	Name      string    `db:"name"`
+	TokenHash string    `db:"token_hash"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// StoredSession is a session as the team server keeps it.
+type StoredSession struct {
+	MemberId         int64     `db:"member_id"`
+	Member           string    `db:"member"`
+	UUID             string    `db:"session_uuid"`
+	StartedAt        time.Time `db:"started_at"`
+	FinishedAt       time.Time `db:"finished_at"`
+	EstimatedSeconds int64     `db:"estimated_seconds"`
+	ActualSeconds    int64     `db:"actual_seconds"`
+	Completed        bool      `db:"completed"`
+	Bucket           string    `db:"bucket"`
+	TaskName         string    `db:"task_name"`
+	ReceivedAt       time.Time `db:"received_at"`
+}
+
+// Store is the team server's database.
+type Store struct {
+	db *sqlx.DB
+}
+
+// OpenStore opens or creates the team database at dataSourceName.
+func OpenStore(dataSourceName string) (*Store, error) {
+	db, err := sqlx.Connect("sqlite", dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(storeSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Error initalising team schema: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// AddMember adds a member and returns the token their CLI pushes with. The token is not
+// stored and cannot be shown again.
+func (s *Store) AddMember(ctx context.Context, name string, now time.Time) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("Error, member name must not be empty")
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(secret)
+
+	_, err := s.db.ExecContext(ctx, "INSERT INTO Members (name, token_hash, created_at) VALUES (?, ?, ?)", name, hashToken(token), now)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE") {
+		return "", fmt.Errorf("Error, member '%s' already exists", name)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// RemoveMember removes a member with every session they pushed.
+func (s *Store) RemoveMember(ctx context.Context, name string) error {
+	member, err := s.Member(ctx, name)
+	if errors.Is(err, ErrNoMember) {
+		return fmt.Errorf("Error, no member named '%s'", name)
+	}
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM Sessions WHERE member_id = ?", member.MemberId); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM Members WHERE member_id = ?", member.MemberId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) Members(ctx context.Context) ([]Member, error) {
+	var members []Member
+	err := s.db.SelectContext(ctx, &members, "SELECT * FROM Members ORDER BY name ASC")
+	return members, err
+}
+
+func (s *Store) Member(ctx context.Context, name string) (Member, error) {
+	var member Member
+	err := s.db.GetContext(ctx, &member, "SELECT * FROM Members WHERE name = ?", name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return member, ErrNoMember
+	}
+	return member, err
+}
+
+// Authenticate returns the member a token belongs to.
+func (s *Store) Authenticate(ctx context.Context, token string) (Member, error) {
+	var member Member
+	if token == "" {
+		return member, ErrUnauthorised
+	}
+
+	err := s.db.GetContext(ctx, &member, "SELECT * FROM Members WHERE token_hash = ?", hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return member, ErrUnauthorised
+	}
+	return member, err
+}
+
+// Save stores a member's sessions, replacing earlier versions and removing deleted ones.
+func (s *Store) Save(ctx context.Context, member Member, sessions []Session, now time.Time) (PushResult, error) {
+	var result PushResult
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	for _, session := range sessions {
+		if session.UUID == "" {
+			return result, errors.New("Error, session is missing its uuid")
+		}
+
+		if session.Deleted {
+			res, err := tx.ExecContext(ctx, "DELETE FROM Sessions WHERE member_id = ? AND session_uuid = ?", member.MemberId, session.UUID)
+			if err != nil {
+				return result, err
+			}
+			n, _ := res.RowsAffected()
+			result.Removed += int(n)
+			continue
+		}
+
+		if session.ActualSeconds < 0 || session.EstimatedSeconds < 0 || session.FinishedAt.Before(session.StartedAt) {
+			return result, fmt.Errorf("Error, session %s has negative durations or finishes before it starts", session.UUID)
+		}
+
+		query := `INSERT OR REPLACE INTO Sessions
+		(member_id, session_uuid, started_at, finished_at, estimated_seconds, actual_seconds, completed, bucket, task_name, received_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		_, err := tx.ExecContext(ctx, query, member.MemberId, session.UUID, session.StartedAt.UTC(), session.FinishedAt.UTC(),
+			session.EstimatedSeconds, session.ActualSeconds, session.Completed, session.Bucket, session.TaskName, now)
+		if err != nil {
+			return result, err
+		}
+		result.Stored++
+	}
+
+	return result, tx.Commit()
+}
+
+// Sessions returns the sessions started within [start, end), newest first. A memberId of 0
+// returns every member's.
+func (s *Store) Sessions(ctx context.Context, memberId int64, start, end time.Time) ([]StoredSession, error) {
+	query := `SELECT s.*, m.name AS member FROM Sessions s JOIN Members m ON m.member_id = s.member_id
+	WHERE s.started_at >= ? AND s.started_at < ? AND (? = 0 OR s.member_id = ?)
+	ORDER BY s.started_at DESC`
+
+	var sessions []StoredSession
+	err := s.db.SelectContext(ctx, &sessions, query, start.UTC(), end.UTC(), memberId, memberId)
+	return sessions, err
+}
+
+// Totals sums a set of sessions.
+type Totals struct {
+	Sessions     int
+	Completed    int
+	FocusSeconds int64
+}
+
+func (t *Totals) add(session StoredSession) {
+	t.Sessions++
+	t.FocusSeconds += session.ActualSeconds
+	if session.Completed {
+		t.Completed++
+	}
+}
+
+type MemberTotals struct {
+	Name string
+	Totals
+	LastSession time.Time
+}
+
+type BucketTotals struct {
+	Bucket  string // empty for sessions without a shared bucket.
+	Members int
+	Totals
+}
+
+type DayTotals struct {
+	Date time.Time
+	Totals
+}
+
+// Dashboard is focus time over a period, for the team or one member.
+type Dashboard struct {
+	Start, End time.Time
+	Total      Totals
+	Members    []MemberTotals // every member, most focus first, including those with none.
+	Buckets    []BucketTotals // most focus first.
+	Days       []DayTotals
+	Sessions   []StoredSession // newest first.
+}
+
+// Dashboard totals the sessions in [start, end), for every member when member is empty or for
+// the named member.
+func (s *Store) Dashboard(ctx context.Context, cal calendar.Calendar, start, end time.Time, member string) (Dashboard, error) {
+	d := Dashboard{Start: start, End: end}
+
+	members, err := s.Members(ctx)
+	if err != nil {
+		return d, err
+	}
+
+	var memberId int64
+	if member != "" {
+		m, err := s.Member(ctx, member)
+		if err != nil {
+			return d, err
+		}
+		memberId, members = m.MemberId, []Member{m}
+	}
+
+	d.Sessions, err = s.Sessions(ctx, memberId, start, end)
+	if err != nil {
+		return d, err
+	}
+
+	byMember := make(map[int64]*MemberTotals)
+	for _, m := range members {
+		d.Members = append(d.Members, MemberTotals{Name: m.Name})
+	}
+	for i, m := range members {
+		byMember[m.MemberId] = &d.Members[i]
+	}
+
+	byBucket := make(map[string]*BucketTotals)
+	bucketMembers := make(map[string]map[int64]bool)
+
+	for day := cal.Date(start); day.Before(cal.Date(end)); day = cal.AddDays(day, 1) {
+		d.Days = append(d.Days, DayTotals{Date: day})
+	}
+
+	for _, session := range d.Sessions {
+		d.Total.add(session)
+
+		if m := byMember[session.MemberId]; m != nil {
+			m.add(session)
+			if session.StartedAt.After(m.LastSession) {
+				m.LastSession = session.StartedAt
+			}
+		}
+
+		key := strings.ToLower(session.Bucket)
+		if byBucket[key] == nil {
+			byBucket[key] = &BucketTotals{Bucket: session.Bucket}
+			bucketMembers[key] = make(map[int64]bool)
+		}
+		byBucket[key].add(session)
+		bucketMembers[key][session.MemberId] = true
+
+		date := cal.Date(session.StartedAt)
+		for i := range d.Days {
+			if d.Days[i].Date.Equal(date) {
+				d.Days[i].add(session)
+				break
+			}
+		}
+	}
+
+	for key, b := range byBucket {
+		b.Members = len(bucketMembers[key])
+		d.Buckets = append(d.Buckets, *b)
+	}
+
+	sort.SliceStable(d.Members, func(i, j int) bool {
+		return d.Members[i].FocusSeconds > d.Members[j].FocusSeconds
+	})
+	sort.Slice(d.Buckets, func(i, j int) bool {
+		if d.Buckets[i].FocusSeconds != d.Buckets[j].FocusSeconds {
+			return d.Buckets[i].FocusSeconds > d.Buckets[j].FocusSeconds
+		}
+		return d.Buckets[i].Bucket < d.Buckets[j].Bucket
+	})
+
+	return d, nil
+}
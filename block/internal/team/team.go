@@ -0,0 +1,155 @@
+// Package team shares finished sessions with a team server, which shows focus time for each
+// member and for the team as a whole.
+//
+// Members choose what leaves their machine: durations are always sent, buckets unless turned
+// off, and task names only when turned on. Sessions in excluded buckets are never sent, and
+// are taken off the server if they were sent before.
+package team
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/config"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/jmoiron/sqlx"
+)
+
+// Session is a finished session as sent to the team server.
+type Session struct {
+	UUID             string    `json:"uuid"`
+	StartedAt        time.Time `json:"started_at"`
+	FinishedAt       time.Time `json:"finished_at"`
+	EstimatedSeconds int64     `json:"estimated_seconds"`
+	ActualSeconds    int64     `json:"actual_seconds"`
+	Completed        bool      `json:"completed"`
+	Bucket           string    `json:"bucket,omitempty"`
+	TaskName         string    `json:"task_name,omitempty"`
+	Deleted          bool      `json:"deleted,omitempty"` // take the session off the server.
+}
+
+// Sharing is what a member shares beyond durations.
+type Sharing struct {
+	TaskNames      bool
+	Buckets        bool
+	ExcludeBuckets []string
+}
+
+func NewSharing(cfg config.TeamConfig) Sharing {
+	return Sharing{TaskNames: cfg.ShareTaskNames, Buckets: cfg.ShareBuckets, ExcludeBuckets: cfg.ExcludeBuckets}
+}
+
+func (s Sharing) excluded(bucket string) bool {
+	return bucket != "" && slices.ContainsFunc(s.ExcludeBuckets, func(name string) bool {
+		return strings.EqualFold(name, bucket)
+	})
+}
+
+// Session converts a finished task, keeping only what s shares.
+func (s Sharing) Session(task tasks.Task, bucket string) Session {
+	session := Session{UUID: task.TaskUUID}
+	if task.DeletedAt.Valid || s.excluded(bucket) {
+		session.Deleted = true
+		return session
+	}
+
+	session.StartedAt = task.CreatedAt
+	session.FinishedAt = task.FinishedAt.Time
+	session.EstimatedSeconds = task.EstimatedDurationSeconds
+	session.ActualSeconds = task.ActualDurationSeconds.Int64
+	session.Completed = task.Completed == 1
+	if s.Buckets {
+		session.Bucket = bucket
+	}
+	if s.TaskNames {
+		session.TaskName = task.TaskName
+	}
+
+	return session
+}
+
+// Outgoing returns the finished sessions updated after since, as s shares them, with the
+// latest update among them.
+func Outgoing(ctx context.Context, db *sqlx.DB, s Sharing, since time.Time) ([]Session, time.Time, error) {
+	// updated_at is compared as a julian day, as rows hold it with different utc offsets.
+	var changed []tasks.Task
+	query := "SELECT * FROM Tasks WHERE finished_at IS NOT NULL AND julianday(updated_at) > julianday(?) ORDER BY julianday(updated_at) ASC"
+	err := db.SelectContext(ctx, &changed, query, since.UTC())
+	if err != nil {
+		return nil, since, err
+	}
+
+	allBuckets, err := buckets.GetAllBuckets(db)
+	if err != nil {
+		return nil, since, err
+	}
+	bucketNames := make(map[int64]string)
+	for _, b := range allBuckets {
+		bucketNames[b.BucketId] = b.BucketName
+	}
+
+	var sessions []Session
+	latest := since
+	for _, task := range changed {
+		sessions = append(sessions, s.Session(task, bucketNames[task.BucketId.Int64]))
+		if task.UpdatedAt.Time.After(latest) {
+			latest = task.UpdatedAt.Time
+		}
+	}
+
+	return sessions, latest, nil
+}
+
+// PushResult counts what the server did with pushed sessions.
+type PushResult struct {
+	Stored  int `json:"stored"`
+	Removed int `json:"removed"`
+}
+
+// Push sends the sessions changed since the last push to client's server. With all set every
+// session is sent again, such as after changing what is shared.
+func Push(ctx context.Context, db *sqlx.DB, client *Client, s Sharing, all bool) (PushResult, error) {
+	var result PushResult
+
+	cursorName := "team cursor " + client.URL
+	var since time.Time
+	if !all {
+		var value string
+		err := db.GetContext(ctx, &value, "SELECT value FROM SyncState WHERE name = ?", cursorName)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return result, err
+		}
+		if value != "" {
+			since, err = time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return result, err
+			}
+		}
+	}
+
+	sessions, latest, err := Outgoing(ctx, db, s, since)
+	if err != nil {
+		return result, err
+	}
+
+	for start := 0; start < len(sessions); start += BatchSize {
+		resp, err := client.Push(ctx, sessions[start:min(start+BatchSize, len(sessions))])
+		if err != nil {
+			return result, err
+		}
+		result.Stored += resp.Stored
+		result.Removed += resp.Removed
+	}
+
+	if latest.IsZero() {
+		return result, nil
+	}
+	query := "INSERT INTO SyncState (name, value) VALUES (?, ?) ON CONFLICT (name) DO UPDATE SET value = excluded.value"
+	_, err = db.ExecContext(ctx, query, cursorName, latest.UTC().Format(time.RFC3339Nano))
+	return result, err
+}
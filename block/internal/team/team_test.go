@@ -0,0 +1,185 @@
+package team
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/buckets"
+	"github.com/connorkuljis/block-cli/internal/calendar"
+	"github.com/connorkuljis/block-cli/internal/db/dbtest"
+	"github.com/connorkuljis/block-cli/internal/tasks"
+)
+
+func finished(name string, startedAt time.Time, minutes int64) tasks.Task {
+	task := tasks.NewTask(name, minutes*60, false, false, startedAt)
+	task.AccumulateSegment(int(minutes*60), true)
+	task.SetFinishTime(startedAt.Add(time.Duration(minutes) * time.Minute))
+	return *task
+}
+
+func TestSharingSession(t *testing.T) {
+	task := finished("client-x invoice", time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC), 25)
+
+	private := Sharing{Buckets: true}.Session(task, "work")
+	if private.TaskName != "" || private.Bucket != "work" || private.ActualSeconds != 1500 {
+		t.Errorf("Expected the duration and bucket without the name, got: %+v", private)
+	}
+
+	open := Sharing{TaskNames: true}.Session(task, "work")
+	if open.TaskName != "client-x invoice" || open.Bucket != "" {
+		t.Errorf("Expected the name without the bucket, got: %+v", open)
+	}
+
+	excluded := Sharing{TaskNames: true, Buckets: true, ExcludeBuckets: []string{"Work"}}.Session(task, "work")
+	if !excluded.Deleted || excluded.TaskName != "" || excluded.ActualSeconds != 0 {
+		t.Errorf("Expected an excluded session to be sent only as a removal, got: %+v", excluded)
+	}
+}
+
+func openStore(t *testing.T) *Store {
+	t.Helper()
+
+	store, err := OpenStore(filepath.Join(t.TempDir(), "team.db") + "?_time_format=sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	return store
+}
+
+func TestDashboard(t *testing.T) {
+	ctx := context.Background()
+	store := openStore(t)
+	cal, _ := calendar.New(time.UTC, 0)
+	monday := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+
+	members := make(map[string]Member)
+	for _, name := range []string{"ana", "ben", "cy"} {
+		token, err := store.AddMember(ctx, name, monday)
+		if err != nil {
+			t.Fatal(err)
+		}
+		members[name], err = store.Authenticate(ctx, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	save := func(member string, sessions ...Session) {
+		t.Helper()
+		if _, err := store.Save(ctx, members[member], sessions, monday); err != nil {
+			t.Fatal(err)
+		}
+	}
+	session := func(uuid string, startedAt time.Time, minutes int64, bucket string) Session {
+		return Session{UUID: uuid, StartedAt: startedAt, FinishedAt: startedAt.Add(time.Duration(minutes) * time.Minute), ActualSeconds: minutes * 60, Bucket: bucket}
+	}
+
+	save("ana", session("a1", monday, 25, "work"), session("a2", monday.AddDate(0, 0, 1), 50, "work"))
+	save("ben", session("b1", monday, 30, "work"), session("b2", monday, 10, ""))
+	save("ben", Session{UUID: "b2", Deleted: true})
+	save("ana", session("a3", monday.AddDate(0, 0, 7), 60, "work")) // the next week.
+
+	start, end := cal.WeekBounds(monday)
+	d, err := store.Dashboard(ctx, cal, start, end, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Total.Sessions != 3 || d.Total.FocusSeconds != 105*60 {
+		t.Errorf("Expected 3 sessions of 105m in the week, got: %+v", d.Total)
+	}
+	if len(d.Members) != 3 || d.Members[0].Name != "ana" || d.Members[0].FocusSeconds != 75*60 || d.Members[2].Sessions != 0 {
+		t.Errorf("Expected ana first with 75m and cy listed with none, got: %+v", d.Members)
+	}
+	if len(d.Buckets) != 1 || d.Buckets[0].Members != 2 {
+		t.Errorf("Expected work shared by two members, got: %+v", d.Buckets)
+	}
+	if len(d.Days) != 7 || d.Days[0].FocusSeconds != 55*60 {
+		t.Errorf("Expected 55m on monday, got: %+v", d.Days)
+	}
+
+	ben, err := store.Dashboard(ctx, cal, start, end, "ben")
+	if err != nil || len(ben.Sessions) != 1 || ben.Sessions[0].UUID != "b1" {
+		t.Errorf("Expected ben's one remaining session, got: %+v %v", ben.Sessions, err)
+	}
+}
+
+func TestPush(t *testing.T) {
+	ctx := context.Background()
+	store := openStore(t)
+	token, err := store.AddMember(ctx, "ana", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		member, err := store.Authenticate(r.Context(), strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		var sessions []Session
+		json.NewDecoder(r.Body).Decode(&sessions)
+		result, err := store.Save(r.Context(), member, sessions, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(result)
+	}))
+	defer ts.Close()
+
+	local := dbtest.Open(t)
+
+	bucket := buckets.NewBucket("work")
+	if err := buckets.InsertBucket(local, bucket); err != nil {
+		t.Fatal(err)
+	}
+	task := finished("write report", time.Now().Add(-time.Hour), 25)
+	task.BucketId = sql.NullInt64{Int64: bucket.BucketId, Valid: true}
+	if err := tasks.NewStore(local).Insert(ctx, &task); err != nil {
+		t.Fatal(err)
+	}
+	if err := tasks.NewStore(local).Finish(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+	running := tasks.NewTask("running", 1500, false, false, time.Now())
+	if err := tasks.NewStore(local).Insert(ctx, running); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewClient(ts.URL, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sharing := Sharing{Buckets: true}
+
+	result, err := Push(ctx, local, client, sharing, false)
+	if err != nil || result.Stored != 1 {
+		t.Fatalf("Expected only the finished session pushed, got: %+v %v", result, err)
+	}
+	if result, _ := Push(ctx, local, client, sharing, false); result.Stored != 0 {
+		t.Errorf("Expected nothing new to push, got: %+v", result)
+	}
+
+	if _, err := tasks.NewStore(local).Delete(ctx, []int64{task.TaskId}, "test", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := Push(ctx, local, client, sharing, false); result.Removed != 1 {
+		t.Errorf("Expected the deleted session removed from the server, got: %+v", result)
+	}
+
+	client.Token = "wrong"
+	if _, err := Push(ctx, local, client, sharing, true); err == nil {
+		t.Error("Expected an error with the wrong token")
+	}
+}
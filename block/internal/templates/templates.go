@@ -0,0 +1,129 @@
+package templates
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/connorkuljis/block-cli/internal/tasks"
+	"github.com/connorkuljis/block-cli/internal/utils"
+	"github.com/jmoiron/sqlx"
+)
+
+// Prefix marks a template reference in place of a duration, eg. `block start @standup`.
+const Prefix = "@"
+
+// Template bundles the settings of a recurring task so it can be started by name.
+type Template struct {
+	TemplateId      int64         `db:"template_id"`
+	TemplateName    string        `db:"template_name"`
+	TaskName        string        `db:"task_name"`
+	DurationSeconds int64         `db:"duration_seconds"`
+	BlockerEnabled  int           `db:"blocker_enabled"`
+	ScreenEnabled   int           `db:"screen_enabled"`
+	BucketId        sql.NullInt64 `db:"bucket_id"`
+	CreatedAt       time.Time     `db:"created_at"`
+}
+
+// NewTemplate returns a template, an empty taskName defaults to the template name.
+func NewTemplate(templateName, taskName string, durationSeconds int64, blockerEnabled, screenEnabled bool, createdAt time.Time) *Template {
+	templateName = strings.TrimPrefix(templateName, Prefix)
+	if taskName == "" {
+		taskName = templateName
+	}
+
+	return &Template{
+		TemplateName:    templateName,
+		TaskName:        taskName,
+		DurationSeconds: durationSeconds,
+		BlockerEnabled:  utils.BoolToInt(blockerEnabled),
+		ScreenEnabled:   utils.BoolToInt(screenEnabled),
+		BucketId:        sql.NullInt64{Valid: false},
+		CreatedAt:       createdAt,
+	}
+}
+
+func (t *Template) AddBucketTag(bucketId int64) {
+	t.BucketId = sql.NullInt64{Int64: bucketId, Valid: true}
+}
+
+// NewTask returns a task with the template's settings, created at createdAt.
+func (t Template) NewTask(createdAt time.Time) *tasks.Task {
+	task := tasks.NewTask(t.TaskName, t.DurationSeconds, t.BlockerEnabled == 1, t.ScreenEnabled == 1, createdAt)
+	task.BucketId = t.BucketId
+	return task
+}
+
+// IsReference reports whether arg names a template, eg. "@standup".
+func IsReference(arg string) bool {
+	return strings.HasPrefix(arg, Prefix) && len(arg) > len(Prefix)
+}
+
+func InsertTemplate(db *sqlx.DB, t *Template) error {
+	query := `INSERT INTO Templates
+	(
+	  template_name
+	, task_name
+	, duration_seconds
+	, blocker_enabled
+	, screen_enabled
+	, bucket_id
+	, created_at
+	)
+	VALUES
+	(
+	  :template_name
+	, :task_name
+	, :duration_seconds
+	, :blocker_enabled
+	, :screen_enabled
+	, :bucket_id
+	, :created_at
+	)`
+
+	result, err := db.NamedExec(query, t)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	t.TemplateId = id
+
+	return nil
+}
+
+func GetAllTemplates(db *sqlx.DB) ([]Template, error) {
+	var templates []Template
+
+	err := db.Select(&templates, "SELECT * FROM Templates ORDER BY template_name ASC")
+	if err != nil {
+		return templates, err
+	}
+
+	return templates, nil
+}
+
+// GetTemplateByName accepts the name with or without the leading "@".
+func GetTemplateByName(db *sqlx.DB, name string) (Template, error) {
+	var t Template
+
+	err := db.Get(&t, "SELECT * FROM Templates WHERE template_name = ?", strings.TrimPrefix(name, Prefix))
+	if err != nil {
+		return t, err
+	}
+
+	return t, nil
+}
+
+func DeleteTemplateByName(db *sqlx.DB, name string) (int64, error) {
+	result, err := db.Exec("DELETE FROM Templates WHERE template_name = ?", strings.TrimPrefix(name, Prefix))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -6,10 +6,14 @@ import (
 	"log"
 	"log/slog"
 	"os"
+	"slices"
+	"strings"
 
+	"github.com/connorkuljis/block-cli/internal/calendar"
 	"github.com/connorkuljis/block-cli/internal/commands"
 	"github.com/connorkuljis/block-cli/internal/config"
 	"github.com/connorkuljis/block-cli/internal/db"
+	"github.com/connorkuljis/block-cli/internal/plugins"
 
 	"github.com/urfave/cli/v2"
 )
@@ -17,47 +21,184 @@ import (
 //go:embed www
 var www embed.FS
 
+// standalone commands run without the config or database.
+var standalone = []*cli.Command{
+	commands.ConfigCmd,
+	commands.WorkspaceCmd,
+	commands.ResetDNSCmd,
+	commands.UpCmd,
+	commands.DownCmd,
+	commands.PluginCmd,
+}
+
 func main() {
 	start()
 }
 
-func start() {
-	err := config.InitConfig() // TODO: Only load config if command requires it.
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	slog.Info("Loaded config.")
-
-	db, err := db.InitDB()
-	if err != nil {
-		log.Fatal(err)
-	}
+// withPlugins are the commands that send plugins events. Plugins are only started for these and for
+// commands block does not know, which may be a plugin's.
+var withPlugins = []*cli.Command{
+	commands.StartCmd,
+	commands.ResumeCmd,
+	commands.NextCmd,
+	commands.PluginCmd,
+}
 
-	slog.Info("Loaded db.")
+func start() {
+	var loaded []plugins.Loaded
 
 	app := &cli.App{
 		Name:  "block",
 		Usage: "block-cli blocks distractions from the command line. track tasks and capture your screen.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "workspace",
+				Aliases: []string{"w"},
+				EnvVars: []string{"BLOCK_WORKSPACE"},
+				Usage:   "Use a named workspace, with its own database and config.",
+			},
+			&cli.StringFlag{
+				Name:    "db",
+				EnvVars: []string{"BLOCK_DB"},
+				Usage:   "Use the database `file` instead of the workspace's.",
+			},
+			&cli.StringSliceFlag{
+				Name:  "set",
+				Usage: "Override a setting for this run, as `key=value`, such as --set goals.dailyMinutes=120.",
+			},
+		},
 		Before: func(c *cli.Context) error {
-			c.Context = context.WithValue(c.Context, "db", db)
+			paths, err := config.Resolve(config.Options{Workspace: c.String("workspace"), DbPath: c.String("db")})
+			if err != nil {
+				return err
+			}
+
+			c.Context = context.WithValue(c.Context, "paths", paths)
 			c.Context = context.WithValue(c.Context, "www", www)
+			c.Context = context.WithValue(c.Context, "plugins", loaded)
+
+			// Commands that do not touch tasks run without loading the config or database, so
+			// they still work when either is broken.
+			cmd := c.App.Command(c.Args().First())
+			if cmd == nil || cmd.Name == "help" || slices.Contains(standalone, cmd) {
+				return nil
+			}
+
+			// block db looks at the schema as it is, so it opens the database without migrating it.
+			if cmd == commands.DbCmd {
+				if err := paths.MakeDirs(); err != nil {
+					return err
+				}
+
+				conn, err := db.Connect(paths.DSN())
+				if err != nil {
+					return err
+				}
+
+				c.Context = context.WithValue(c.Context, "db", conn)
+				return nil
+			}
+
+			cfg, err := config.Load(paths, c.StringSlice("set"))
+			if err != nil {
+				return err
+			}
+
+			slog.Info("Loaded config.")
+
+			cal, err := calendar.Load(cfg.Config.Days.Timezone, cfg.Config.Days.StartHour)
+			if err != nil {
+				return err
+			}
+			calendar.SetDefault(cal)
+
+			db, err := db.Open(paths.DSN())
+			if err != nil {
+				return err
+			}
+
+			slog.Info("Loaded db.")
+
+			c.Context = context.WithValue(c.Context, "config", cfg)
+			c.Context = context.WithValue(c.Context, "db", db)
 			return nil
 		},
 		// TODO: Refactor out cli commands to a seperate module, with one command per file.
 		Commands: []*cli.Command{
 			commands.StartCmd,
+			commands.ResumeCmd,
+			commands.LogCmd,
+			commands.TodayCmd,
+			commands.PlanCmd,
+			commands.NextCmd,
+			commands.TemplateCmd,
+			commands.BucketCmd,
+			commands.DbCmd,
+			commands.WorkspaceCmd,
+			commands.ConfigCmd,
 			commands.HistoryCmd,
+			commands.SearchCmd,
+			commands.ReportCmd,
+			commands.EstimatesCmd,
+			commands.ExportCmd,
+			commands.ImportCmd,
+			commands.SyncCmd,
+			commands.TeamCmd,
 			commands.DeleteTaskCmd,
+			commands.RestoreCmd,
+			commands.UndoCmd,
 			commands.ServeCmd,
 			commands.GenerateCmd,
 			commands.ResetDNSCmd,
 			commands.UpCmd,
 			commands.DownCmd,
+			commands.PluginCmd,
 		},
 	}
 
+	if name := commandName(app, os.Args[1:]); name != "" {
+		if cmd := app.Command(name); cmd == nil || slices.Contains(withPlugins, cmd) {
+			loaded = plugins.Load(context.Background(), plugins.Discover(os.Getenv("PATH")))
+
+			pluginCmds := commands.PluginCommands(loaded, app.Commands)
+			app.Commands = append(app.Commands, pluginCmds...)
+			standalone = append(standalone, pluginCmds...)
+		}
+	}
+
 	if err := app.Run(os.Args); err != nil {
 		log.Fatal(err)
 	}
 }
+
+// commandName finds the command in args, skipping the app's flags and their values. It is empty
+// when no command is given.
+func commandName(app *cli.App, args []string) string {
+	takesValue := make(map[string]bool)
+	for _, flag := range app.Flags {
+		_, isBool := flag.(*cli.BoolFlag)
+		for _, name := range flag.Names() {
+			takesValue[name] = !isBool
+		}
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			if i+1 < len(args) {
+				return args[i+1]
+			}
+			return ""
+		}
+		if !strings.HasPrefix(arg, "-") {
+			return arg
+		}
+
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if takesValue[name] && !hasValue {
+			i++
+		}
+	}
+
+	return ""
+}